@@ -0,0 +1,100 @@
+// Command rislive-proxy keeps one upstream connection to RIS Live and
+// serves any number of downstream clients with the RIS Live WebSocket
+// protocol, applying their subscriptions locally. Clients without
+// WebSocket support can use /v1/stream/ with Server-Sent Events or NDJSON,
+// and with -grpc the RisLive gRPC service streams decoded route events.
+package main
+
+import (
+	"context"
+	"flag"
+	stdlog "log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/a16/go-rislive/pkg/client"
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/a16/go-rislive/pkg/proxy"
+	"github.com/a16/go-rislive/pkg/rislivegrpc"
+	"github.com/a16/go-rislive/pkg/rislivepb"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+var log = logrus.New()
+
+func main() {
+	listen := flag.String("listen", ":8080", "address to serve downstream clients on")
+	upstream := flag.String("upstream", client.DefaultURL, "RIS Live WebSocket URL")
+	name := flag.String("client", "go-rislive-proxy", "client name sent upstream")
+	host := flag.String("host", "", "only subscribe upstream to this collector")
+	grpcListen := flag.String("grpc", "", "address to serve the gRPC API on, disabled if empty")
+	buffer := flag.Int("buffer", proxy.DefaultMaxBuffer, "messages queued per client before it is disconnected")
+	flag.Parse()
+
+	f := rislive.NewFilter()
+	f.SetHost(*host)
+	f.SetSocketOptions(true)
+
+	s := proxy.NewServer()
+	s.MaxBuffer = *buffer
+	s.ErrorLog = stdlog.New(log.WriterLevel(logrus.WarnLevel), "", 0)
+
+	c := client.New(f)
+	c.URL = *upstream
+	c.Name = *name
+	c.OnConnect = func() { log.Infof("connected to %s", *upstream) }
+	c.OnDisconnect = func(err error) { log.Warnf("disconnected from %s: %v", *upstream, err) }
+
+	g := rislivegrpc.NewServer(c.RrcList)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sig
+		cancel()
+	}()
+
+	go c.Run(ctx, func(frame []byte, m *rislive.RisLiveMessage) {
+		if m == nil {
+			log.Warnf("undecodable upstream frame: %s", frame)
+			return
+		}
+		if e, ok := m.AsError(); ok {
+			log.Warnf("upstream error: %s", e.Message)
+		}
+		s.HandleUpstream(frame, m)
+		g.HandleUpstream(frame, m)
+	})
+
+	if *grpcListen != "" {
+		lis, err := net.Listen("tcp", *grpcListen)
+		if err != nil {
+			log.Fatal(err)
+		}
+		gs := grpc.NewServer()
+		rislivepb.RegisterRisLiveServer(gs, g)
+		go func() {
+			<-ctx.Done()
+			gs.Stop()
+		}()
+		log.Infof("serving gRPC on %s", *grpcListen)
+		go gs.Serve(lis)
+	}
+
+	srv := &http.Server{Addr: *listen, Handler: s.Handler()}
+	go func() {
+		<-ctx.Done()
+		s.Close()
+		srv.Close()
+	}()
+	log.Infof("listening on %s", *listen)
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+}
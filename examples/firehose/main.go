@@ -3,7 +3,6 @@ package main
 import (
 	"bufio"
 	"encoding/json"
-	"math"
 	"net/http"
 	"net/url"
 	"os"
@@ -15,12 +14,6 @@ import (
 
 var log = logrus.New()
 
-func ConvertTime(f float64) time.Time {
-	t1, t2 := math.Modf(f)
-	t2 = math.Ceil(t2 * math.Pow10(6))
-	return time.Unix(int64(t1), int64(t2)).UTC()
-}
-
 func main() {
 	log.SetFormatter(&logrus.JSONFormatter{
 		FieldMap: logrus.FieldMap{
@@ -55,34 +48,23 @@ func main() {
 		if err != nil {
 			log.Fatalf("%v: %v\n", err, scanner.Text())
 		}
-		switch msg.Type {
-		case "ris_message":
-			switch msg.BgpMsgType {
-			case "UPDATE":
-				update := msg.Data.(*rislive.RisMessageUpdate)
+		if update, ok := msg.AsUpdate(); ok {
+			for _, ev := range update.RouteEvents() {
 				fields := logrus.Fields{
-					"Type":     update.Type,
-					"RcvdTime": ConvertTime(update.Timestamp),
-					"Peer":     update.Peer,
-					"PeerASN":  update.PeerASN,
-				}
-				for _, a := range update.Announcements {
-					for _, prefix := range a.Prefixes {
-						fields["NextHop"] = a.NextHop
-						fields["Prefix"] = prefix
-						fields["AsPath"] = update.Path
-						fields["Origin"] = update.Origin
-						fields["AnnouncementOrWithdrawal"] = "Announcement"
-						log.WithFields(fields).Info()
-					}
+					"Type":                     update.Type,
+					"RcvdTime":                 ev.Time,
+					"Peer":                     ev.Peer,
+					"PeerASN":                  ev.PeerASN,
+					"Prefix":                   ev.Prefix,
+					"AnnouncementOrWithdrawal": "Withdrawal",
 				}
-				for _, w := range update.Withdrawals {
-					for _, pfx := range w {
-						fields["Prefix"] = pfx
-						fields["AnnouncementOrWithdrawal"] = "Withdrawal"
-						log.WithFields(fields).Info()
-					}
+				if ev.Kind == rislive.RouteAnnouncement {
+					fields["NextHop"] = ev.NextHop
+					fields["AsPath"] = ev.Path
+					fields["Origin"] = update.Origin
+					fields["AnnouncementOrWithdrawal"] = "Announcement"
 				}
+				log.WithFields(fields).Info()
 			}
 		}
 	}
@@ -42,9 +42,10 @@ func main() {
 		log.Println("unmarshal:", err)
 		return
 	}
-	if msg.Type != "ris_rrc_list" {
+	rrcs, ok := msg.AsRrcList()
+	if !ok {
 		log.Println("Received unexpected message: ", msg.Type)
 		return
 	}
-	log.Println(msg.Data.(rislive.RisRrcList))
+	log.Println(rrcs)
 }
@@ -3,7 +3,6 @@ package main
 import (
 	"encoding/json"
 	"log"
-	"math"
 	"net/url"
 	"time"
 
@@ -80,12 +79,6 @@ func main() {
 	}
 }
 
-func FloatToTime(f float64) time.Time {
-	t1, t2 := math.Modf(f)
-	t2 = math.Ceil(t2 * math.Pow10(6))
-	return time.Unix(int64(t1), int64(t2)).UTC()
-}
-
 func risliveWorker(queue chan *rislive.RisLiveMessage, doneCh chan struct{}) {
 	defer func() {
 		doneCh <- struct{}{}
@@ -95,33 +88,41 @@ func risliveWorker(queue chan *rislive.RisLiveMessage, doneCh chan struct{}) {
 	defer t.Stop()
 	counter := 0
 	for msg := range queue {
-		switch msg.Type {
-		case "ris_error":
-			risErr := msg.Data.(*rislive.RisError)
-			log.Printf("ris_error: %v, %v", risErr.CommandType, risErr.Message)
-		case "ris_message":
+		if msg.Type == "ris_message" {
 			counter += 1
-			switch msg.BgpMsgType {
-			case "OPEN":
-				risMsgOpen := msg.Data.(*rislive.RisMessageOpen)
-				log.Printf("ris_message(OPEN): %v, %v", risMsgOpen.Timestamp, risMsgOpen.Raw)
-			case "UPDATE":
-				risMsgUpdate := msg.Data.(*rislive.RisMessageUpdate)
-				log.Println(msg.Data)
-				log.Printf("ris_message(UPDATE): %v, %v", risMsgUpdate.Timestamp, risMsgUpdate.Raw)
-			case "KEEPALIVE":
-				risMsgKeepalive := msg.Data.(*rislive.RisMessageKeepalive)
-				log.Printf("ris_message(KEEPALIVE): %v, %v", risMsgKeepalive.Timestamp, risMsgKeepalive.Raw)
-			case "NOTIFICATION":
-				risMsgNotification := msg.Data.(*rislive.RisMessageNotification)
-				log.Printf("ris_message(NOTIFICATION): %v, %v", risMsgNotification.Timestamp, risMsgNotification.Raw)
-			case "RIS_PEER_STATE":
-				risMsgRisPeerState := msg.Data.(*rislive.RisMessageRisPeerState)
-				log.Printf("ris_message(PEER_STATE): %v", FloatToTime(risMsgRisPeerState.Timestamp))
-			default:
-				log.Printf("UNKNOWN: %#v", msg.Data)
-				return
-			}
+		}
+		if err := msg.Dispatch(logHandler{}); err != nil {
+			log.Printf("UNKNOWN: %#v", msg.Data)
+			return
 		}
 	}
 }
+
+type logHandler struct {
+	rislive.NopHandler
+}
+
+func (logHandler) HandleError(risErr *rislive.RisError) {
+	log.Printf("ris_error: %v, %v", risErr.CommandType, risErr.Message)
+}
+
+func (logHandler) HandleOpen(risMsgOpen *rislive.RisMessageOpen) {
+	log.Printf("ris_message(OPEN): %v, %v", risMsgOpen.Timestamp, risMsgOpen.Raw)
+}
+
+func (logHandler) HandleUpdate(risMsgUpdate *rislive.RisMessageUpdate) {
+	log.Println(risMsgUpdate)
+	log.Printf("ris_message(UPDATE): %v, %v", risMsgUpdate.Timestamp, risMsgUpdate.Raw)
+}
+
+func (logHandler) HandleKeepalive(risMsgKeepalive *rislive.RisMessageKeepalive) {
+	log.Printf("ris_message(KEEPALIVE): %v, %v", risMsgKeepalive.Timestamp, risMsgKeepalive.Raw)
+}
+
+func (logHandler) HandleNotification(risMsgNotification *rislive.RisMessageNotification) {
+	log.Printf("ris_message(NOTIFICATION): %v, %v", risMsgNotification.Timestamp, risMsgNotification.Raw)
+}
+
+func (logHandler) HandlePeerState(risMsgRisPeerState *rislive.RisMessageRisPeerState) {
+	log.Printf("ris_message(PEER_STATE): %v", risMsgRisPeerState.GetTimestamp())
+}
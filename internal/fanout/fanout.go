@@ -0,0 +1,342 @@
+// Package fanout delivers RIS Live frames to many subscribers, each with
+// its own subscriptions and bounded queue, and answers their RIS Live
+// commands. It is shared by the proxy and the test server.
+package fanout
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/gorilla/websocket"
+)
+
+// Hub is a set of subscribers. It is safe for concurrent use.
+type Hub struct {
+	mu      sync.Mutex
+	subs    map[*Subscriber]struct{}
+	rrcList rislive.RisRrcList
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: map[*Subscriber]struct{}{}}
+}
+
+// SetRrcList sets the collectors returned for request_rrc_list.
+func (h *Hub) SetRrcList(rrcs rislive.RisRrcList) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.rrcList = append(rislive.RisRrcList(nil), rrcs...)
+}
+
+func (h *Hub) rrcs() rislive.RisRrcList {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append(rislive.RisRrcList{}, h.rrcList...)
+}
+
+// Len returns the number of subscribers.
+func (h *Hub) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
+
+// Subscribers returns the current subscribers.
+func (h *Hub) Subscribers() []*Subscriber {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	subs := make([]*Subscriber, 0, len(h.subs))
+	for s := range h.subs {
+		subs = append(subs, s)
+	}
+	return subs
+}
+
+// Add registers a subscriber queueing up to maxBuffer frames.
+func (h *Hub) Add(maxBuffer int, filters ...*rislive.Filter) *Subscriber {
+	s := &Subscriber{
+		hub:       h,
+		send:      make(chan []byte, maxBuffer),
+		done:      make(chan struct{}),
+		maxBuffer: maxBuffer,
+		filters:   filters,
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subs[s] = struct{}{}
+	return s
+}
+
+// Remove unregisters a subscriber.
+func (h *Hub) Remove(s *Subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subs, s)
+}
+
+// Publish delivers a ris_message frame to the subscribers whose
+// subscriptions match m, the decoded frame. data.raw is removed for those
+// that did not ask for it; if that fails they are skipped, the others still
+// get the frame and the error is returned. Frames of other types are sent
+// to every subscriber.
+func (h *Hub) Publish(frame []byte, m *rislive.RisLiveMessage) error {
+	if m.Type != "ris_message" {
+		h.Broadcast(frame)
+		return nil
+	}
+	var stripped []byte
+	var stripErr error
+	for _, s := range h.Subscribers() {
+		match, raw := s.Match(m)
+		if !match {
+			continue
+		}
+		out := frame
+		if !raw && m.Raw != "" {
+			if stripped == nil && stripErr == nil {
+				stripped, stripErr = StripRaw(frame)
+			}
+			if stripErr != nil {
+				continue
+			}
+			out = stripped
+		}
+		s.Enqueue(out)
+	}
+	return stripErr
+}
+
+// Broadcast sends frame to every subscriber.
+func (h *Hub) Broadcast(frame []byte) {
+	for _, s := range h.Subscribers() {
+		s.Enqueue(frame)
+	}
+}
+
+// CloseAll disconnects every subscriber without notice.
+func (h *Hub) CloseAll() {
+	for _, s := range h.Subscribers() {
+		s.Close()
+	}
+}
+
+// StripRaw removes data.raw from a ris_message frame.
+func StripRaw(frame []byte) ([]byte, error) {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(frame, &m); err != nil {
+		return nil, err
+	}
+	var data map[string]json.RawMessage
+	if err := json.Unmarshal(m["data"], &data); err != nil {
+		return nil, err
+	}
+	if _, ok := data["raw"]; !ok {
+		return frame, nil
+	}
+	delete(data, "raw")
+	buf, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	m["data"] = buf
+	return json.Marshal(m)
+}
+
+// SlowConsumerError is the ris_error RIS Live sends before dropping a
+// client more than size messages behind.
+func SlowConsumerError(size int) []byte {
+	return MustMarshal(&rislive.RisLiveMessage{Type: "ris_error", Data: &rislive.RisError{
+		Message:    fmt.Sprintf("Closing connection after being behind by more than %d messages", size),
+		BufferSize: uint64(size),
+	}})
+}
+
+func MustMarshal(v interface{}) []byte {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// Subscriber is a client of a Hub.
+type Subscriber struct {
+	hub       *Hub
+	send      chan []byte
+	done      chan struct{}
+	maxBuffer int
+
+	mu      sync.Mutex
+	filters []*rislive.Filter
+	final   []byte
+	closed  bool
+}
+
+// Subscriptions returns the filters of the subscriber.
+func (s *Subscriber) Subscriptions() []*rislive.Filter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*rislive.Filter{}, s.filters...)
+}
+
+// Match reports whether any subscription matches m and whether one of the
+// matching subscriptions asked for raw messages.
+func (s *Subscriber) Match(m *rislive.RisLiveMessage) (bool, bool) {
+	matched, raw := false, false
+	for _, f := range s.Subscriptions() {
+		if !f.Match(m) {
+			continue
+		}
+		matched = true
+		if f.SocketOptions != nil && f.SocketOptions.IncludeRaw {
+			raw = true
+		}
+	}
+	return matched, raw
+}
+
+// Enqueue queues frame, disconnecting the subscriber when its queue is
+// full.
+func (s *Subscriber) Enqueue(frame []byte) {
+	select {
+	case <-s.done:
+	case s.send <- frame:
+	default:
+		s.CloseSlow()
+	}
+}
+
+// Close stops the subscriber.
+func (s *Subscriber) Close() {
+	s.closeWith(nil)
+}
+
+// CloseSlow stops the subscriber after sending the slow consumer error.
+func (s *Subscriber) CloseSlow() {
+	s.closeWith(SlowConsumerError(s.maxBuffer))
+}
+
+func (s *Subscriber) closeWith(final []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed, s.final = true, final
+	close(s.done)
+}
+
+// CloseOnDone stops the subscriber when ctx is done, as when an HTTP
+// client goes away.
+func (s *Subscriber) CloseOnDone(ctx context.Context) {
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.Close()
+		case <-s.done:
+		}
+	}()
+}
+
+// WriteLoop passes the queued frames to write until the subscriber is
+// stopped or write fails.
+func (s *Subscriber) WriteLoop(write func([]byte) error) {
+	for {
+		select {
+		case frame := <-s.send:
+			if err := write(frame); err != nil {
+				s.Close()
+				return
+			}
+		case <-s.done:
+			s.mu.Lock()
+			final := s.final
+			s.mu.Unlock()
+			if final != nil {
+				write(final)
+			}
+			return
+		}
+	}
+}
+
+// ServeWebSocket reads commands from conn and writes the queued frames to
+// it until either side stops. A positive writeTimeout bounds each write.
+func (s *Subscriber) ServeWebSocket(conn *websocket.Conn, writeTimeout time.Duration) {
+	go func() {
+		for {
+			_, p, err := conn.ReadMessage()
+			if err != nil {
+				s.Close()
+				return
+			}
+			s.HandleCommand(p)
+		}
+	}()
+	s.WriteLoop(func(frame []byte) error {
+		if writeTimeout > 0 {
+			conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		}
+		return conn.WriteMessage(websocket.TextMessage, frame)
+	})
+}
+
+func (s *Subscriber) reply(m *rislive.RisLiveMessage) {
+	s.Enqueue(MustMarshal(m))
+}
+
+func (s *Subscriber) replyError(message, commandType string) {
+	s.reply(&rislive.RisLiveMessage{Type: "ris_error", Data: &rislive.RisError{Message: message, CommandType: commandType}})
+}
+
+// HandleCommand answers a client command as RIS Live does.
+func (s *Subscriber) HandleCommand(p []byte) {
+	var head struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(p, &head); err != nil {
+		s.replyError("Invalid JSON", "")
+		return
+	}
+	switch head.Type {
+	case "ris_subscribe", "ris_unsubscribe", "request_rrc_list", "ping":
+	default:
+		s.replyError("Unknown command type", head.Type)
+		return
+	}
+	var m rislive.RisLiveMessage
+	if err := json.Unmarshal(p, &m); err != nil {
+		s.replyError(err.Error(), head.Type)
+		return
+	}
+	switch m.Type {
+	case "ris_subscribe":
+		f := m.Data.(*rislive.Filter)
+		if err := f.Validate(); err != nil {
+			s.replyError(err.Error(), m.Type)
+			return
+		}
+		s.mu.Lock()
+		s.filters = append(s.filters, f)
+		s.mu.Unlock()
+	case "ris_unsubscribe":
+		f := m.Data.(*rislive.Filter)
+		s.mu.Lock()
+		for i, sub := range s.filters {
+			if reflect.DeepEqual(sub, f) {
+				s.filters = append(s.filters[:i], s.filters[i+1:]...)
+				break
+			}
+		}
+		s.mu.Unlock()
+	case "request_rrc_list":
+		s.reply(&rislive.RisLiveMessage{Type: "ris_rrc_list", Data: s.hub.rrcs()})
+	case "ping":
+		s.reply(&rislive.RisLiveMessage{Type: "pong"})
+	}
+}
@@ -0,0 +1,93 @@
+package fanout
+
+import (
+	"testing"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/stretchr/testify/assert"
+)
+
+func subscriber(h *Hub, includeRaw bool) *Subscriber {
+	f := rislive.NewFilter()
+	f.SetSocketOptions(includeRaw)
+	return h.Add(4, f)
+}
+
+func queued(s *Subscriber) []string {
+	var frames []string
+	for {
+		select {
+		case f := <-s.send:
+			frames = append(frames, string(f))
+		default:
+			return frames
+		}
+	}
+}
+
+func TestPublish(t *testing.T) {
+	assert := assert.New(t)
+	h := NewHub()
+	raw, plain := subscriber(h, true), subscriber(h, false)
+	unsubscribed := h.Add(4)
+
+	m := rislive.NewKeepalive().Host("rrc00").Peer("192.0.2.1", 64500).Raw("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF001304").MustMessage()
+	frame := MustMarshal(m)
+	assert.NoError(h.Publish(frame, m))
+	assert.Equal([]string{string(frame)}, queued(raw))
+	if frames := queued(plain); assert.Len(frames, 1) {
+		assert.NotContains(frames[0], "raw")
+		assert.Contains(frames[0], `"KEEPALIVE"`)
+	}
+	assert.Empty(queued(unsubscribed))
+
+	// A frame that cannot be stripped still reaches the subscribers that
+	// want it whole.
+	assert.Error(h.Publish([]byte(`{"type": "ris_message", "data": "truncated`), m))
+	assert.Equal([]string{`{"type": "ris_message", "data": "truncated`}, queued(raw))
+	assert.Empty(queued(plain))
+
+	pong := []byte(`{"type":"pong"}`)
+	assert.NoError(h.Publish(pong, &rislive.RisLiveMessage{Type: "pong"}))
+	for _, s := range []*Subscriber{raw, plain, unsubscribed} {
+		assert.Equal([]string{string(pong)}, queued(s))
+	}
+}
+
+func TestSlowSubscriber(t *testing.T) {
+	assert := assert.New(t)
+	h := NewHub()
+	s := h.Add(1)
+	s.Enqueue([]byte("a"))
+	s.Enqueue([]byte("b"))
+
+	var written []string
+	s.WriteLoop(func(frame []byte) error {
+		written = append(written, string(frame))
+		return nil
+	})
+	// The queued frame may or may not be written before the loop sees
+	// the subscriber closed, but the error always comes last.
+	if assert.NotEmpty(written) {
+		assert.Equal(string(SlowConsumerError(1)), written[len(written)-1])
+	}
+}
+
+func TestHandleCommand(t *testing.T) {
+	assert := assert.New(t)
+	h := NewHub()
+	h.SetRrcList(rislive.RisRrcList{"rrc00"})
+	s := h.Add(8)
+	s.HandleCommand([]byte(`{"type": "ris_subscribe", "data": {"host": "rrc00"}}`))
+	assert.Len(s.Subscriptions(), 1)
+	s.HandleCommand([]byte(`{"type": "ris_unsubscribe", "data": {"host": "rrc00"}}`))
+	assert.Empty(s.Subscriptions())
+	s.HandleCommand([]byte(`{"type": "request_rrc_list"}`))
+	s.HandleCommand([]byte(`{"type": "ping"}`))
+	s.HandleCommand([]byte(`{"type": "nope"}`))
+	assert.Equal([]string{
+		`{"type":"ris_rrc_list","data":["rrc00"]}`,
+		`{"type":"pong"}`,
+		`{"type":"ris_error","data":{"message":"Unknown command type","command_type":"nope"}}`,
+	}, queued(s))
+}
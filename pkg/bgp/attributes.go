@@ -0,0 +1,327 @@
+// Package bgp decodes and encodes the parts of BGP-4 messages needed to
+// convert between MRT records and RIS Live messages.
+package bgp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+)
+
+// Path attribute type codes.
+const (
+	AttrOrigin          = 1
+	AttrASPath          = 2
+	AttrNextHop         = 3
+	AttrMED             = 4
+	AttrLocalPref       = 5
+	AttrAtomicAggregate = 6
+	AttrAggregator      = 7
+	AttrCommunity       = 8
+	AttrMPReach         = 14
+	AttrMPUnreach       = 15
+	AttrAS4Path         = 17
+	AttrOTC             = 35
+)
+
+// Attribute flags.
+const (
+	FlagOptional   = 0x80
+	FlagTransitive = 0x40
+	FlagPartial    = 0x20
+	FlagExtended   = 0x10
+)
+
+const (
+	AFIIPv4 = 1
+	AFIIPv6 = 2
+
+	SAFIUnicast = 1
+)
+
+// ASTrans is the 2-octet placeholder for 4-octet ASNs (RFC 6793).
+const ASTrans = 23456
+
+const (
+	segmentSet       = 1
+	segmentSequence  = 2
+	segmentConfedSeq = 3
+	segmentConfedSet = 4
+)
+
+var originNames = []string{"igp", "egp", "incomplete"}
+
+// MPReach is the MP_REACH_NLRI attribute (RFC 4760).
+type MPReach struct {
+	AFI     uint16
+	SAFI    uint8
+	NextHop []net.IP
+	NLRI    []*net.IPNet
+}
+
+// MPUnreach is the MP_UNREACH_NLRI attribute (RFC 4760).
+type MPUnreach struct {
+	AFI       uint16
+	SAFI      uint8
+	Withdrawn []*net.IPNet
+}
+
+// Attributes holds the decoded path attributes. Attributes that are not
+// decoded are ignored.
+type Attributes struct {
+	Origin      string
+	Path        rislive.ASPath
+	NextHop     net.IP
+	MED         uint32
+	HasMED      bool
+	LocalPref   uint32
+	Communities [][]uint16
+	MPReach     *MPReach
+	MPUnreach   *MPUnreach
+	// OTC is the Only to Customer attribute (RFC 9234), zero when absent.
+	OTC uint32
+}
+
+// DecodeOptions controls how attributes are decoded.
+type DecodeOptions struct {
+	// AS2 is set when AS_PATH carries 2-octet ASNs. AS4_PATH is then
+	// merged into the path.
+	AS2 bool
+	// TableDump is set for the attributes of MRT TABLE_DUMP_V2 RIB
+	// entries, in which MP_REACH_NLRI only holds the next hop (RFC 6396).
+	TableDump bool
+}
+
+// DecodeAttributes decodes a sequence of path attributes.
+func DecodeAttributes(b []byte, opts DecodeOptions) (*Attributes, error) {
+	a := &Attributes{}
+	var as4Path rislive.ASPath
+	for len(b) > 0 {
+		if len(b) < 3 {
+			return nil, errors.New("bgp: truncated attribute header")
+		}
+		flags, code := b[0], b[1]
+		var l int
+		if flags&FlagExtended != 0 {
+			if len(b) < 4 {
+				return nil, errors.New("bgp: truncated attribute header")
+			}
+			l = int(binary.BigEndian.Uint16(b[2:4]))
+			b = b[4:]
+		} else {
+			l = int(b[2])
+			b = b[3:]
+		}
+		if len(b) < l {
+			return nil, fmt.Errorf("bgp: truncated attribute %d", code)
+		}
+		v := b[:l]
+		b = b[l:]
+
+		var err error
+		switch code {
+		case AttrOrigin:
+			if len(v) != 1 || int(v[0]) >= len(originNames) {
+				return nil, errors.New("bgp: invalid ORIGIN")
+			}
+			a.Origin = originNames[v[0]]
+		case AttrASPath:
+			asLen := 4
+			if opts.AS2 {
+				asLen = 2
+			}
+			a.Path, err = decodeASPath(v, asLen)
+		case AttrAS4Path:
+			as4Path, err = decodeASPath(v, 4)
+		case AttrNextHop:
+			if len(v) != net.IPv4len {
+				return nil, errors.New("bgp: invalid NEXT_HOP")
+			}
+			a.NextHop = net.IP(append([]byte{}, v...))
+		case AttrMED:
+			if len(v) != 4 {
+				return nil, errors.New("bgp: invalid MULTI_EXIT_DISC")
+			}
+			a.MED, a.HasMED = binary.BigEndian.Uint32(v), true
+		case AttrLocalPref:
+			if len(v) != 4 {
+				return nil, errors.New("bgp: invalid LOCAL_PREF")
+			}
+			a.LocalPref = binary.BigEndian.Uint32(v)
+		case AttrCommunity:
+			if len(v)%4 != 0 {
+				return nil, errors.New("bgp: invalid COMMUNITY")
+			}
+			for i := 0; i < len(v); i += 4 {
+				a.Communities = append(a.Communities, []uint16{
+					binary.BigEndian.Uint16(v[i:]),
+					binary.BigEndian.Uint16(v[i+2:]),
+				})
+			}
+		case AttrOTC:
+			if len(v) != 4 {
+				return nil, errors.New("bgp: invalid OTC")
+			}
+			a.OTC = binary.BigEndian.Uint32(v)
+		case AttrMPReach:
+			if opts.TableDump {
+				a.MPReach, err = decodeTableDumpMPReach(v)
+			} else {
+				a.MPReach, err = decodeMPReach(v)
+			}
+		case AttrMPUnreach:
+			a.MPUnreach, err = decodeMPUnreach(v)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if opts.AS2 && as4Path != nil {
+		a.Path = mergeAS4Path(a.Path, as4Path)
+	}
+	return a, nil
+}
+
+func decodeASPath(b []byte, asLen int) (rislive.ASPath, error) {
+	path := rislive.ASPath{}
+	for len(b) > 0 {
+		if len(b) < 2 {
+			return nil, errors.New("bgp: truncated AS_PATH segment")
+		}
+		typ, n := b[0], int(b[1])
+		b = b[2:]
+		if len(b) < n*asLen {
+			return nil, errors.New("bgp: truncated AS_PATH segment")
+		}
+		asns := make([]uint32, n)
+		for i := range asns {
+			if asLen == 2 {
+				asns[i] = uint32(binary.BigEndian.Uint16(b[i*2:]))
+			} else {
+				asns[i] = binary.BigEndian.Uint32(b[i*4:])
+			}
+		}
+		b = b[n*asLen:]
+		switch typ {
+		case segmentSequence, segmentConfedSeq:
+			for _, asn := range asns {
+				path = append(path, rislive.PathHop{ASN: asn})
+			}
+		case segmentSet, segmentConfedSet:
+			path = append(path, rislive.PathHop{Set: asns})
+		default:
+			return nil, fmt.Errorf("bgp: unknown AS_PATH segment type %d", typ)
+		}
+	}
+	return path, nil
+}
+
+// mergeAS4Path reconstructs the path from AS_PATH and AS4_PATH as in
+// RFC 6793 section 4.2.3.
+func mergeAS4Path(path, as4Path rislive.ASPath) rislive.ASPath {
+	if len(as4Path) > len(path) {
+		return path
+	}
+	merged := append(rislive.ASPath{}, path[:len(path)-len(as4Path)]...)
+	return append(merged, as4Path...)
+}
+
+func afiLen(afi uint16) (int, error) {
+	switch afi {
+	case AFIIPv4:
+		return net.IPv4len, nil
+	case AFIIPv6:
+		return net.IPv6len, nil
+	}
+	return 0, fmt.Errorf("bgp: unsupported AFI %d", afi)
+}
+
+func decodeNextHops(b []byte) []net.IP {
+	var hops []net.IP
+	switch len(b) {
+	case net.IPv4len, net.IPv6len:
+		hops = append(hops, net.IP(append([]byte{}, b...)))
+	case 2 * net.IPv6len:
+		hops = append(hops, net.IP(append([]byte{}, b[:16]...)), net.IP(append([]byte{}, b[16:]...)))
+	}
+	return hops
+}
+
+func decodeMPReach(b []byte) (*MPReach, error) {
+	if len(b) < 5 {
+		return nil, errors.New("bgp: truncated MP_REACH_NLRI")
+	}
+	r := &MPReach{AFI: binary.BigEndian.Uint16(b), SAFI: b[2]}
+	nhLen := int(b[3])
+	b = b[4:]
+	if len(b) < nhLen+1 {
+		return nil, errors.New("bgp: truncated MP_REACH_NLRI")
+	}
+	r.NextHop = decodeNextHops(b[:nhLen])
+	b = b[nhLen+1:]
+	l, err := afiLen(r.AFI)
+	if err != nil {
+		return nil, err
+	}
+	r.NLRI, err = DecodePrefixes(b, l)
+	return r, err
+}
+
+func decodeTableDumpMPReach(b []byte) (*MPReach, error) {
+	if len(b) < 1 || len(b) < int(b[0])+1 {
+		return nil, errors.New("bgp: truncated MP_REACH_NLRI")
+	}
+	r := &MPReach{SAFI: SAFIUnicast, NextHop: decodeNextHops(b[1 : int(b[0])+1])}
+	r.AFI = AFIIPv6
+	if len(r.NextHop) > 0 && r.NextHop[0].To4() != nil && len(r.NextHop[0]) == net.IPv4len {
+		r.AFI = AFIIPv4
+	}
+	return r, nil
+}
+
+func decodeMPUnreach(b []byte) (*MPUnreach, error) {
+	if len(b) < 3 {
+		return nil, errors.New("bgp: truncated MP_UNREACH_NLRI")
+	}
+	u := &MPUnreach{AFI: binary.BigEndian.Uint16(b), SAFI: b[2]}
+	l, err := afiLen(u.AFI)
+	if err != nil {
+		return nil, err
+	}
+	u.Withdrawn, err = DecodePrefixes(b[3:], l)
+	return u, err
+}
+
+// DecodePrefixes decodes NLRI encoded prefixes of an address family whose
+// addresses are addrLen octets long.
+func DecodePrefixes(b []byte, addrLen int) ([]*net.IPNet, error) {
+	var prefixes []*net.IPNet
+	for len(b) > 0 {
+		p, n, err := DecodePrefix(b, addrLen)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, p)
+		b = b[n:]
+	}
+	return prefixes, nil
+}
+
+// DecodePrefix decodes one NLRI encoded prefix and returns it with the
+// number of octets consumed.
+func DecodePrefix(b []byte, addrLen int) (*net.IPNet, int, error) {
+	if len(b) < 1 {
+		return nil, 0, errors.New("bgp: truncated prefix")
+	}
+	bits := int(b[0])
+	n := (bits + 7) / 8
+	if bits > addrLen*8 || len(b) < 1+n {
+		return nil, 0, errors.New("bgp: invalid prefix")
+	}
+	ip := make(net.IP, addrLen)
+	copy(ip, b[1:1+n])
+	return &net.IPNet{IP: ip.Mask(net.CIDRMask(bits, addrLen*8)), Mask: net.CIDRMask(bits, addrLen*8)}, 1 + n, nil
+}
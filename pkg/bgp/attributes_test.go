@@ -0,0 +1,58 @@
+package bgp
+
+import (
+	"testing"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeAttributes(t *testing.T) {
+	assert := assert.New(t)
+	b := []byte{
+		0x40, AttrOrigin, 1, 0,
+		0x40, AttrASPath, 16, segmentSequence, 2, 0, 0, 0xfb, 0xf4, 0, 0, 0xfb, 0xfe, segmentSet, 1, 0, 0, 0xfc, 0x08,
+		0x40, AttrNextHop, 4, 192, 0, 2, 1,
+		0x80, AttrMED, 4, 0, 0, 0, 10,
+		0xc0, AttrCommunity, 4, 0xfb, 0xf4, 0, 100,
+		0xc0, AttrOTC, 4, 0, 0, 0xfb, 0xfe,
+		0x90, AttrMPReach, 0, 28, 0, 2, 1, 16, 0x20, 0x01, 0x0d, 0xb8, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0, 48, 0x20, 0x01, 0x0d, 0xb8, 0, 1,
+		0x80, AttrMPUnreach, 7, 0, 2, 1, 24, 0x20, 0x01, 0x0d,
+	}
+	a, err := DecodeAttributes(b, DecodeOptions{})
+	assert.NoError(err)
+	assert.Equal("igp", a.Origin)
+	assert.Equal(rislive.ASPath{{ASN: 64500}, {ASN: 64510}, {Set: []uint32{64520}}}, a.Path)
+	assert.Equal("192.0.2.1", a.NextHop.String())
+	assert.True(a.HasMED)
+	assert.Equal(uint32(10), a.MED)
+	assert.Equal([][]uint16{{64500, 100}}, a.Communities)
+	assert.Equal(uint32(64510), a.OTC)
+	assert.Equal(uint16(AFIIPv6), a.MPReach.AFI)
+	assert.Equal("2001:db8::1", a.MPReach.NextHop[0].String())
+	assert.Equal("2001:db8:1::/48", a.MPReach.NLRI[0].String())
+	assert.Equal("2001:d00::/24", a.MPUnreach.Withdrawn[0].String())
+
+	_, err = DecodeAttributes(b[:len(b)-1], DecodeOptions{})
+	assert.Error(err)
+}
+
+func TestDecodeAS2WithAS4Path(t *testing.T) {
+	assert := assert.New(t)
+	b := []byte{
+		0x40, AttrASPath, 8, segmentSequence, 3, 0xfb, 0xf4, 0x5b, 0xa0, 0x5b, 0xa0,
+		0xc0, AttrAS4Path, 10, segmentSequence, 2, 0, 3, 0x0d, 0x40, 0, 3, 0x0d, 0x41,
+	}
+	a, err := DecodeAttributes(b, DecodeOptions{AS2: true})
+	assert.NoError(err)
+	assert.Equal(rislive.ASPath{{ASN: 64500}, {ASN: 200000}, {ASN: 200001}}, a.Path)
+}
+
+func TestDecodeTableDumpMPReach(t *testing.T) {
+	assert := assert.New(t)
+	b := []byte{0x80, AttrMPReach, 17, 16, 0x20, 0x01, 0x0d, 0xb8, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2}
+	a, err := DecodeAttributes(b, DecodeOptions{TableDump: true})
+	assert.NoError(err)
+	assert.Equal(uint16(AFIIPv6), a.MPReach.AFI)
+	assert.Equal("2001:db8::2", a.MPReach.NextHop[0].String())
+}
@@ -0,0 +1,136 @@
+package bgp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+)
+
+type Header struct {
+	Length uint16
+	Type   uint8
+}
+
+// DecodeHeader checks the marker and returns the header and body of a BGP
+// message.
+func DecodeHeader(b []byte) (Header, []byte, error) {
+	if len(b) < HeaderLen {
+		return Header{}, nil, errors.New("bgp: truncated header")
+	}
+	for _, c := range b[:16] {
+		if c != 0xff {
+			return Header{}, nil, errors.New("bgp: invalid marker")
+		}
+	}
+	h := Header{Length: binary.BigEndian.Uint16(b[16:]), Type: b[18]}
+	if int(h.Length) < HeaderLen || int(h.Length) > len(b) {
+		return Header{}, nil, fmt.Errorf("bgp: invalid message length %d", h.Length)
+	}
+	return h, b[HeaderLen:h.Length], nil
+}
+
+type Update struct {
+	Withdrawn  []*net.IPNet
+	Attributes *Attributes
+	NLRI       []*net.IPNet
+}
+
+// DecodeUpdate decodes the body of an UPDATE message.
+func DecodeUpdate(b []byte, opts DecodeOptions) (*Update, error) {
+	if len(b) < 2 {
+		return nil, errors.New("bgp: truncated UPDATE")
+	}
+	wl := int(binary.BigEndian.Uint16(b))
+	if len(b) < 4+wl {
+		return nil, errors.New("bgp: truncated UPDATE")
+	}
+	u := &Update{}
+	var err error
+	if u.Withdrawn, err = DecodePrefixes(b[2:2+wl], net.IPv4len); err != nil {
+		return nil, err
+	}
+	b = b[2+wl:]
+	al := int(binary.BigEndian.Uint16(b))
+	if len(b) < 2+al {
+		return nil, errors.New("bgp: truncated UPDATE")
+	}
+	if u.Attributes, err = DecodeAttributes(b[2:2+al], opts); err != nil {
+		return nil, err
+	}
+	if u.NLRI, err = DecodePrefixes(b[2+al:], net.IPv4len); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+type Capability struct {
+	Code  uint8
+	Value []byte
+}
+
+type Open struct {
+	Version      uint8
+	MyAS         uint16
+	HoldTime     uint16
+	BGPID        net.IP
+	Capabilities []Capability
+}
+
+// AS returns the 4-octet ASN capability if present, MyAS otherwise.
+func (o *Open) AS() uint32 {
+	for _, c := range o.Capabilities {
+		if c.Code == 65 && len(c.Value) == 4 {
+			return binary.BigEndian.Uint32(c.Value)
+		}
+	}
+	return uint32(o.MyAS)
+}
+
+// DecodeOpen decodes the body of an OPEN message.
+func DecodeOpen(b []byte) (*Open, error) {
+	if len(b) < 10 || len(b) < 10+int(b[9]) {
+		return nil, errors.New("bgp: truncated OPEN")
+	}
+	o := &Open{
+		Version:  b[0],
+		MyAS:     binary.BigEndian.Uint16(b[1:]),
+		HoldTime: binary.BigEndian.Uint16(b[3:]),
+		BGPID:    net.IP(append([]byte{}, b[5:9]...)),
+	}
+	params := b[10 : 10+int(b[9])]
+	for len(params) >= 2 {
+		typ, l := params[0], int(params[1])
+		if len(params) < 2+l {
+			return nil, errors.New("bgp: truncated OPEN parameter")
+		}
+		v := params[2 : 2+l]
+		params = params[2+l:]
+		if typ != 2 {
+			continue
+		}
+		for len(v) >= 2 {
+			cl := int(v[1])
+			if len(v) < 2+cl {
+				return nil, errors.New("bgp: truncated capability")
+			}
+			o.Capabilities = append(o.Capabilities, Capability{Code: v[0], Value: append([]byte{}, v[2:2+cl]...)})
+			v = v[2+cl:]
+		}
+	}
+	return o, nil
+}
+
+type Notification struct {
+	Code    uint8
+	Subcode uint8
+	Data    []byte
+}
+
+// DecodeNotification decodes the body of a NOTIFICATION message.
+func DecodeNotification(b []byte) (*Notification, error) {
+	if len(b) < 2 {
+		return nil, errors.New("bgp: truncated NOTIFICATION")
+	}
+	return &Notification{Code: b[0], Subcode: b[1], Data: append([]byte{}, b[2:]...)}, nil
+}
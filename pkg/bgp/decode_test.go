@@ -0,0 +1,48 @@
+package bgp
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeUpdate(t *testing.T) {
+	assert := assert.New(t)
+	b, _ := hex.DecodeString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF006A020004148D8820002F400101004002160205000070F500000CB9000005130004155D000402ED400304C3D0D093C0080870F50FA070F50FA318B1177418B1177718B1177018A879C518B1260D18A879C718B1260A18B1260F")
+	h, body, err := DecodeHeader(b)
+	assert.NoError(err)
+	assert.Equal(Header{Length: 106, Type: MsgUpdate}, h)
+	u, err := DecodeUpdate(body, DecodeOptions{})
+	assert.NoError(err)
+	assert.Equal("141.136.32.0/20", u.Withdrawn[0].String())
+	assert.Len(u.NLRI, 8)
+	assert.Equal("28917 3257 1299 267613 262893", u.Attributes.Path.String())
+
+	_, _, err = DecodeHeader(b[:18])
+	assert.Error(err)
+	b[0] = 0
+	_, _, err = DecodeHeader(b)
+	assert.Error(err)
+	_, err = DecodeUpdate(body[:10], DecodeOptions{})
+	assert.Error(err)
+}
+
+func TestDecodeOpenAndNotification(t *testing.T) {
+	assert := assert.New(t)
+	b, _ := hex.DecodeString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF004F01041AD200B4C30E986532020601040002000102028000020202000206410400001AD202084006007800020100020E050C000100010002000100020002")
+	_, body, err := DecodeHeader(b)
+	assert.NoError(err)
+	o, err := DecodeOpen(body)
+	assert.NoError(err)
+	assert.Equal(uint32(6866), o.AS())
+	assert.Equal(uint16(180), o.HoldTime)
+	assert.Equal("195.14.152.101", o.BGPID.String())
+	assert.Len(o.Capabilities, 6)
+
+	n, err := DecodeNotification([]byte{6, 5})
+	assert.NoError(err)
+	assert.Equal(&Notification{Code: 6, Subcode: 5, Data: []byte{}}, n)
+	_, err = DecodeNotification([]byte{6})
+	assert.Error(err)
+}
@@ -0,0 +1,339 @@
+package bgp
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+)
+
+// BGP message types.
+const (
+	MsgOpen         = 1
+	MsgUpdate       = 2
+	MsgNotification = 3
+	MsgKeepalive    = 4
+)
+
+const (
+	HeaderLen     = 19
+	MaxMessageLen = 4096
+)
+
+// EncodeMessage prepends the marker and header to a message body.
+func EncodeMessage(typ uint8, body []byte) []byte {
+	b := make([]byte, HeaderLen, HeaderLen+len(body))
+	for i := 0; i < 16; i++ {
+		b[i] = 0xff
+	}
+	binary.BigEndian.PutUint16(b[16:], uint16(HeaderLen+len(body)))
+	b[18] = typ
+	return append(b, body...)
+}
+
+// RawMessage decodes the hex encoded raw BGP message of a ris_message,
+// which is only present when the subscription set includeRaw.
+func RawMessage(m rislive.RisMessageCommon) ([]byte, bool) {
+	if m.Raw == "" {
+		return nil, false
+	}
+	b, err := hex.DecodeString(m.Raw)
+	if err != nil || len(b) < HeaderLen {
+		return nil, false
+	}
+	return b, true
+}
+
+// EncodeKeepalive returns a KEEPALIVE message.
+func EncodeKeepalive() []byte {
+	return EncodeMessage(MsgKeepalive, nil)
+}
+
+// EncodeNotification re-encodes a NOTIFICATION from its JSON fields. As in
+// RIS Live, data holds the whole message body, starting with the code and
+// subcode, and is used as is. Only when data is empty is the body made of
+// the code and subcode.
+func EncodeNotification(n *rislive.RisMessageNotification) ([]byte, error) {
+	body, err := hex.DecodeString(n.Notification.Data)
+	if err != nil {
+		return nil, fmt.Errorf("bgp: invalid notification data: %v", err)
+	}
+	if len(body) == 0 {
+		body = []byte{n.Notification.Code, n.Notification.Subcode}
+	}
+	return EncodeMessage(MsgNotification, body), nil
+}
+
+// EncodeOpen re-encodes an OPEN from its JSON fields. Capabilities are not
+// preserved except for the 4-octet AS number capability. The AS is taken
+// from ASN, or for a received OPEN without it from the peer ASN.
+func EncodeOpen(o *rislive.RisMessageOpen) ([]byte, error) {
+	asn := uint64(o.ASN)
+	if asn == 0 {
+		if o.Direction == "sent" {
+			return nil, fmt.Errorf("bgp: sent OPEN without ASN")
+		}
+		var err error
+		asn, err = strconv.ParseUint(o.PeerASN, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("bgp: invalid peer ASN: %q", o.PeerASN)
+		}
+	}
+	id := net.ParseIP(o.RouterID).To4()
+	if id == nil {
+		return nil, fmt.Errorf("bgp: invalid router ID: %q", o.RouterID)
+	}
+	version := o.Version
+	if version == 0 {
+		version = 4
+	}
+	myAS := uint16(asn)
+	if asn > 0xffff {
+		myAS = ASTrans
+	}
+	body := make([]byte, 10)
+	body[0] = byte(version)
+	binary.BigEndian.PutUint16(body[1:], myAS)
+	binary.BigEndian.PutUint16(body[3:], uint16(o.HoldTime))
+	copy(body[5:9], id)
+	capability := []byte{2, 6, 65, 4, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(capability[4:], uint32(asn))
+	body[9] = byte(len(capability))
+	return EncodeMessage(MsgOpen, append(body, capability...)), nil
+}
+
+func appendAttr(b []byte, flags, code uint8, v []byte) []byte {
+	if len(v) > 0xff {
+		b = append(b, flags|FlagExtended, code, 0, 0)
+		binary.BigEndian.PutUint16(b[len(b)-2:], uint16(len(v)))
+	} else {
+		b = append(b, flags, code, byte(len(v)))
+	}
+	return append(b, v...)
+}
+
+func encodeASPath(path rislive.ASPath) []byte {
+	b := []byte{}
+	var seq []uint32
+	flush := func() {
+		for len(seq) > 0 {
+			n := len(seq)
+			if n > 255 {
+				n = 255
+			}
+			b = appendSegment(b, segmentSequence, seq[:n])
+			seq = seq[n:]
+		}
+	}
+	for _, h := range path {
+		if h.IsSet() {
+			flush()
+			b = appendSegment(b, segmentSet, h.Set)
+			continue
+		}
+		seq = append(seq, h.ASN)
+	}
+	flush()
+	return b
+}
+
+func appendSegment(b []byte, typ uint8, asns []uint32) []byte {
+	b = append(b, typ, byte(len(asns)))
+	for _, asn := range asns {
+		b = append(b, 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(b[len(b)-4:], asn)
+	}
+	return b
+}
+
+// EncodePrefix appends the NLRI encoding of p to b.
+func EncodePrefix(b []byte, p *net.IPNet) []byte {
+	ones, _ := p.Mask.Size()
+	ip := p.IP.To4()
+	if ip == nil {
+		ip = p.IP.To16()
+	}
+	b = append(b, byte(ones))
+	return append(b, ip[:(ones+7)/8]...)
+}
+
+func parsePrefix(s string) (*net.IPNet, bool, error) {
+	_, p, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil, false, err
+	}
+	return p, len(p.IP) == net.IPv4len, nil
+}
+
+type nlriGroup struct {
+	nextHop  []net.IP
+	prefixes []*net.IPNet
+}
+
+// EncodeUpdate re-encodes an UPDATE from its JSON fields. IPv4 routes with
+// an IPv4 next hop use the classic fields and everything else
+// MP_REACH_NLRI/MP_UNREACH_NLRI. As a message carries a single next hop
+// per address family, announcements with several next hops result in more
+// than one message.
+func EncodeUpdate(u *rislive.RisMessageUpdate) ([][]byte, error) {
+	path, err := u.ASPath()
+	if err != nil {
+		return nil, err
+	}
+	var withdrawn4 []byte
+	var withdrawn6 []*net.IPNet
+	for _, s := range u.Withdrawals {
+		p, v4, err := parsePrefix(s)
+		if err != nil {
+			return nil, err
+		}
+		if v4 {
+			withdrawn4 = EncodePrefix(withdrawn4, p)
+		} else {
+			withdrawn6 = append(withdrawn6, p)
+		}
+	}
+
+	var groups4, groupsMP []nlriGroup
+	for _, a := range u.Announcements {
+		var hops []net.IP
+		for _, s := range strings.Split(a.NextHop, ",") {
+			ip := net.ParseIP(strings.TrimSpace(s))
+			if ip == nil {
+				return nil, fmt.Errorf("bgp: invalid next hop: %q", a.NextHop)
+			}
+			hops = append(hops, ip)
+		}
+		// IPv4 routes with an IPv6 next hop (RFC 8950) need their own
+		// MP_REACH_NLRI.
+		g4 := nlriGroup{nextHop: hops[:1]}
+		g6 := nlriGroup{nextHop: hops}
+		g4mp := nlriGroup{nextHop: hops}
+		for _, s := range a.Prefixes {
+			p, v4, err := parsePrefix(s)
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case v4 && hops[0].To4() != nil:
+				g4.prefixes = append(g4.prefixes, p)
+			case v4:
+				g4mp.prefixes = append(g4mp.prefixes, p)
+			default:
+				g6.prefixes = append(g6.prefixes, p)
+			}
+		}
+		for _, g := range []nlriGroup{g6, g4mp} {
+			if len(g.prefixes) > 0 {
+				groupsMP = append(groupsMP, g)
+			}
+		}
+		if len(g4.prefixes) > 0 {
+			groups4 = append(groups4, g4)
+		}
+	}
+
+	var common []byte
+	if len(groups4) > 0 || len(groupsMP) > 0 {
+		origin := byte(0)
+		for i, name := range originNames {
+			if name == u.Origin {
+				origin = byte(i)
+			}
+		}
+		common = appendAttr(common, FlagTransitive, AttrOrigin, []byte{origin})
+		common = appendAttr(common, FlagTransitive, AttrASPath, encodeASPath(path))
+	}
+	var trailing []byte
+	if u.MED != 0 {
+		v := make([]byte, 4)
+		binary.BigEndian.PutUint32(v, u.MED)
+		trailing = appendAttr(trailing, FlagOptional, AttrMED, v)
+	}
+	if len(u.Communities) > 0 {
+		v := []byte{}
+		for _, c := range u.Communities {
+			if len(c) != 2 {
+				return nil, errors.New("bgp: invalid community")
+			}
+			v = append(v, byte(c[0]>>8), byte(c[0]), byte(c[1]>>8), byte(c[1]))
+		}
+		trailing = appendAttr(trailing, FlagOptional|FlagTransitive, AttrCommunity, v)
+	}
+
+	n := len(groups4)
+	if len(groupsMP) > n {
+		n = len(groupsMP)
+	}
+	if n == 0 {
+		n = 1
+	}
+	msgs := make([][]byte, 0, n)
+	for i := 0; i < n; i++ {
+		var attrs, nlri, withdrawn []byte
+		if i == 0 {
+			withdrawn = withdrawn4
+			if len(withdrawn6) > 0 {
+				v := []byte{0, AFIIPv6, SAFIUnicast}
+				for _, p := range withdrawn6 {
+					v = EncodePrefix(v, p)
+				}
+				attrs = appendAttr(attrs, FlagOptional, AttrMPUnreach, v)
+			}
+		}
+		if i < len(groups4) || i < len(groupsMP) {
+			attrs = append(attrs, common...)
+		}
+		if i < len(groups4) {
+			attrs = appendAttr(attrs, FlagTransitive, AttrNextHop, groups4[i].nextHop[0].To4())
+			for _, p := range groups4[i].prefixes {
+				nlri = EncodePrefix(nlri, p)
+			}
+		}
+		if i < len(groups4) || i < len(groupsMP) {
+			attrs = append(attrs, trailing...)
+		}
+		if i < len(groupsMP) {
+			attrs = appendAttr(attrs, FlagOptional, AttrMPReach, encodeMPReach(groupsMP[i]))
+		}
+		body := make([]byte, 2, 4+len(withdrawn)+len(attrs)+len(nlri))
+		binary.BigEndian.PutUint16(body, uint16(len(withdrawn)))
+		body = append(body, withdrawn...)
+		body = append(body, byte(len(attrs)>>8), byte(len(attrs)))
+		body = append(body, attrs...)
+		body = append(body, nlri...)
+		if HeaderLen+len(body) > MaxMessageLen {
+			return nil, errors.New("bgp: UPDATE exceeds maximum message size")
+		}
+		msgs = append(msgs, EncodeMessage(MsgUpdate, body))
+	}
+	return msgs, nil
+}
+
+func encodeMPReach(g nlriGroup) []byte {
+	afi := byte(AFIIPv6)
+	if len(g.prefixes[0].IP) == net.IPv4len {
+		afi = AFIIPv4
+	}
+	v := []byte{0, afi, SAFIUnicast}
+	var nh []byte
+	for _, ip := range g.nextHop {
+		if ip4 := ip.To4(); ip4 != nil && afi == AFIIPv4 {
+			nh = append(nh, ip4...)
+		} else {
+			nh = append(nh, ip.To16()...)
+		}
+	}
+	v = append(v, byte(len(nh)))
+	v = append(v, nh...)
+	v = append(v, 0)
+	for _, p := range g.prefixes {
+		v = EncodePrefix(v, p)
+	}
+	return v
+}
@@ -0,0 +1,123 @@
+package bgp
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeUpdate(t *testing.T, s string) *rislive.RisMessageUpdate {
+	var m rislive.RisLiveMessage
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		t.Fatal(err)
+	}
+	u, ok := m.AsUpdate()
+	if !ok {
+		t.Fatal("not an UPDATE")
+	}
+	return u
+}
+
+func TestEncodeUpdateMatchesRaw(t *testing.T) {
+	assert := assert.New(t)
+	u := decodeUpdate(t, `{"type": "ris_message", "data": {
+		"timestamp": 1562822233.68, "peer": "195.208.208.147", "peer_asn": "28917", "id": "x", "host": "rrc13", "type": "UPDATE",
+		"raw": "FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF006A020004148D8820002F400101004002160205000070F500000CB9000005130004155D000402ED400304C3D0D093C0080870F50FA070F50FA318B1177418B1177718B1177018A879C518B1260D18A879C718B1260A18B1260F",
+		"path": [28917, 3257, 1299, 267613, 262893],
+		"community": [[28917, 4000], [28917, 4003]],
+		"origin": "igp",
+		"announcements": [{"next_hop": "195.208.208.147", "prefixes": ["177.23.116.0/24", "177.23.119.0/24", "177.23.112.0/24", "168.121.197.0/24", "177.38.13.0/24", "168.121.199.0/24", "177.38.10.0/24", "177.38.15.0/24"]}],
+		"withdrawals": ["141.136.32.0/20"]}}`)
+	msgs, err := EncodeUpdate(u)
+	assert.NoError(err)
+	assert.Len(msgs, 1)
+	assert.Equal(u.Raw, strings.ToUpper(hex.EncodeToString(msgs[0])))
+
+	raw, ok := RawMessage(u.RisMessageCommon)
+	assert.True(ok)
+	assert.Equal(msgs[0], raw)
+}
+
+func TestEncodeUpdateMP(t *testing.T) {
+	assert := assert.New(t)
+	u := decodeUpdate(t, `{"type": "ris_message", "data": {
+		"timestamp": 1, "peer": "2001:db8::1", "peer_asn": "64500", "id": "x", "host": "rrc00", "type": "UPDATE",
+		"path": [64500, [64510, 64511]], "origin": "egp", "med": 20,
+		"announcements": [
+			{"next_hop": "2001:db8::1,fe80::1", "prefixes": ["2001:db8:1::/48", "192.0.2.0/24"]},
+			{"next_hop": "2001:db8::2", "prefixes": ["2001:db8:2::/48"]}],
+		"withdrawals": ["2001:db8:3::/48", "198.51.100.0/24"]}}`)
+	msgs, err := EncodeUpdate(u)
+	assert.NoError(err)
+	assert.Len(msgs, 3)
+
+	body := msgs[0][HeaderLen:]
+	withdrawnLen := int(body[0])<<8 | int(body[1])
+	assert.Equal(4, withdrawnLen)
+	attrLen := int(body[2+withdrawnLen])<<8 | int(body[3+withdrawnLen])
+	a, err := DecodeAttributes(body[4+withdrawnLen:4+withdrawnLen+attrLen], DecodeOptions{})
+	assert.NoError(err)
+	assert.Equal("egp", a.Origin)
+	assert.Equal(uint32(20), a.MED)
+	assert.Equal("64500 {64510,64511}", a.Path.String())
+	assert.Equal("2001:db8:3::/48", a.MPUnreach.Withdrawn[0].String())
+	assert.Equal("2001:db8:1::/48", a.MPReach.NLRI[0].String())
+	assert.Equal("fe80::1", a.MPReach.NextHop[1].String())
+
+	body = msgs[1][HeaderLen:]
+	a, err = DecodeAttributes(body[4:], DecodeOptions{})
+	assert.NoError(err)
+	assert.Equal(uint16(AFIIPv4), a.MPReach.AFI)
+	assert.Equal("192.0.2.0/24", a.MPReach.NLRI[0].String())
+	assert.Nil(a.MPUnreach)
+}
+
+func TestEncodeOthers(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("ffffffffffffffffffffffffffffffff001304", hex.EncodeToString(EncodeKeepalive()))
+
+	n := &rislive.RisMessageNotification{}
+	n.Notification.Code, n.Notification.Subcode, n.Notification.Data = 6, 5, ""
+	b, err := EncodeNotification(n)
+	assert.NoError(err)
+	assert.Equal("ffffffffffffffffffffffffffffffff0015030605", hex.EncodeToString(b))
+	n.Notification.Data = "0605"
+	b, err = EncodeNotification(n)
+	assert.NoError(err)
+	assert.Equal("ffffffffffffffffffffffffffffffff0015030605", hex.EncodeToString(b))
+	// A Cease with a shutdown communication (RFC 9003).
+	n.Notification.Subcode, n.Notification.Data = 2, "060203616263"
+	b, err = EncodeNotification(n)
+	assert.NoError(err)
+	assert.Equal("ffffffffffffffffffffffffffffffff001903060203616263", hex.EncodeToString(b))
+	// Data is used as is, even when it disagrees with code and subcode.
+	n.Notification.Data = "0101"
+	b, err = EncodeNotification(n)
+	assert.NoError(err)
+	assert.Equal("ffffffffffffffffffffffffffffffff0015030101", hex.EncodeToString(b))
+	n.Notification.Data = "zz"
+	_, err = EncodeNotification(n)
+	assert.Error(err)
+
+	o := &rislive.RisMessageOpen{RouterID: "195.14.152.101", HoldTime: 180, Version: 4}
+	o.PeerASN = "196608"
+	b, err = EncodeOpen(o)
+	assert.NoError(err)
+	assert.Equal("ffffffffffffffffffffffffffffffff002501045ba000b4c30e9865080206410400030000", hex.EncodeToString(b))
+	// The OPEN's own AS wins over the peer's, as for one sent by the collector.
+	o.Direction, o.ASN = "sent", 12654
+	b, err = EncodeOpen(o)
+	assert.NoError(err)
+	assert.Equal("ffffffffffffffffffffffffffffffff00250104316e00b4c30e986508020641040000316e", hex.EncodeToString(b))
+	o.ASN = 0
+	_, err = EncodeOpen(o)
+	assert.EqualError(err, "bgp: sent OPEN without ASN")
+	o.Direction = "received"
+	o.RouterID = "x"
+	_, err = EncodeOpen(o)
+	assert.Error(err)
+}
@@ -0,0 +1,323 @@
+// Package bogon flags announcements of special-purpose or unallocated
+// address space and AS paths with reserved ASNs, as seen in the RIS Live
+// update stream.
+package bogon
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/a16/go-rislive/pkg/prefixtree"
+)
+
+// Kind is the kind of bogon a Finding reports.
+type Kind int
+
+const (
+	// Unknown is the zero Kind, which no Finding has.
+	Unknown Kind = iota
+	// SpecialPrefix is space of the IANA special-purpose registries
+	// (RFC 6890) that is not globally routable.
+	SpecialPrefix
+	// UnallocatedPrefix is space not allocated to any RIR or user.
+	UnallocatedPrefix
+	// ReservedASN is an ASN reserved for private use, documentation or
+	// other purposes.
+	ReservedASN
+)
+
+func (k Kind) String() string {
+	switch k {
+	case SpecialPrefix:
+		return "special-prefix"
+	case UnallocatedPrefix:
+		return "unallocated-prefix"
+	case ReservedASN:
+		return "reserved-asn"
+	}
+	return "unknown"
+}
+
+// Finding is a bogon found in an announcement.
+type Finding struct {
+	Kind Kind
+	// Block is the bogon space covering the prefix.
+	Block *net.IPNet
+	// ASN is the reserved ASN in the path.
+	ASN         uint32
+	Description string
+}
+
+func (f Finding) String() string {
+	if f.Kind == ReservedASN {
+		return fmt.Sprintf("%s AS%d (%s)", f.Kind, f.ASN, f.Description)
+	}
+	if f.Description == "" {
+		return fmt.Sprintf("%s %s", f.Kind, f.Block)
+	}
+	return fmt.Sprintf("%s %s (%s)", f.Kind, f.Block, f.Description)
+}
+
+var specialPrefixes = []struct {
+	prefix, description string
+}{
+	{"0.0.0.0/8", "this network"},
+	{"10.0.0.0/8", "private use"},
+	{"100.64.0.0/10", "shared address space"},
+	{"127.0.0.0/8", "loopback"},
+	{"169.254.0.0/16", "link local"},
+	{"172.16.0.0/12", "private use"},
+	{"192.0.0.0/24", "IETF protocol assignments"},
+	{"192.0.2.0/24", "documentation"},
+	{"192.88.99.0/24", "6to4 relay anycast"},
+	{"192.168.0.0/16", "private use"},
+	{"198.18.0.0/15", "benchmarking"},
+	{"198.51.100.0/24", "documentation"},
+	{"203.0.113.0/24", "documentation"},
+	{"224.0.0.0/4", "multicast"},
+	{"240.0.0.0/4", "reserved"},
+	{"::/8", "loopback, unspecified and IPv4 mapped"},
+	{"100::/64", "discard only"},
+	{"2001:2::/48", "benchmarking"},
+	{"2001:10::/28", "ORCHID"},
+	{"2001:db8::/32", "documentation"},
+	{"3fff::/20", "documentation"},
+	{"fc00::/7", "unique local"},
+	{"fe80::/10", "link local"},
+	{"fec0::/10", "site local"},
+	{"ff00::/8", "multicast"},
+}
+
+// globalPrefixes are the globally reachable assignments made within the
+// special-purpose blocks above.
+var globalPrefixes = []string{
+	"192.0.0.9/32",  // Port Control Protocol anycast
+	"192.0.0.10/32", // TURN anycast
+	"64:ff9b::/96",  // IPv4-IPv6 translation
+}
+
+var reservedASNs = []struct {
+	first, last uint32
+	description string
+}{
+	{0, 0, "reserved"},
+	{23456, 23456, "AS_TRANS"},
+	{64496, 64511, "documentation"},
+	{64512, 65534, "private use"},
+	{65535, 65535, "reserved"},
+	{65536, 65551, "documentation"},
+	{65552, 131071, "reserved"},
+	{4200000000, 4294967294, "private use"},
+	{4294967295, 4294967295, "reserved"},
+}
+
+// IsReservedASN tells whether asn is reserved and why.
+func IsReservedASN(asn uint32) (string, bool) {
+	for _, r := range reservedASNs {
+		if asn >= r.first && asn <= r.last {
+			return r.description, true
+		}
+	}
+	return "", false
+}
+
+// Checker checks announcements against the special-purpose space, the
+// unallocated space it was given and the reserved ASNs. It is safe for
+// concurrent use.
+type Checker struct {
+	special *prefixtree.Tree
+	global  *prefixtree.Tree
+
+	mu          sync.RWMutex
+	unallocated *prefixtree.Tree
+}
+
+func NewChecker() *Checker {
+	c := &Checker{special: prefixtree.New(), global: prefixtree.New(), unallocated: prefixtree.New()}
+	for _, s := range specialPrefixes {
+		_, p, err := net.ParseCIDR(s.prefix)
+		if err != nil {
+			panic(err)
+		}
+		c.special.Insert(p, s.description)
+	}
+	for _, s := range globalPrefixes {
+		_, p, err := net.ParseCIDR(s)
+		if err != nil {
+			panic(err)
+		}
+		c.global.Insert(p, "")
+	}
+	return c
+}
+
+// SetUnallocated replaces the unallocated space.
+func (c *Checker) SetUnallocated(prefixes []*net.IPNet) {
+	t := prefixtree.New()
+	for _, p := range prefixes {
+		t.Insert(p, "")
+	}
+	c.mu.Lock()
+	c.unallocated = t
+	c.mu.Unlock()
+}
+
+// ReadPrefixes reads a list of prefixes, one per line, as in the Team
+// Cymru full bogon lists. Blank lines and lines starting with # are
+// skipped.
+func ReadPrefixes(r io.Reader) ([]*net.IPNet, error) {
+	prefixes, err := readPrefixes(r)
+	if err != nil {
+		return nil, fmt.Errorf("bogon: %v", err)
+	}
+	return prefixes, nil
+}
+
+func readPrefixes(r io.Reader) ([]*net.IPNet, error) {
+	var prefixes []*net.IPNet
+	scanner := bufio.NewScanner(r)
+	n := 0
+	for scanner.Scan() {
+		n++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		_, p, err := net.ParseCIDR(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid prefix: %s", n, line)
+		}
+		prefixes = append(prefixes, p)
+	}
+	return prefixes, scanner.Err()
+}
+
+// LoadUnallocated replaces the unallocated space with the prefixes listed
+// in a file. On failure the previous space is kept.
+func (c *Checker) LoadUnallocated(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	prefixes, err := readPrefixes(f)
+	if err != nil {
+		return fmt.Errorf("bogon: %s: %v", path, err)
+	}
+	c.SetUnallocated(prefixes)
+	return nil
+}
+
+// covering returns the most specific entry of t covering prefix.
+func covering(t *prefixtree.Tree, prefix *net.IPNet) (*net.IPNet, string, bool) {
+	var block *net.IPNet
+	var description string
+	t.Covering(prefix, func(p *net.IPNet, v interface{}) bool {
+		block, description = p, v.(string)
+		return true
+	})
+	return block, description, block != nil
+}
+
+// CheckPrefix returns the bogon space covering prefix, if any.
+// Special-purpose space is reported before unallocated space. The globally
+// reachable assignments within special-purpose blocks, such as the anycast
+// addresses of 192.0.0.0/24, are not bogons.
+func (c *Checker) CheckPrefix(prefix *net.IPNet) (Finding, bool) {
+	_, _, global := covering(c.global, prefix)
+	if block, description, ok := covering(c.special, prefix); ok && !global {
+		return Finding{Kind: SpecialPrefix, Block: block, Description: description}, true
+	}
+	c.mu.RLock()
+	unallocated := c.unallocated
+	c.mu.RUnlock()
+	if block, _, ok := covering(unallocated, prefix); ok {
+		return Finding{Kind: UnallocatedPrefix, Block: block}, true
+	}
+	return Finding{}, false
+}
+
+// CheckPath returns the reserved ASNs of a path, including members of
+// AS_SETs, each once in path order.
+func (c *Checker) CheckPath(path rislive.ASPath) []Finding {
+	var findings []Finding
+	seen := map[uint32]bool{}
+	check := func(asn uint32) {
+		if seen[asn] {
+			return
+		}
+		seen[asn] = true
+		if description, ok := IsReservedASN(asn); ok {
+			findings = append(findings, Finding{Kind: ReservedASN, ASN: asn, Description: description})
+		}
+	}
+	for _, h := range path {
+		if !h.IsSet() {
+			check(h.ASN)
+			continue
+		}
+		for _, asn := range h.Set {
+			check(asn)
+		}
+	}
+	return findings
+}
+
+// Annotation holds the bogons found in an announced route.
+type Annotation struct {
+	rislive.RouteEvent
+	Findings []Finding
+}
+
+// CheckUpdate returns the announcements of an UPDATE that have bogons.
+// Prefixes that cannot be parsed are skipped.
+func (c *Checker) CheckUpdate(u *rislive.RisMessageUpdate) []Annotation {
+	var as []Annotation
+	var pathFindings []Finding
+	pathChecked := false
+	u.EachRouteEvent(func(ev rislive.RouteEvent) bool {
+		if ev.Kind != rislive.RouteAnnouncement {
+			return true
+		}
+		_, prefix, err := net.ParseCIDR(ev.Prefix)
+		if err != nil {
+			return true
+		}
+		if !pathChecked {
+			pathFindings, pathChecked = c.CheckPath(ev.Path), true
+		}
+		var findings []Finding
+		if f, ok := c.CheckPrefix(prefix); ok {
+			findings = append(findings, f)
+		}
+		findings = append(findings, pathFindings...)
+		if len(findings) > 0 {
+			as = append(as, Annotation{RouteEvent: ev, Findings: findings})
+		}
+		return true
+	})
+	return as
+}
+
+type checker struct {
+	rislive.NopHandler
+	c           *Checker
+	annotations []Annotation
+}
+
+func (h *checker) HandleUpdate(u *rislive.RisMessageUpdate) {
+	h.annotations = h.c.CheckUpdate(u)
+}
+
+// Apply returns the bogons in a message, which are only found in UPDATEs.
+func (c *Checker) Apply(m *rislive.RisLiveMessage) ([]Annotation, error) {
+	h := &checker{c: c}
+	err := m.Dispatch(h)
+	return h.annotations, err
+}
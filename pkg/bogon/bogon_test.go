@@ -0,0 +1,114 @@
+package bogon
+
+import (
+	"strings"
+	"testing"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/a16/go-rislive/pkg/rislivetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckPrefix(t *testing.T) {
+	c := NewChecker()
+	assert.NoError(t, c.LoadUnallocated("testdata/fullbogons.txt"))
+	tests := []struct {
+		Prefix string
+		Bogon  bool
+		Kind   Kind
+		Block  string
+	}{
+		{"10.1.0.0/16", true, SpecialPrefix, "10.0.0.0/8"},
+		{"192.168.0.0/16", true, SpecialPrefix, "192.168.0.0/16"},
+		{"100.64.0.0/10", true, SpecialPrefix, "100.64.0.0/10"},
+		{"100.0.0.0/8", false, Unknown, ""},
+		{"192.0.0.8/32", true, SpecialPrefix, "192.0.0.0/24"},
+		{"192.0.0.9/32", false, Unknown, ""},
+		{"192.0.0.10/32", false, Unknown, ""},
+		{"192.0.0.8/29", true, SpecialPrefix, "192.0.0.0/24"},
+		{"64:ff9b::/96", false, Unknown, ""},
+		{"64:ff9b:1::/48", true, SpecialPrefix, "::/8"},
+		{"2001:db8:1::/48", true, SpecialPrefix, "2001:db8::/32"},
+		{"::ffff:0:0/96", true, SpecialPrefix, "::/8"},
+		{"102.1.0.0/16", true, UnallocatedPrefix, "102.0.0.0/8"},
+		{"2001:1b01::/32", true, UnallocatedPrefix, "2001:1b00::/24"},
+		{"41.60.0.0/14", false, Unknown, ""},
+		{"193.0.0.0/21", false, Unknown, ""},
+		{"2001:67c::/32", false, Unknown, ""},
+	}
+	for _, tt := range tests {
+		f, ok := c.CheckPrefix(rislivetest.MustPrefix(tt.Prefix))
+		if !assert.Equal(t, tt.Bogon, ok, tt.Prefix) || !ok {
+			continue
+		}
+		assert.Equal(t, tt.Kind, f.Kind, tt.Prefix)
+		assert.Equal(t, tt.Block, f.Block.String(), tt.Prefix)
+	}
+
+	c.SetUnallocated(nil)
+	_, ok := c.CheckPrefix(rislivetest.MustPrefix("102.1.0.0/16"))
+	assert.False(t, ok)
+}
+
+func TestReadPrefixesErrors(t *testing.T) {
+	_, err := ReadPrefixes(strings.NewReader("# comment\n10.0.0.0/8\n10.0.0.0\n"))
+	assert.EqualError(t, err, "bogon: line 3: invalid prefix: 10.0.0.0")
+	c := NewChecker()
+	assert.Error(t, c.LoadUnallocated("testdata/missing.txt"))
+	err = c.LoadUnallocated("testdata/invalid.txt")
+	assert.EqualError(t, err, "bogon: testdata/invalid.txt: line 2: invalid prefix: 102.0.0.0/33")
+}
+
+func TestIsReservedASN(t *testing.T) {
+	for _, tt := range []struct {
+		ASN         uint32
+		Description string
+		Reserved    bool
+	}{
+		{0, "reserved", true},
+		{3333, "", false},
+		{23456, "AS_TRANS", true},
+		{64496, "documentation", true},
+		{64512, "private use", true},
+		{65535, "reserved", true},
+		{65551, "documentation", true},
+		{100000, "reserved", true},
+		{131072, "", false},
+		{4200000000, "private use", true},
+		{4294967295, "reserved", true},
+	} {
+		description, ok := IsReservedASN(tt.ASN)
+		assert.Equal(t, tt.Reserved, ok, "AS%d", tt.ASN)
+		assert.Equal(t, tt.Description, description, "AS%d", tt.ASN)
+	}
+}
+
+func TestApply(t *testing.T) {
+	assert := assert.New(t)
+	c := NewChecker()
+	m := rislive.NewUpdate().Host("rrc00").Peer("193.0.0.1", 3333).
+		Path(3333, 23456, 1299).PathSet(64512, 1299).
+		Announce("193.0.0.1", "10.0.0.0/8", "193.0.0.0/21").Withdraw("192.168.0.0/16").MustMessage()
+	as, err := c.Apply(m)
+	assert.NoError(err)
+	if assert.Len(as, 2) {
+		assert.Equal("10.0.0.0/8", as[0].Prefix)
+		var findings []string
+		for _, f := range as[0].Findings {
+			findings = append(findings, f.String())
+		}
+		assert.Equal([]string{
+			"special-prefix 10.0.0.0/8 (private use)",
+			"reserved-asn AS23456 (AS_TRANS)",
+			"reserved-asn AS64512 (private use)",
+		}, findings)
+		assert.Equal("193.0.0.0/21", as[1].Prefix)
+		assert.Len(as[1].Findings, 2)
+	}
+
+	m = rislive.NewUpdate().Host("rrc00").Peer("193.0.0.1", 3333).Path(3333, 1299).
+		Announce("193.0.0.1", "193.0.0.0/21").MustMessage()
+	as, err = c.Apply(m)
+	assert.NoError(err)
+	assert.Empty(as)
+}
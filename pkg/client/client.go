@@ -0,0 +1,190 @@
+// Package client maintains a WebSocket connection to RIS Live, subscribing
+// with a set of filters and reconnecting when the connection drops.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"sync"
+	"time"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// DefaultURL is the WebSocket endpoint of RIS Live.
+	DefaultURL = "wss://ris-live.ripe.net/v1/ws/"
+	// DefaultName is sent as the client query parameter.
+	DefaultName = "go-rislive"
+)
+
+// Handler receives each frame read from RIS Live with its decoded form.
+// Frames that fail to decode are passed with a nil message.
+type Handler func(frame []byte, m *rislive.RisLiveMessage)
+
+type Client struct {
+	URL     string
+	Name    string
+	Filters []*rislive.Filter
+	Dialer  *websocket.Dialer
+
+	// MinBackoff and MaxBackoff bound the delay between reconnects, which
+	// doubles after each failed attempt.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// OnConnect and OnDisconnect, if set, are called as the upstream
+	// connection comes and goes.
+	OnConnect    func()
+	OnDisconnect func(error)
+
+	mu      sync.Mutex
+	conn    *websocket.Conn
+	rrcList rislive.RisRrcList
+}
+
+// New returns a client of RIS Live subscribing with filters. Without
+// filters the whole firehose is subscribed.
+func New(filters ...*rislive.Filter) *Client {
+	return &Client{
+		URL:        DefaultURL,
+		Name:       DefaultName,
+		Filters:    filters,
+		Dialer:     websocket.DefaultDialer,
+		MinBackoff: time.Second,
+		MaxBackoff: time.Minute,
+	}
+}
+
+func (c *Client) endpoint() (string, error) {
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return "", err
+	}
+	if c.Name != "" {
+		q := u.Query()
+		q.Set("client", c.Name)
+		u.RawQuery = q.Encode()
+	}
+	return u.String(), nil
+}
+
+func (c *Client) dial(ctx context.Context) (*websocket.Conn, error) {
+	u, err := c.endpoint()
+	if err != nil {
+		return nil, err
+	}
+	d := c.Dialer
+	if d == nil {
+		d = websocket.DefaultDialer
+	}
+	conn, _, err := d.DialContext(ctx, u, nil)
+	return conn, err
+}
+
+// Run connects and calls fn for every frame received until ctx is done.
+// Subscriptions and a request_rrc_list are sent on every (re)connect.
+func (c *Client) Run(ctx context.Context, fn Handler) error {
+	backoff := c.MinBackoff
+	for {
+		connected, err := c.runOnce(ctx, fn)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if connected {
+			backoff = c.MinBackoff
+			if c.OnDisconnect != nil {
+				c.OnDisconnect(err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > c.MaxBackoff {
+			backoff = c.MaxBackoff
+		}
+	}
+}
+
+func (c *Client) runOnce(ctx context.Context, fn Handler) (bool, error) {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return false, err
+	}
+	c.mu.Lock()
+	c.conn = conn
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.conn = nil
+		c.mu.Unlock()
+		conn.Close()
+	}()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-stop:
+		}
+	}()
+
+	filters := c.Filters
+	if len(filters) == 0 {
+		filters = []*rislive.Filter{rislive.NewFilter()}
+	}
+	for _, f := range filters {
+		if err := c.Send(rislive.NewRisSubscribe(f)); err != nil {
+			return false, err
+		}
+	}
+	if err := c.Send(rislive.NewRisRequestRrcList()); err != nil {
+		return false, err
+	}
+	if c.OnConnect != nil {
+		c.OnConnect()
+	}
+
+	for {
+		_, p, err := conn.ReadMessage()
+		if err != nil {
+			return true, err
+		}
+		var m rislive.RisLiveMessage
+		if err := json.Unmarshal(p, &m); err != nil {
+			fn(p, nil)
+			continue
+		}
+		if l, ok := m.AsRrcList(); ok {
+			c.mu.Lock()
+			c.rrcList = l
+			c.mu.Unlock()
+		}
+		fn(p, &m)
+	}
+}
+
+// Send writes a message on the current connection.
+func (c *Client) Send(m *rislive.RisLiveMessage) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return errors.New("client: not connected")
+	}
+	return c.conn.WriteJSON(m)
+}
+
+// RrcList returns the collectors last reported by RIS Live, or nil before
+// the first ris_rrc_list was received.
+func (c *Client) RrcList() rislive.RisRrcList {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append(rislive.RisRrcList(nil), c.rrcList...)
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/a16/go-rislive/pkg/rislivetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunReconnects(t *testing.T) {
+	assert := assert.New(t)
+	s := rislivetest.NewServer()
+	defer s.Close()
+	s.SetRrcList("rrc00", "rrc13")
+
+	c := New()
+	c.URL = s.WebSocketURL()
+	c.MinBackoff = 10 * time.Millisecond
+	connects := make(chan struct{}, 2)
+	c.OnConnect = func() { connects <- struct{}{} }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	msgs := make(chan *rislive.RisLiveMessage, 10)
+	done := make(chan error)
+	go func() {
+		done <- c.Run(ctx, func(frame []byte, m *rislive.RisLiveMessage) {
+			if m != nil && m.Type == "ris_message" {
+				msgs <- m
+			}
+		})
+	}()
+
+	for i := 0; i < 2; i++ {
+		<-connects
+		assert.NoError(s.WaitForSubscribers(1, time.Second))
+		assert.NoError(s.PublishMessages(rislive.NewKeepalive().Host("rrc13").Peer("192.0.2.1", 64496).MustMessage()))
+		select {
+		case m := <-msgs:
+			assert.Equal("KEEPALIVE", m.BgpMsgType)
+		case <-time.After(5 * time.Second):
+			t.Fatal("no message")
+		}
+		s.DisconnectAll()
+	}
+	assert.Equal(rislive.RisRrcList{"rrc00", "rrc13"}, c.RrcList())
+
+	cancel()
+	assert.Equal(context.Canceled, <-done)
+	assert.Error(c.Send(rislive.NewRisPing()))
+}
@@ -0,0 +1,435 @@
+// Package flap tracks route flaps per peer and prefix in the RIS Live
+// update stream, with the penalty and decay of route flap damping
+// (RFC 2439).
+package flap
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/a16/go-rislive/pkg/rib"
+)
+
+// Config holds the damping parameters.
+type Config struct {
+	// WithdrawalPenalty is added when an announced route is withdrawn.
+	WithdrawalPenalty float64
+	// ReannouncePenalty is added when a withdrawn route is announced again.
+	ReannouncePenalty float64
+	// AttributePenalty is added when a route is replaced by one with a
+	// different path, next hop or communities.
+	AttributePenalty float64
+	// A route is suppressed once its penalty exceeds SuppressLimit, until
+	// it decays below ReuseLimit.
+	SuppressLimit float64
+	ReuseLimit    float64
+	// HalfLife is the time it takes the penalty to decay by half.
+	HalfLife time.Duration
+	// MaxSuppress caps the penalty so that a route is not suppressed for
+	// longer once it stops flapping.
+	MaxSuppress time.Duration
+}
+
+// DefaultConfig has the defaults of common router implementations.
+var DefaultConfig = Config{
+	WithdrawalPenalty: 1000,
+	ReannouncePenalty: 0,
+	AttributePenalty:  500,
+	SuppressLimit:     2000,
+	ReuseLimit:        750,
+	HalfLife:          15 * time.Minute,
+	MaxSuppress:       time.Hour,
+}
+
+func (c *Config) ceiling() float64 {
+	return c.ReuseLimit * math.Pow(2, float64(c.MaxSuppress)/float64(c.HalfLife))
+}
+
+func (c *Config) decay(penalty float64, d time.Duration) float64 {
+	if d <= 0 {
+		return penalty
+	}
+	return penalty * math.Pow(2, -float64(d)/float64(c.HalfLife))
+}
+
+type EventKind int
+
+const (
+	// Suppressed is emitted when the penalty of a route exceeds the
+	// suppress limit.
+	Suppressed EventKind = iota
+	// Reused is emitted when the penalty of a suppressed route decays
+	// below the reuse limit, or when the session of its peer is reset.
+	Reused
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case Suppressed:
+		return "suppressed"
+	case Reused:
+		return "reused"
+	}
+	return "unknown"
+}
+
+type Event struct {
+	Kind EventKind
+	rib.PeerKey
+	Prefix  string
+	Penalty float64
+	// Flaps is the number of flaps since the route was first seen.
+	Flaps int
+	Time  time.Time
+}
+
+func (e Event) String() string {
+	return fmt.Sprintf("%s %s from %s %s: penalty %.0f after %d flaps", e.Prefix, e.Kind, e.Host, e.Peer, e.Penalty, e.Flaps)
+}
+
+type entry struct {
+	penalty    float64
+	updated    time.Time
+	announced  bool
+	attributes string
+	suppressed bool
+	flaps      int
+}
+
+type flapKey struct {
+	peer   rib.PeerKey
+	prefix string
+}
+
+// flapRecord is a flap kept for the reports.
+type flapRecord struct {
+	flapKey
+	time time.Time
+}
+
+// Tracker keeps the penalty of every (peer, prefix) and a log of the flaps
+// of the last Window for reports. Penalties decay with message time. It is
+// safe for concurrent use.
+//
+// The state of every route seen is kept to detect attribute changes, so
+// memory grows with the routes of all peers as for a rib.RIB.
+type Tracker struct {
+	Config Config
+	Window time.Duration
+
+	mu         sync.Mutex
+	entries    map[rib.PeerKey]map[string]*entry
+	suppressed map[flapKey]*entry
+	log        []flapRecord
+	now        time.Time
+	swept      time.Time
+	collected  time.Time
+}
+
+func NewTracker(config Config, window time.Duration) *Tracker {
+	return &Tracker{
+		Config:     config,
+		Window:     window,
+		entries:    map[rib.PeerKey]map[string]*entry{},
+		suppressed: map[flapKey]*entry{},
+	}
+}
+
+// Apply charges the flaps of an UPDATE. An OPEN or a RIS_PEER_STATE "down"
+// resets the damping state of the peer, whose routes then start afresh.
+// The routes suppressed or reused are returned.
+func (t *Tracker) Apply(m *rislive.RisLiveMessage) ([]Event, error) {
+	var events []Event
+	err := m.Dispatch(rib.PeerEvents{
+		Update: func(u *rislive.RisMessageUpdate) { events = t.ApplyUpdate(u) },
+		Flush:  func(peer rib.PeerKey, now time.Time) { events = t.flushPeer(peer, now) },
+	})
+	return events, err
+}
+
+// advance moves the clock to now. Suppressed routes are checked for reuse
+// every minute, and the routes whose penalty decayed away are forgotten
+// every half-life.
+func (t *Tracker) advance(events []Event, now time.Time) []Event {
+	if now.After(t.now) {
+		t.now = now
+	}
+	if t.now.Sub(t.swept) >= time.Minute {
+		t.swept = t.now
+		events = t.reuse(events)
+	}
+	if t.now.Sub(t.collected) >= t.Config.HalfLife {
+		t.collected = t.now
+		t.collect()
+	}
+	return events
+}
+
+func attributes(ev rislive.RouteEvent) string {
+	return fmt.Sprint(ev.Path, ev.NextHop, ev.Communities)
+}
+
+// ApplyUpdate penalizes the routes withdrawn, announced again or changed by
+// an UPDATE.
+func (t *Tracker) ApplyUpdate(u *rislive.RisMessageUpdate) []Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	events := t.advance(nil, u.GetTimestamp())
+	u.EachRouteEvent(func(ev rislive.RouteEvent) bool {
+		_, prefix, err := net.ParseCIDR(ev.Prefix)
+		if err != nil {
+			return true
+		}
+		key := flapKey{peer: rib.PeerKey{Host: ev.Host, Peer: ev.Peer}, prefix: prefix.String()}
+		peer := t.entries[key.peer]
+		if peer == nil {
+			peer = map[string]*entry{}
+			t.entries[key.peer] = peer
+		}
+		e := peer[key.prefix]
+		if e == nil {
+			if ev.Kind == rislive.RouteWithdrawal {
+				return true
+			}
+			peer[key.prefix] = &entry{updated: t.now, announced: true, attributes: attributes(ev)}
+			return true
+		}
+		var penalty float64
+		flap := true
+		if ev.Kind == rislive.RouteWithdrawal {
+			if !e.announced {
+				return true
+			}
+			e.announced = false
+			penalty = t.Config.WithdrawalPenalty
+		} else {
+			attrs := attributes(ev)
+			switch {
+			case !e.announced:
+				// The flap was counted on withdrawal.
+				penalty, flap = t.Config.ReannouncePenalty, false
+			case attrs != e.attributes:
+				penalty = t.Config.AttributePenalty
+			default:
+				return true
+			}
+			e.announced, e.attributes = true, attrs
+		}
+		events = t.penalize(events, key, e, penalty, flap)
+		return true
+	})
+	return events
+}
+
+// penalize adds to the penalty of a route, and records a flap if flap is
+// set, at the current time, which never goes back when messages of several
+// collectors are slightly out of order.
+func (t *Tracker) penalize(events []Event, key flapKey, e *entry, penalty float64, flap bool) []Event {
+	now := t.now
+	e.penalty = t.Config.decay(e.penalty, now.Sub(e.updated)) + penalty
+	if ceiling := t.Config.ceiling(); e.penalty > ceiling {
+		e.penalty = ceiling
+	}
+	e.updated = now
+	if flap {
+		e.flaps++
+		t.log = append(t.log, flapRecord{flapKey: key, time: now})
+	}
+	if !e.suppressed && e.penalty > t.Config.SuppressLimit {
+		e.suppressed = true
+		t.suppressed[key] = e
+		events = append(events, Event{Kind: Suppressed, PeerKey: key.peer, Prefix: key.prefix, Penalty: e.penalty, Flaps: e.flaps, Time: now})
+	}
+	return events
+}
+
+// reuse releases the suppressed routes whose penalty decayed below the
+// reuse limit.
+func (t *Tracker) reuse(events []Event) []Event {
+	var released []Event
+	for key, e := range t.suppressed {
+		penalty := t.Config.decay(e.penalty, t.now.Sub(e.updated))
+		if penalty < t.Config.ReuseLimit {
+			e.suppressed = false
+			delete(t.suppressed, key)
+			released = append(released, Event{Kind: Reused, PeerKey: key.peer, Prefix: key.prefix, Penalty: penalty, Flaps: e.flaps, Time: t.now})
+		}
+	}
+	sort.Slice(released, func(i, j int) bool {
+		a, b := released[i], released[j]
+		if a.Prefix != b.Prefix {
+			return a.Prefix < b.Prefix
+		}
+		if a.Host != b.Host {
+			return a.Host < b.Host
+		}
+		return a.Peer < b.Peer
+	})
+	return append(events, released...)
+}
+
+// collect forgets the withdrawn routes without penalty left and trims the
+// flap log to the window.
+func (t *Tracker) collect() {
+	for peer, prefixes := range t.entries {
+		for prefix, e := range prefixes {
+			if !e.announced && !e.suppressed && t.Config.decay(e.penalty, t.now.Sub(e.updated)) < 1 {
+				delete(prefixes, prefix)
+			}
+		}
+		if len(prefixes) == 0 {
+			delete(t.entries, peer)
+		}
+	}
+	t.trim()
+}
+
+func (t *Tracker) trim() {
+	i := sort.Search(len(t.log), func(i int) bool { return t.now.Sub(t.log[i].time) <= t.Window })
+	t.log = append(t.log[:0], t.log[i:]...)
+}
+
+// Decay moves the clock to now and returns the suppressed routes that can
+// be reused. Reuse is otherwise only checked as UPDATEs arrive, so Decay
+// should run from a timer to release routes that stopped flapping.
+func (t *Tracker) Decay(now time.Time) []Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if now.After(t.now) {
+		t.now = now
+	}
+	t.swept = t.now
+	return t.reuse(nil)
+}
+
+// FlushPeer forgets the routes of a peer and their penalties, as when its
+// session goes down, and releases its suppressed routes with Reused events.
+// The routes the peer announces on its next session are new and not
+// charged. Its flaps stay in the reports.
+func (t *Tracker) FlushPeer(peer rib.PeerKey) []Event {
+	return t.flushPeer(peer, time.Time{})
+}
+
+func (t *Tracker) flushPeer(peer rib.PeerKey, now time.Time) []Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	events := t.advance(nil, now)
+	var released []Event
+	for prefix, e := range t.entries[peer] {
+		if !e.suppressed {
+			continue
+		}
+		delete(t.suppressed, flapKey{peer: peer, prefix: prefix})
+		penalty := t.Config.decay(e.penalty, t.now.Sub(e.updated))
+		released = append(released, Event{Kind: Reused, PeerKey: peer, Prefix: prefix, Penalty: penalty, Flaps: e.flaps, Time: t.now})
+	}
+	delete(t.entries, peer)
+	sort.Slice(released, func(i, j int) bool { return released[i].Prefix < released[j].Prefix })
+	return append(events, released...)
+}
+
+// Penalty returns the current penalty of the route of a peer for prefix,
+// and whether it is suppressed.
+func (t *Tracker) Penalty(peer rib.PeerKey, prefix string) (float64, bool) {
+	_, p, err := net.ParseCIDR(prefix)
+	if err != nil {
+		return 0, false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e := t.entries[peer][p.String()]
+	if e == nil {
+		return 0, false
+	}
+	return t.Config.decay(e.penalty, t.now.Sub(e.updated)), e.suppressed
+}
+
+// PrefixCount is the number of flaps of a prefix over all peers.
+type PrefixCount struct {
+	Prefix string
+	Flaps  int
+	// Peers is the number of peers that saw the prefix flap.
+	Peers int
+}
+
+// PeerCount is the number of flaps seen by a peer over all prefixes.
+type PeerCount struct {
+	rib.PeerKey
+	Flaps int
+	// Prefixes is the number of prefixes that flapped.
+	Prefixes int
+}
+
+// Report lists the prefixes and peers with the most flaps over a window.
+type Report struct {
+	Start, End time.Time
+	Prefixes   []PrefixCount
+	Peers      []PeerCount
+}
+
+// Report returns the n prefixes and the n peers with the most flaps during
+// the last Window, most first. All of them are returned when n is zero or
+// less.
+func (t *Tracker) Report(n int) Report {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.trim()
+	r := Report{Start: t.now.Add(-t.Window), End: t.now}
+	prefixes := map[string]*PrefixCount{}
+	peers := map[rib.PeerKey]*PeerCount{}
+	seen := map[flapKey]bool{}
+	for _, f := range t.log {
+		pc := prefixes[f.prefix]
+		if pc == nil {
+			pc = &PrefixCount{Prefix: f.prefix}
+			prefixes[f.prefix] = pc
+		}
+		ps := peers[f.peer]
+		if ps == nil {
+			ps = &PeerCount{PeerKey: f.peer}
+			peers[f.peer] = ps
+		}
+		pc.Flaps++
+		ps.Flaps++
+		if !seen[f.flapKey] {
+			seen[f.flapKey] = true
+			pc.Peers++
+			ps.Prefixes++
+		}
+	}
+	for _, pc := range prefixes {
+		r.Prefixes = append(r.Prefixes, *pc)
+	}
+	sort.Slice(r.Prefixes, func(i, j int) bool {
+		a, b := r.Prefixes[i], r.Prefixes[j]
+		if a.Flaps != b.Flaps {
+			return a.Flaps > b.Flaps
+		}
+		return a.Prefix < b.Prefix
+	})
+	for _, ps := range peers {
+		r.Peers = append(r.Peers, *ps)
+	}
+	sort.Slice(r.Peers, func(i, j int) bool {
+		a, b := r.Peers[i], r.Peers[j]
+		if a.Flaps != b.Flaps {
+			return a.Flaps > b.Flaps
+		}
+		if a.Host != b.Host {
+			return a.Host < b.Host
+		}
+		return a.Peer < b.Peer
+	})
+	if n > 0 && len(r.Prefixes) > n {
+		r.Prefixes = r.Prefixes[:n]
+	}
+	if n > 0 && len(r.Peers) > n {
+		r.Peers = r.Peers[:n]
+	}
+	return r
+}
@@ -0,0 +1,158 @@
+package flap
+
+import (
+	"testing"
+	"time"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/a16/go-rislive/pkg/rib"
+	"github.com/a16/go-rislive/pkg/rislivetest"
+	"github.com/stretchr/testify/assert"
+)
+
+// The tests damp the routes of two peers of the same collector.
+var (
+	morning = time.Date(2020, 9, 14, 8, 0, 0, 0, time.UTC)
+	first   = rib.PeerKey{Host: "rrc04", Peer: "2001:db8:4::1"}
+	second  = rib.PeerKey{Host: "rrc04", Peer: "2001:db8:4::2"}
+)
+
+func TestSuppressAndReuse(t *testing.T) {
+	assert := assert.New(t)
+	tr := NewTracker(DefaultConfig, time.Hour)
+	const p = "198.51.100.0/24"
+
+	assert.Empty(tr.ApplyUpdate(rislivetest.Announce(first, morning, []uint32{64496, 64500}, p)))
+	assert.Empty(tr.ApplyUpdate(rislivetest.Announce(first, morning.Add(30*time.Second), []uint32{64496, 64500}, p)))
+	penalty, suppressed := tr.Penalty(first, p)
+	assert.Equal(0.0, penalty)
+	assert.False(suppressed)
+
+	assert.Empty(tr.ApplyUpdate(rislivetest.Withdraw(first, morning.Add(time.Minute), p)))
+	assert.Empty(tr.ApplyUpdate(rislivetest.Withdraw(first, morning.Add(90*time.Second), p)))
+	assert.Empty(tr.ApplyUpdate(rislivetest.Announce(first, morning.Add(2*time.Minute), []uint32{64496, 64500}, p)))
+	assert.Empty(tr.ApplyUpdate(rislivetest.Withdraw(first, morning.Add(3*time.Minute), p)))
+	penalty, _ = tr.Penalty(first, p)
+	assert.InDelta(1911.7, penalty, 0.1)
+
+	assert.Empty(tr.ApplyUpdate(rislivetest.Announce(first, morning.Add(4*time.Minute), []uint32{64496, 64500}, p)))
+	events := tr.ApplyUpdate(rislivetest.Announce(first, morning.Add(5*time.Minute), []uint32{64496, 64501, 64500}, p))
+	if assert.Len(events, 1) {
+		e := events[0]
+		assert.Equal(Suppressed, e.Kind)
+		assert.Equal(first, e.PeerKey)
+		assert.Equal(p, e.Prefix)
+		assert.Equal(3, e.Flaps)
+		assert.InDelta(2243.0, e.Penalty, 0.1)
+		assert.Equal(morning.Add(5*time.Minute), e.Time)
+	}
+	_, suppressed = tr.Penalty(first, p)
+	assert.True(suppressed)
+
+	// 2243 decays below 750 after 23.7 minutes.
+	assert.Empty(tr.Decay(morning.Add(28 * time.Minute)))
+	events = tr.Decay(morning.Add(29 * time.Minute))
+	if assert.Len(events, 1) {
+		assert.Equal(Reused, events[0].Kind)
+		assert.Equal("198.51.100.0/24 reused from rrc04 2001:db8:4::1: penalty 740 after 3 flaps", events[0].String())
+	}
+}
+
+func TestCeiling(t *testing.T) {
+	tr := NewTracker(DefaultConfig, time.Hour)
+	const p = "198.51.100.0/24"
+	tr.ApplyUpdate(rislivetest.Announce(first, morning, []uint32{64496, 64500}, p))
+	for i := 1; i <= 20; i++ {
+		tr.ApplyUpdate(rislivetest.Withdraw(first, morning.Add(time.Duration(2*i)*time.Second), p))
+		tr.ApplyUpdate(rislivetest.Announce(first, morning.Add(time.Duration(2*i+1)*time.Second), []uint32{64496, 64500}, p))
+	}
+	penalty, suppressed := tr.Penalty(first, p)
+	assert.True(t, suppressed)
+	assert.InDelta(t, 12000, penalty, 100)
+}
+
+func TestPeerDown(t *testing.T) {
+	assert := assert.New(t)
+	tr := NewTracker(DefaultConfig, time.Hour)
+	const p = "198.51.100.0/24"
+	tr.ApplyUpdate(rislivetest.Announce(first, morning, []uint32{64496, 64500}, p))
+	events, err := tr.Apply(rislivetest.PeerState(first, morning.Add(time.Minute), "down"))
+	assert.NoError(err)
+	assert.Empty(events)
+	// The route is gone without penalty, so a withdrawal is not a flap.
+	tr.ApplyUpdate(rislivetest.Withdraw(first, morning.Add(2*time.Minute), p))
+	penalty, _ := tr.Penalty(first, p)
+	assert.Equal(0.0, penalty)
+	assert.Empty(tr.Report(10).Prefixes)
+}
+
+func TestSessionReset(t *testing.T) {
+	assert := assert.New(t)
+	config := DefaultConfig
+	config.ReannouncePenalty = 500
+	tr := NewTracker(config, time.Hour)
+	const p = "198.51.100.0/24"
+	tr.ApplyUpdate(rislivetest.Announce(first, morning, []uint32{64496, 64500}, p))
+	tr.ApplyUpdate(rislivetest.Withdraw(first, morning.Add(time.Minute), p))
+	tr.ApplyUpdate(rislivetest.Announce(first, morning.Add(2*time.Minute), []uint32{64496, 64500}, p))
+	events := tr.ApplyUpdate(rislivetest.Withdraw(first, morning.Add(3*time.Minute), p))
+	if assert.Len(events, 1) {
+		assert.Equal(Suppressed, events[0].Kind)
+	}
+
+	// An OPEN releases the suppressed route, and the routes of the peer are
+	// new: announcing them again is not charged.
+	events, err := tr.Apply(rislive.NewOpen().Host(first.Host).Peer(first.Peer, 64496).Time(morning.Add(4 * time.Minute)).MustMessage())
+	assert.NoError(err)
+	if assert.Len(events, 1) {
+		assert.Equal(Reused, events[0].Kind)
+		assert.Equal(first, events[0].PeerKey)
+		assert.Equal(p, events[0].Prefix)
+		assert.Equal(morning.Add(4*time.Minute), events[0].Time)
+	}
+	assert.Empty(tr.ApplyUpdate(rislivetest.Announce(first, morning.Add(5*time.Minute), []uint32{64496, 64500}, p)))
+	penalty, suppressed := tr.Penalty(first, p)
+	assert.Equal(0.0, penalty)
+	assert.False(suppressed)
+	assert.Empty(tr.Decay(morning.Add(time.Hour)))
+	assert.Equal([]PrefixCount{{Prefix: p, Flaps: 2, Peers: 1}}, tr.Report(0).Prefixes)
+}
+
+func TestReport(t *testing.T) {
+	assert := assert.New(t)
+	tr := NewTracker(DefaultConfig, time.Hour)
+	const p, q = "198.51.100.0/24", "203.0.113.0/24"
+	tr.ApplyUpdate(rislivetest.Announce(first, morning, []uint32{64496, 64500}, p))
+	tr.ApplyUpdate(rislivetest.Announce(second, morning, []uint32{64496, 64500}, p, q))
+	for _, f := range []struct {
+		peer   rib.PeerKey
+		at     time.Duration
+		prefix string
+	}{
+		{first, time.Minute, p},
+		{first, 70 * time.Minute, p},
+		{first, 71 * time.Minute, p},
+		{second, 72 * time.Minute, p},
+		{second, 73 * time.Minute, q},
+		{second, 74 * time.Minute, q},
+		{second, 75 * time.Minute, q},
+	} {
+		tr.ApplyUpdate(rislivetest.Withdraw(f.peer, morning.Add(f.at), f.prefix))
+		tr.ApplyUpdate(rislivetest.Announce(f.peer, morning.Add(f.at+time.Second), []uint32{64496, 64500}, f.prefix))
+	}
+
+	r := tr.Report(10)
+	assert.Equal(morning.Add(75*time.Minute+time.Second), r.End)
+	assert.Equal([]PrefixCount{{Prefix: p, Flaps: 3, Peers: 2}, {Prefix: q, Flaps: 3, Peers: 1}}, r.Prefixes)
+	assert.Equal([]PeerCount{{PeerKey: second, Flaps: 4, Prefixes: 2}, {PeerKey: first, Flaps: 2, Prefixes: 1}}, r.Peers)
+
+	r = tr.Report(1)
+	assert.Len(r.Prefixes, 1)
+	assert.Len(r.Peers, 1)
+
+	for _, n := range []int{0, -1} {
+		r = tr.Report(n)
+		assert.Len(r.Prefixes, 2, "n %d", n)
+		assert.Len(r.Peers, 2, "n %d", n)
+	}
+}
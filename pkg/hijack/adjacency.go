@@ -0,0 +1,171 @@
+package hijack
+
+import (
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/a16/go-rislive/pkg/prefixtree"
+	"github.com/a16/go-rislive/pkg/rib"
+)
+
+// AdjacencyDetector raises NewAdjacency alerts for paths of monitored
+// prefixes where the expected origin appears behind a neighbor that was not
+// seen during training, the signature of a type-N path manipulation.
+// Announcements by unexpected origins are left to Detector. It is safe for
+// concurrent use.
+type AdjacencyDetector struct {
+	mu       sync.Mutex
+	owned    *prefixtree.Tree
+	training time.Duration
+	start    time.Time
+	// neighbors holds the known neighbors of each origin.
+	neighbors map[uint32]map[uint32]bool
+	alerts    alertTable
+}
+
+// NewAdjacencyDetector returns a detector that learns neighbors without
+// raising alerts for the training period, measured in message time from the
+// first UPDATE it sees.
+func NewAdjacencyDetector(training time.Duration, owned ...Owned) *AdjacencyDetector {
+	d := &AdjacencyDetector{
+		owned:     prefixtree.New(),
+		training:  training,
+		neighbors: map[uint32]map[uint32]bool{},
+		alerts:    newAlertTable(),
+	}
+	for i := range owned {
+		o := owned[i]
+		d.owned.Insert(o.Prefix, &o)
+	}
+	return d
+}
+
+// Training reports whether the detector is still learning at time t.
+func (d *AdjacencyDetector) Training(t time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.inTraining(t)
+}
+
+func (d *AdjacencyDetector) inTraining(t time.Time) bool {
+	return d.start.IsZero() || t.Before(d.start.Add(d.training))
+}
+
+func (d *AdjacencyDetector) learn(origin, neighbor uint32) {
+	if d.neighbors[origin] == nil {
+		d.neighbors[origin] = map[uint32]bool{}
+	}
+	d.neighbors[origin][neighbor] = true
+}
+
+// Learn marks neighbor as a legitimate neighbor of origin and clears the
+// alerts raised for the adjacency.
+func (d *AdjacencyDetector) Learn(origin, neighbor uint32) []Event {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.learn(origin, neighbor)
+	var events []Event
+	for key, st := range d.alerts.alerts {
+		if key.typ != NewAdjacency || key.origin != origin || key.neighbor != neighbor {
+			continue
+		}
+		for peer := range st.sightings {
+			events = d.alerts.unsight(events, peer, key.prefix, key)
+		}
+	}
+	return events
+}
+
+// Neighbors returns the known neighbors of origin in ascending order.
+func (d *AdjacencyDetector) Neighbors(origin uint32) []uint32 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	asns := make([]uint32, 0, len(d.neighbors[origin]))
+	for asn := range d.neighbors[origin] {
+		asns = append(asns, asn)
+	}
+	sort.Slice(asns, func(i, j int) bool { return asns[i] < asns[j] })
+	return asns
+}
+
+// Apply learns or checks the origin adjacencies seen in an UPDATE, and
+// drops the sightings of a peer whose session ends with an OPEN or a
+// RIS_PEER_STATE "down". It returns the alert events that result.
+func (d *AdjacencyDetector) Apply(m *rislive.RisLiveMessage) ([]Event, error) {
+	var events []Event
+	err := m.Dispatch(rib.PeerEvents{
+		Update: func(u *rislive.RisMessageUpdate) { events = d.ApplyUpdate(u) },
+		Flush:  func(peer rib.PeerKey, _ time.Time) { events = d.FlushPeer(peer) },
+	})
+	return events, err
+}
+
+// check returns the alert an announcement causes, if any, learning the
+// adjacency of the origin while in training.
+func (d *AdjacencyDetector) check(prefix *net.IPNet, path rislive.ASPath, t time.Time) (alertKey, Alert, bool) {
+	owned := lookup(d.owned, prefix)
+	path = path.Dedup()
+	if owned == nil || len(path) < 2 || path[len(path)-2].IsSet() {
+		return alertKey{}, Alert{}, false
+	}
+	origin, ok := path.Origin()
+	if !ok || !owned.expected(origin) {
+		return alertKey{}, Alert{}, false
+	}
+	neighbor := path[len(path)-2].ASN
+	if d.inTraining(t) {
+		d.learn(origin, neighbor)
+	}
+	if d.neighbors[origin][neighbor] {
+		return alertKey{}, Alert{}, false
+	}
+	key := alertKey{typ: NewAdjacency, prefix: prefix.String(), origin: origin, neighbor: neighbor}
+	a := Alert{Type: NewAdjacency, Prefix: prefix, Owned: owned.Prefix, Origin: origin, Expected: owned.Origins, Neighbor: neighbor}
+	return key, a, true
+}
+
+// ApplyUpdate learns or checks the origin adjacencies in the paths of
+// monitored prefixes and clears sightings withdrawn or replaced.
+func (d *AdjacencyDetector) ApplyUpdate(u *rislive.RisMessageUpdate) []Event {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	// Training starts with the first message that has a timestamp.
+	if t := u.GetTimestamp(); d.start.IsZero() && t.After(time.Unix(0, 0)) {
+		d.start = t
+	}
+	var events []Event
+	u.EachRouteEvent(func(ev rislive.RouteEvent) bool {
+		prefix, ok := parsePrefix(ev.Prefix)
+		if !ok {
+			return true
+		}
+		peer := rib.PeerKey{Host: ev.Host, Peer: ev.Peer}
+		if ev.Kind == rislive.RouteAnnouncement {
+			if key, a, bad := d.check(prefix, ev.Path, ev.Time); bad {
+				events = d.alerts.sight(events, key, a, Sighting{PeerKey: peer, PeerASN: ev.PeerASN, Path: ev.Path, Time: ev.Time})
+				return true
+			}
+		}
+		events = d.alerts.withdraw(events, peer, prefix.String())
+		return true
+	})
+	return events
+}
+
+// FlushPeer removes every sighting of a peer, as when its session goes
+// down.
+func (d *AdjacencyDetector) FlushPeer(peer rib.PeerKey) []Event {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.alerts.flushPeer(peer)
+}
+
+// Alerts returns the active alerts ordered by first sighting.
+func (d *AdjacencyDetector) Alerts() []Alert {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.alerts.list()
+}
@@ -0,0 +1,85 @@
+package hijack
+
+import (
+	"testing"
+	"time"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdjacency(t *testing.T) {
+	assert := assert.New(t)
+	owned, err := ParseOwned("198.51.100.0/22", 64500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := NewAdjacencyDetector(time.Hour, owned)
+	announce := func(at time.Duration, prefix string, path ...uint32) []Event {
+		events, err := d.Apply(rislive.NewUpdate().Host("rrc00").Peer("192.0.2.1", 64496).
+			Time(t0.Add(at)).Path(path...).Announce("192.0.2.1", prefix).MustMessage())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return events
+	}
+
+	assert.True(d.Training(t0))
+	assert.Empty(announce(0, "198.51.100.0/22", 64496, 64510, 64500))
+	assert.Empty(announce(time.Minute, "198.51.100.0/22", 64496, 64520, 64500, 64500))
+	assert.Equal([]uint32{64510, 64520}, d.Neighbors(64500))
+	assert.False(d.Training(t0.Add(time.Hour)))
+
+	// Known neighbors, prepending, other origins and unrelated prefixes are
+	// fine after training.
+	assert.Empty(announce(2*time.Hour, "198.51.100.0/24", 64496, 64510, 64510, 64500))
+	assert.Empty(announce(2*time.Hour, "198.51.100.0/22", 64496, 64666))
+	assert.Empty(announce(2*time.Hour, "192.0.2.0/24", 64496, 64666, 64500))
+	assert.Empty(announce(2*time.Hour, "198.51.100.0/22", 64500))
+
+	events := announce(3*time.Hour, "198.51.100.0/22", 64496, 64666, 64500)
+	if assert.Len(events, 1) {
+		a := events[0].Alert
+		assert.Equal(AlertRaised, events[0].Kind)
+		assert.Equal(NewAdjacency, a.Type)
+		assert.Equal(uint32(64500), a.Origin)
+		assert.Equal(uint32(64666), a.Neighbor)
+		assert.Equal(t0.Add(3*time.Hour), a.FirstSeen)
+		assert.Equal([]string{"rrc00"}, a.Collectors())
+	}
+	assert.Equal([]uint32{64510, 64520}, d.Neighbors(64500))
+
+	// Returning to a known neighbor clears the alert.
+	events = announce(4*time.Hour, "198.51.100.0/22", 64496, 64510, 64500)
+	if assert.Len(events, 1) {
+		assert.Equal(AlertCleared, events[0].Kind)
+	}
+
+	announce(5*time.Hour, "198.51.100.0/22", 64496, 64666, 64500)
+	assert.Len(d.Alerts(), 1)
+	events = d.Learn(64500, 64666)
+	if assert.Len(events, 1) {
+		assert.Equal(AlertCleared, events[0].Kind)
+	}
+	assert.Empty(d.Alerts())
+	assert.Empty(announce(6*time.Hour, "198.51.101.0/24", 64496, 64666, 64500))
+}
+
+func TestAdjacencyTrainingStart(t *testing.T) {
+	assert := assert.New(t)
+	owned, err := ParseOwned("198.51.100.0/22", 64500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := NewAdjacencyDetector(time.Hour, owned)
+	// A message without a timestamp does not start training at 1970.
+	_, err = d.Apply(rislive.NewUpdate().Timestamp(0).Path(64496, 64510, 64500).
+		Announce("192.0.2.1", "198.51.100.0/22").MustMessage())
+	assert.NoError(err)
+	assert.True(d.Training(t0))
+	_, err = d.Apply(rislive.NewUpdate().Time(t0).Path(64496, 64520, 64500).
+		Announce("192.0.2.1", "198.51.100.0/22").MustMessage())
+	assert.NoError(err)
+	assert.True(d.Training(t0.Add(time.Minute)))
+	assert.False(d.Training(t0.Add(time.Hour)))
+}
@@ -0,0 +1,243 @@
+package hijack
+
+import (
+	"net"
+	"sort"
+	"time"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/a16/go-rislive/pkg/rib"
+)
+
+type AlertType int
+
+const (
+	// ExactPrefix is a monitored prefix announced by an unexpected origin.
+	ExactPrefix AlertType = iota
+	// SubPrefix is a more specific of a monitored prefix announced by an
+	// unexpected origin.
+	SubPrefix
+	// Squatting is an announcement of monitored space that should not be
+	// announced at all.
+	Squatting
+	// NewAdjacency is a path of a monitored prefix in which the origin has
+	// a neighbor not seen before.
+	NewAdjacency
+)
+
+func (t AlertType) String() string {
+	switch t {
+	case ExactPrefix:
+		return "exact-prefix"
+	case SubPrefix:
+		return "sub-prefix"
+	case Squatting:
+		return "squatting"
+	case NewAdjacency:
+		return "new-adjacency"
+	}
+	return "unknown"
+}
+
+// Sighting is the route of a single peer causing an alert.
+type Sighting struct {
+	rib.PeerKey
+	PeerASN string
+	Path    rislive.ASPath
+	Time    time.Time
+}
+
+type Alert struct {
+	Type AlertType
+	// Prefix is the announced prefix and Owned the monitored prefix
+	// covering it.
+	Prefix *net.IPNet
+	Owned  *net.IPNet
+	// Origin is the announcing ASN, zero when the path ends in an AS_SET.
+	Origin   uint32
+	Expected []uint32
+	// Neighbor is the ASN next to Origin in the path of NewAdjacency
+	// alerts.
+	Neighbor  uint32
+	FirstSeen time.Time
+	LastSeen  time.Time
+	// Sightings holds the peers currently carrying the route, sorted by
+	// collector and peer.
+	Sightings []Sighting
+}
+
+// Collectors returns the distinct collectors of the sightings.
+func (a *Alert) Collectors() []string {
+	var hosts []string
+	seen := map[string]bool{}
+	for _, s := range a.Sightings {
+		if !seen[s.Host] {
+			seen[s.Host] = true
+			hosts = append(hosts, s.Host)
+		}
+	}
+	return hosts
+}
+
+type EventKind int
+
+const (
+	// AlertRaised is sent when the first peer carries an offending route.
+	AlertRaised EventKind = iota
+	// AlertUpdated is sent when another peer starts carrying the route.
+	AlertUpdated
+	// AlertCleared is sent when no peer carries the route anymore.
+	AlertCleared
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case AlertRaised:
+		return "raised"
+	case AlertUpdated:
+		return "updated"
+	case AlertCleared:
+		return "cleared"
+	}
+	return "unknown"
+}
+
+// Event reports a change of an alert. Alert is a copy taken at the time of
+// the change.
+type Event struct {
+	Kind  EventKind
+	Alert Alert
+}
+
+type alertKey struct {
+	typ      AlertType
+	prefix   string
+	origin   uint32
+	neighbor uint32
+}
+
+type alertState struct {
+	alert     Alert
+	sightings map[rib.PeerKey]Sighting
+}
+
+func (s *alertState) snapshot() Alert {
+	a := s.alert
+	a.Sightings = make([]Sighting, 0, len(s.sightings))
+	for _, sg := range s.sightings {
+		a.Sightings = append(a.Sightings, sg)
+	}
+	sort.Slice(a.Sightings, func(i, j int) bool {
+		if a.Sightings[i].Host != a.Sightings[j].Host {
+			return a.Sightings[i].Host < a.Sightings[j].Host
+		}
+		return a.Sightings[i].Peer < a.Sightings[j].Peer
+	})
+	return a
+}
+
+// alertTable tracks the sightings of active alerts. A peer has at most one
+// offending route per prefix, so a new route of the peer for the prefix
+// replaces its sighting in another alert.
+type alertTable struct {
+	alerts map[alertKey]*alertState
+	// byPeer maps the prefixes each peer has an offending route for to the
+	// alert the route belongs to.
+	byPeer map[rib.PeerKey]map[string]alertKey
+}
+
+func newAlertTable() alertTable {
+	return alertTable{
+		alerts: map[alertKey]*alertState{},
+		byPeer: map[rib.PeerKey]map[string]alertKey{},
+	}
+}
+
+// sight records the route of a peer for alert key, creating the alert from
+// a when it is not active.
+func (t *alertTable) sight(events []Event, key alertKey, a Alert, s Sighting) []Event {
+	if prev, ok := t.byPeer[s.PeerKey][key.prefix]; ok && prev != key {
+		events = t.unsight(events, s.PeerKey, key.prefix, prev)
+	}
+	st, ok := t.alerts[key]
+	kind := AlertUpdated
+	if !ok {
+		kind = AlertRaised
+		a.FirstSeen = s.Time
+		st = &alertState{alert: a, sightings: map[rib.PeerKey]Sighting{}}
+		t.alerts[key] = st
+	}
+	_, known := st.sightings[s.PeerKey]
+	st.sightings[s.PeerKey] = s
+	if s.Time.After(st.alert.LastSeen) {
+		st.alert.LastSeen = s.Time
+	}
+	if t.byPeer[s.PeerKey] == nil {
+		t.byPeer[s.PeerKey] = map[string]alertKey{}
+	}
+	t.byPeer[s.PeerKey][key.prefix] = key
+	if known {
+		return events
+	}
+	return append(events, Event{Kind: kind, Alert: st.snapshot()})
+}
+
+// withdraw removes the sighting of a peer for prefix, if any.
+func (t *alertTable) withdraw(events []Event, peer rib.PeerKey, prefix string) []Event {
+	if key, ok := t.byPeer[peer][prefix]; ok {
+		events = t.unsight(events, peer, prefix, key)
+	}
+	return events
+}
+
+// unsight removes the sighting of peer from an alert, clearing the alert
+// when it was the last one.
+func (t *alertTable) unsight(events []Event, peer rib.PeerKey, prefix string, key alertKey) []Event {
+	delete(t.byPeer[peer], prefix)
+	if len(t.byPeer[peer]) == 0 {
+		delete(t.byPeer, peer)
+	}
+	st, ok := t.alerts[key]
+	if !ok {
+		return events
+	}
+	delete(st.sightings, peer)
+	if len(st.sightings) > 0 {
+		return events
+	}
+	delete(t.alerts, key)
+	return append(events, Event{Kind: AlertCleared, Alert: st.snapshot()})
+}
+
+func (t *alertTable) flushPeer(peer rib.PeerKey) []Event {
+	prefixes := make([]string, 0, len(t.byPeer[peer]))
+	for prefix := range t.byPeer[peer] {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	var events []Event
+	for _, prefix := range prefixes {
+		events = t.unsight(events, peer, prefix, t.byPeer[peer][prefix])
+	}
+	return events
+}
+
+// list returns the active alerts ordered by first sighting.
+func (t *alertTable) list() []Alert {
+	alerts := make([]Alert, 0, len(t.alerts))
+	for _, st := range t.alerts {
+		alerts = append(alerts, st.snapshot())
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		if !alerts[i].FirstSeen.Equal(alerts[j].FirstSeen) {
+			return alerts[i].FirstSeen.Before(alerts[j].FirstSeen)
+		}
+		return alerts[i].Prefix.String() < alerts[j].Prefix.String()
+	})
+	return alerts
+}
+
+func parsePrefix(s string) (*net.IPNet, bool) {
+	_, p, err := net.ParseCIDR(s)
+	return p, err == nil
+}
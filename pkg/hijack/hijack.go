@@ -0,0 +1,146 @@
+// Package hijack raises alerts for announcements of monitored address space
+// by unexpected origins or through unknown origin adjacencies, as seen in
+// the RIS Live update stream.
+package hijack
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/a16/go-rislive/pkg/prefixtree"
+	"github.com/a16/go-rislive/pkg/rib"
+)
+
+// Owned is monitored address space. Announcements of Prefix or any more
+// specific are expected to originate from one of Origins. Unannounced space
+// is normally not routed, so any announcement of it by other origins is
+// squatting.
+type Owned struct {
+	Prefix      *net.IPNet
+	Origins     []uint32
+	Unannounced bool
+}
+
+// ParseOwned returns the Owned for a prefix in CIDR notation.
+func ParseOwned(prefix string, origins ...uint32) (Owned, error) {
+	_, p, err := net.ParseCIDR(prefix)
+	if err != nil {
+		return Owned{}, err
+	}
+	return Owned{Prefix: p, Origins: origins}, nil
+}
+
+func (o *Owned) expected(asns ...uint32) bool {
+	for _, asn := range asns {
+		for _, origin := range o.Origins {
+			if asn == origin {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Detector checks announcements against the monitored space and keeps track
+// of the alerts that are active. It is safe for concurrent use.
+type Detector struct {
+	mu     sync.Mutex
+	owned  *prefixtree.Tree
+	alerts alertTable
+}
+
+func NewDetector(owned ...Owned) *Detector {
+	d := &Detector{
+		owned:  prefixtree.New(),
+		alerts: newAlertTable(),
+	}
+	for i := range owned {
+		o := owned[i]
+		d.owned.Insert(o.Prefix, &o)
+	}
+	return d
+}
+
+// Apply checks the announcements of an UPDATE against the monitored space.
+// An OPEN or a RIS_PEER_STATE "down" forgets what the peer announced, which
+// may clear alerts. The alert events raised or cleared are returned.
+func (d *Detector) Apply(m *rislive.RisLiveMessage) ([]Event, error) {
+	var events []Event
+	err := m.Dispatch(rib.PeerEvents{
+		Update: func(u *rislive.RisMessageUpdate) { events = d.ApplyUpdate(u) },
+		Flush:  func(peer rib.PeerKey, _ time.Time) { events = d.FlushPeer(peer) },
+	})
+	return events, err
+}
+
+// lookup returns the most specific monitored space covering prefix.
+func lookup(tree *prefixtree.Tree, prefix *net.IPNet) *Owned {
+	var owned *Owned
+	tree.Covering(prefix, func(_ *net.IPNet, v interface{}) bool {
+		owned = v.(*Owned)
+		return true
+	})
+	return owned
+}
+
+// classify returns the alert an announcement causes, if any.
+func (d *Detector) classify(prefix *net.IPNet, path rislive.ASPath) (alertKey, *Owned, uint32, bool) {
+	owned := lookup(d.owned, prefix)
+	if owned == nil || len(path) == 0 {
+		return alertKey{}, nil, 0, false
+	}
+	origin, ok := path.Origin()
+	if (ok && owned.expected(origin)) || (!ok && owned.expected(path[len(path)-1].Set...)) {
+		return alertKey{}, nil, 0, false
+	}
+	typ := SubPrefix
+	switch {
+	case owned.Unannounced:
+		typ = Squatting
+	case prefix.String() == owned.Prefix.String():
+		typ = ExactPrefix
+	}
+	return alertKey{typ: typ, prefix: prefix.String(), origin: origin}, owned, origin, true
+}
+
+// ApplyUpdate checks the announcements of an UPDATE and clears sightings
+// withdrawn or replaced by it.
+func (d *Detector) ApplyUpdate(u *rislive.RisMessageUpdate) []Event {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var events []Event
+	u.EachRouteEvent(func(ev rislive.RouteEvent) bool {
+		prefix, ok := parsePrefix(ev.Prefix)
+		if !ok {
+			return true
+		}
+		peer := rib.PeerKey{Host: ev.Host, Peer: ev.Peer}
+		if ev.Kind == rislive.RouteAnnouncement {
+			if key, owned, origin, bad := d.classify(prefix, ev.Path); bad {
+				a := Alert{Type: key.typ, Prefix: prefix, Owned: owned.Prefix, Origin: origin, Expected: owned.Origins}
+				events = d.alerts.sight(events, key, a, Sighting{PeerKey: peer, PeerASN: ev.PeerASN, Path: ev.Path, Time: ev.Time})
+				return true
+			}
+		}
+		events = d.alerts.withdraw(events, peer, prefix.String())
+		return true
+	})
+	return events
+}
+
+// FlushPeer removes every sighting of a peer, as when its session goes
+// down.
+func (d *Detector) FlushPeer(peer rib.PeerKey) []Event {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.alerts.flushPeer(peer)
+}
+
+// Alerts returns the active alerts ordered by first sighting.
+func (d *Detector) Alerts() []Alert {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.alerts.list()
+}
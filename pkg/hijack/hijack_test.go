@@ -0,0 +1,136 @@
+package hijack
+
+import (
+	"testing"
+	"time"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/a16/go-rislive/pkg/rib"
+	"github.com/stretchr/testify/assert"
+)
+
+var t0 = time.Date(2019, 7, 11, 0, 0, 0, 0, time.UTC)
+
+func detector(t *testing.T) *Detector {
+	owned, err := ParseOwned("198.51.100.0/22", 64500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unannounced, err := ParseOwned("203.0.113.0/24")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unannounced.Unannounced = true
+	return NewDetector(owned, unannounced)
+}
+
+func apply(t *testing.T, d *Detector, b *rislive.Builder) []Event {
+	events, err := d.Apply(b.MustMessage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return events
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		Description string
+		Path        []uint32
+		Set         []uint32
+		Prefix      string
+		Type        AlertType
+		Alert       bool
+	}{
+		{"legitimate", []uint32{64496, 64500}, nil, "198.51.100.0/22", 0, false},
+		{"legitimate more specific", []uint32{64496, 64500}, nil, "198.51.100.0/24", 0, false},
+		{"exact prefix", []uint32{64496, 64666}, nil, "198.51.100.0/22", ExactPrefix, true},
+		{"sub-prefix", []uint32{64496, 64666}, nil, "198.51.101.0/24", SubPrefix, true},
+		{"less specific", []uint32{64496, 64666}, nil, "198.51.96.0/20", 0, false},
+		{"unrelated", []uint32{64496, 64666}, nil, "192.0.2.0/24", 0, false},
+		{"squatting", []uint32{64496, 64666}, nil, "203.0.113.0/24", Squatting, true},
+		{"squatting more specific", []uint32{64496, 64666}, nil, "203.0.113.128/25", Squatting, true},
+		{"set origin expected", []uint32{64496}, []uint32{64500, 64501}, "198.51.100.0/22", 0, false},
+		{"set origin unexpected", []uint32{64496}, []uint32{64666}, "198.51.100.0/22", ExactPrefix, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Description, func(t *testing.T) {
+			assert := assert.New(t)
+			b := rislive.NewUpdate().Host("rrc00").Peer("192.0.2.1", 64496).Time(t0).
+				Path(tt.Path...).Announce("192.0.2.1", tt.Prefix)
+			if tt.Set != nil {
+				b.PathSet(tt.Set...)
+			}
+			events := apply(t, detector(t), b)
+			if !tt.Alert {
+				assert.Empty(events)
+				return
+			}
+			if assert.Len(events, 1) {
+				assert.Equal(AlertRaised, events[0].Kind)
+				assert.Equal(tt.Type, events[0].Alert.Type)
+				assert.Equal(tt.Prefix, events[0].Alert.Prefix.String())
+			}
+		})
+	}
+}
+
+func TestAlertLifecycle(t *testing.T) {
+	assert := assert.New(t)
+	d := detector(t)
+	hijack := func(host, peer string, asn uint32, at time.Duration) *rislive.Builder {
+		return rislive.NewUpdate().Host(host).Peer(peer, asn).Time(t0.Add(at)).
+			Path(asn, 64666).Announce(peer, "198.51.100.0/24")
+	}
+
+	events := apply(t, d, hijack("rrc00", "192.0.2.1", 64496, 0))
+	assert.Len(events, 1)
+	assert.Equal(AlertRaised, events[0].Kind)
+	a := events[0].Alert
+	assert.Equal(SubPrefix, a.Type)
+	assert.Equal(uint32(64666), a.Origin)
+	assert.Equal([]uint32{64500}, a.Expected)
+	assert.Equal("198.51.100.0/22", a.Owned.String())
+
+	// The same route again changes nothing.
+	assert.Empty(apply(t, d, hijack("rrc00", "192.0.2.1", 64496, time.Second)))
+
+	events = apply(t, d, hijack("rrc13", "192.0.2.2", 64497, 2*time.Second))
+	assert.Len(events, 1)
+	assert.Equal(AlertUpdated, events[0].Kind)
+	a = events[0].Alert
+	assert.Equal([]string{"rrc00", "rrc13"}, a.Collectors())
+	assert.Equal(t0, a.FirstSeen)
+	assert.Equal(t0.Add(2*time.Second), a.LastSeen)
+	assert.Equal(rib.PeerKey{Host: "rrc13", Peer: "192.0.2.2"}, a.Sightings[1].PeerKey)
+	assert.Equal(rislive.ASPath{{ASN: 64497}, {ASN: 64666}}, a.Sightings[1].Path)
+	assert.Len(d.Alerts(), 1)
+
+	// An implicit withdrawal by a legitimate route removes the sighting.
+	assert.Empty(apply(t, d, rislive.NewUpdate().Host("rrc00").Peer("192.0.2.1", 64496).
+		Path(64496, 64500).Announce("192.0.2.1", "198.51.100.0/24")))
+	assert.Len(d.Alerts()[0].Sightings, 1)
+
+	events = apply(t, d, rislive.NewPeerState("down").Host("rrc13").Peer("192.0.2.2", 64497))
+	assert.Len(events, 1)
+	assert.Equal(AlertCleared, events[0].Kind)
+	assert.Empty(events[0].Alert.Sightings)
+	assert.Empty(d.Alerts())
+}
+
+func TestOriginChange(t *testing.T) {
+	assert := assert.New(t)
+	d := detector(t)
+	apply(t, d, rislive.NewUpdate().Host("rrc00").Peer("192.0.2.1", 64496).
+		Path(64496, 64666).Announce("192.0.2.1", "198.51.100.0/22"))
+	events := apply(t, d, rislive.NewUpdate().Host("rrc00").Peer("192.0.2.1", 64496).
+		Path(64496, 64777).Announce("192.0.2.1", "198.51.100.0/22"))
+	assert.Len(events, 2)
+	assert.Equal(AlertCleared, events[0].Kind)
+	assert.Equal(uint32(64666), events[0].Alert.Origin)
+	assert.Equal(AlertRaised, events[1].Kind)
+	assert.Equal(uint32(64777), events[1].Alert.Origin)
+
+	events = apply(t, d, rislive.NewUpdate().Host("rrc00").Peer("192.0.2.1", 64496).Withdraw("198.51.100.0/22"))
+	assert.Len(events, 1)
+	assert.Equal(AlertCleared, events[0].Kind)
+}
@@ -0,0 +1,128 @@
+package leak
+
+import (
+	"bufio"
+	"compress/bzip2"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Relationship is what an AS is to another one.
+type Relationship int
+
+const (
+	Unknown Relationship = iota
+	Customer
+	Provider
+	Peer
+)
+
+func (r Relationship) String() string {
+	switch r {
+	case Customer:
+		return "customer"
+	case Provider:
+		return "provider"
+	case Peer:
+		return "peer"
+	}
+	return "unknown"
+}
+
+type asPair struct {
+	a, b uint32
+}
+
+// Relationships holds AS relationships inferred by CAIDA.
+type Relationships struct {
+	rels map[asPair]Relationship
+}
+
+func NewRelationships() *Relationships {
+	return &Relationships{rels: map[asPair]Relationship{}}
+}
+
+// Set records that b is rel of a, and the reverse relationship.
+func (r *Relationships) Set(a, b uint32, rel Relationship) {
+	r.rels[asPair{a, b}] = rel
+	switch rel {
+	case Customer:
+		r.rels[asPair{b, a}] = Provider
+	case Provider:
+		r.rels[asPair{b, a}] = Customer
+	default:
+		r.rels[asPair{b, a}] = rel
+	}
+}
+
+// Get returns what b is to a.
+func (r *Relationships) Get(a, b uint32) Relationship {
+	return r.rels[asPair{a, b}]
+}
+
+// Len returns the number of AS pairs with a relationship.
+func (r *Relationships) Len() int {
+	return len(r.rels) / 2
+}
+
+// ReadRelationships reads the CAIDA as-rel format, lines of
+// "<provider>|<customer>|-1" or "<peer>|<peer>|0" with an optional
+// trailing source field as in as-rel2. Lines starting with # are comments.
+func ReadRelationships(rd io.Reader) (*Relationships, error) {
+	r := NewRelationships()
+	scanner := bufio.NewScanner(rd)
+	n := 0
+	for scanner.Scan() {
+		n++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		fields := strings.Split(line, "|")
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("line %d: invalid relationship: %s", n, line)
+		}
+		a, err := strconv.ParseUint(fields[0], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid asn: %s", n, fields[0])
+		}
+		b, err := strconv.ParseUint(fields[1], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid asn: %s", n, fields[1])
+		}
+		switch fields[2] {
+		case "-1":
+			r.Set(uint32(a), uint32(b), Customer)
+		case "0":
+			r.Set(uint32(a), uint32(b), Peer)
+		default:
+			return nil, fmt.Errorf("line %d: invalid relationship type: %s", n, fields[2])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// LoadRelationships reads a CAIDA as-rel file, which is decompressed when
+// its name ends in .bz2 as published.
+func LoadRelationships(path string) (*Relationships, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var rd io.Reader = f
+	if strings.HasSuffix(path, ".bz2") {
+		rd = bzip2.NewReader(f)
+	}
+	r, err := ReadRelationships(rd)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return r, nil
+}
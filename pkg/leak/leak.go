@@ -0,0 +1,175 @@
+// Package leak detects route leaks in announced AS paths, as violations of
+// the valley-free property given AS relationships and of the Only to
+// Customer attribute (RFC 9234).
+package leak
+
+import (
+	"fmt"
+
+	"github.com/a16/go-rislive/pkg/bgp"
+	rislive "github.com/a16/go-rislive/pkg/message"
+)
+
+// Kind tells how a leak was detected.
+type Kind int
+
+const (
+	// ValleyFree is a route passed on to a provider or peer after being
+	// learned from a provider or peer.
+	ValleyFree Kind = iota
+	// OnlyToCustomer is a route passed on to a provider or peer after the
+	// OTC attribute was set.
+	OnlyToCustomer
+)
+
+func (k Kind) String() string {
+	switch k {
+	case ValleyFree:
+		return "valley-free"
+	case OnlyToCustomer:
+		return "otc"
+	}
+	return "unknown"
+}
+
+// Leak is a route leak found in the path of an announcement.
+type Leak struct {
+	Kind Kind
+	rislive.RouteEvent
+	// Leaker is the AS that passed the route on.
+	Leaker uint32
+	// Segment is the part of the path around the leak, in path order: the
+	// AS the route was leaked to, the leaker and the AS it learned the
+	// route from.
+	Segment []uint32
+	// OTC is the value of the OTC attribute, if any.
+	OTC uint32
+}
+
+func (l Leak) String() string {
+	return fmt.Sprintf("%s leak of %s by AS%d: %v", l.Kind, l.Prefix, l.Leaker, l.Segment)
+}
+
+// Detector checks announcements for leaks. It only reads its
+// relationships and is safe for concurrent use.
+type Detector struct {
+	rels *Relationships
+}
+
+func NewDetector(rels *Relationships) *Detector {
+	return &Detector{rels: rels}
+}
+
+// Check returns the leak in a path, if any. OTC is the value of the OTC
+// attribute, or zero. Only the hops within the path are judged, skipping
+// those with AS_SETs or unknown relationships: collectors receive full
+// feeds, whatever their peers learned the routes from.
+func (d *Detector) Check(path rislive.ASPath, otc uint32) (Leak, bool) {
+	path = path.Dedup()
+	n := len(path)
+	// as holds the path from the origin towards the neighbor.
+	as := make([]uint32, n)
+	for i, h := range path {
+		as[n-1-i] = h.ASN
+	}
+	// rel returns what as[i+1] is to as[i].
+	rel := func(i int) Relationship {
+		if path[n-1-i].IsSet() || path[n-2-i].IsSet() {
+			return Unknown
+		}
+		return d.rels.Get(as[i], as[i+1])
+	}
+	leak := func(kind Kind, i int) (Leak, bool) {
+		return Leak{Kind: kind, Leaker: as[i], Segment: []uint32{as[i+1], as[i], as[i-1]}, OTC: otc}, true
+	}
+
+	if otc != 0 {
+		// The route may go to a peer or customer of the AS that set the
+		// attribute, and then only down to customers.
+		for start, asn := range as {
+			if asn != otc {
+				continue
+			}
+			for i := start + 1; i+1 < n; i++ {
+				if r := rel(i); r == Provider || r == Peer {
+					return leak(OnlyToCustomer, i)
+				}
+			}
+			break
+		}
+	}
+
+	down := false
+	for i := 0; i+1 < n; i++ {
+		switch rel(i) {
+		case Provider:
+			if down {
+				return leak(ValleyFree, i)
+			}
+		case Peer:
+			if down {
+				return leak(ValleyFree, i)
+			}
+			down = true
+		case Customer:
+			down = true
+		}
+	}
+	return Leak{}, false
+}
+
+// CheckUpdate returns the leaks in the announcements of an UPDATE. RIS Live
+// only conveys the OTC attribute in the raw message, so OnlyToCustomer
+// leaks are found when the subscription included raw messages.
+func (d *Detector) CheckUpdate(u *rislive.RisMessageUpdate) []Leak {
+	otc := rawOTC(u)
+	var leaks []Leak
+	u.EachRouteEvent(func(ev rislive.RouteEvent) bool {
+		if ev.Kind != rislive.RouteAnnouncement {
+			return true
+		}
+		if l, ok := d.Check(ev.Path, otc); ok {
+			l.RouteEvent = ev
+			leaks = append(leaks, l)
+		}
+		return true
+	})
+	return leaks
+}
+
+// rawOTC returns the OTC attribute of the raw message of an UPDATE, or zero.
+// The ASN size of the session is not known, so 2-octet AS_PATHs are tried
+// when 4-octet ones do not decode.
+func rawOTC(u *rislive.RisMessageUpdate) uint32 {
+	b, ok := bgp.RawMessage(u.RisMessageCommon)
+	if !ok {
+		return 0
+	}
+	h, body, err := bgp.DecodeHeader(b)
+	if err != nil || h.Type != bgp.MsgUpdate {
+		return 0
+	}
+	for _, opts := range []bgp.DecodeOptions{{}, {AS2: true}} {
+		if m, err := bgp.DecodeUpdate(body, opts); err == nil {
+			return m.Attributes.OTC
+		}
+	}
+	return 0
+}
+
+type checker struct {
+	rislive.NopHandler
+	d     *Detector
+	leaks []Leak
+}
+
+func (h *checker) HandleUpdate(u *rislive.RisMessageUpdate) {
+	h.leaks = h.d.CheckUpdate(u)
+}
+
+// Apply returns the leaks in a message, which are only found in UPDATEs.
+func (d *Detector) Apply(m *rislive.RisLiveMessage) ([]Leak, error) {
+	h := &checker{d: d}
+	err := m.Dispatch(h)
+	return h.leaks, err
+}
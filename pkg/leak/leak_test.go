@@ -0,0 +1,111 @@
+package leak
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/stretchr/testify/assert"
+)
+
+func mustPath(s string) rislive.ASPath {
+	var p rislive.ASPath
+	if err := p.UnmarshalJSON([]byte(s)); err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func TestLoadRelationships(t *testing.T) {
+	for _, name := range []string{"as-rel.txt", "as-rel.txt.bz2"} {
+		r, err := LoadRelationships(filepath.Join("testdata", name))
+		if !assert.NoError(t, err, name) {
+			continue
+		}
+		assert.Equal(t, 8, r.Len())
+		assert.Equal(t, Customer, r.Get(64510, 64500))
+		assert.Equal(t, Provider, r.Get(64500, 64510))
+		assert.Equal(t, Peer, r.Get(64511, 64510))
+		assert.Equal(t, Unknown, r.Get(64500, 64550))
+	}
+}
+
+func TestReadRelationshipsErrors(t *testing.T) {
+	for _, doc := range []string{
+		"64510|64500",
+		"AS64510|64500|-1",
+		"64510|x|-1",
+		"64510|64500|1",
+	} {
+		_, err := ReadRelationships(strings.NewReader(doc))
+		assert.Error(t, err, doc)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	rels, err := LoadRelationships("testdata/as-rel.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := NewDetector(rels)
+	tests := []struct {
+		Path    string
+		OTC     uint32
+		Leak    bool
+		Kind    Kind
+		Leaker  uint32
+		Segment []uint32
+	}{
+		{`[64520, 64510, 64500]`, 0, false, 0, 0, nil},
+		{`[64540, 64530, 64520, 64510, 64500]`, 0, false, 0, 0, nil},
+		{`[64520, 64511, 64510, 64500]`, 0, true, ValleyFree, 64511, []uint32{64520, 64511, 64510}},
+		{`[64520, 64511, 64511, 64510, 64500]`, 0, true, ValleyFree, 64511, []uint32{64520, 64511, 64510}},
+		{`[64511, 64510, 64520, 64530]`, 0, true, ValleyFree, 64510, []uint32{64511, 64510, 64520}},
+		{`[64599, 64510, 64500]`, 0, false, 0, 0, nil},
+		{`[64520, [64510, 64511], 64500]`, 0, false, 0, 0, nil},
+		{`[64540, 64530, 64520, 64510, 64500]`, 64520, false, 0, 0, nil},
+		{`[64540, 64530, 64520, 64510, 64500]`, 64510, true, OnlyToCustomer, 64520, []uint32{64530, 64520, 64510}},
+		{`[64540, 64530, 64520, 64510, 64500]`, 64599, false, 0, 0, nil},
+		{`[]`, 0, false, 0, 0, nil},
+	}
+	for _, tt := range tests {
+		l, ok := d.Check(mustPath(tt.Path), tt.OTC)
+		if !assert.Equal(t, tt.Leak, ok, "%s otc %d", tt.Path, tt.OTC) || !ok {
+			continue
+		}
+		assert.Equal(t, tt.Kind, l.Kind, tt.Path)
+		assert.Equal(t, tt.Leaker, l.Leaker, tt.Path)
+		assert.Equal(t, tt.Segment, l.Segment, tt.Path)
+		assert.Equal(t, tt.OTC, l.OTC, tt.Path)
+	}
+}
+
+func TestApply(t *testing.T) {
+	assert := assert.New(t)
+	rels, err := LoadRelationships("testdata/as-rel.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := NewDetector(rels)
+	// The raw message carries the OTC attribute set by AS64510.
+	b := rislive.NewUpdate().Host("rrc00").Peer("192.0.2.1", 64540).Path(64540, 64530, 64520, 64510, 64500).
+		Announce("192.0.2.1", "198.51.100.0/24").Withdraw("203.0.113.0/24")
+	leaks, err := d.Apply(b.MustMessage())
+	assert.NoError(err)
+	assert.Empty(leaks)
+
+	b.Raw("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF004A02000418CB0071002B4001010040021602050000FC1C0000FC120000FC080000FBFE0000FBF4400304C0000201C023040000FBFE18C63364")
+	leaks, err = d.Apply(b.MustMessage())
+	assert.NoError(err)
+	if assert.Len(leaks, 1) {
+		assert.Equal("198.51.100.0/24", leaks[0].Prefix)
+		assert.Equal("rrc00", leaks[0].Host)
+		assert.Equal(uint32(64510), leaks[0].OTC)
+		assert.Equal("otc leak of 198.51.100.0/24 by AS64520: [64530 64520 64510]", leaks[0].String())
+	}
+
+	leaks, err = d.Apply(rislive.NewKeepalive().Host("rrc00").Peer("192.0.2.1", 64540).MustMessage())
+	assert.NoError(err)
+	assert.Empty(leaks)
+}
@@ -0,0 +1,142 @@
+package rislive
+
+import "fmt"
+
+// AsOpen returns the OPEN payload of a ris_message.
+func (m *RisLiveMessage) AsOpen() (*RisMessageOpen, bool) {
+	if m.Type != "ris_message" {
+		return nil, false
+	}
+	o, ok := m.Data.(*RisMessageOpen)
+	return o, ok && o != nil
+}
+
+// AsUpdate returns the UPDATE payload of a ris_message.
+func (m *RisLiveMessage) AsUpdate() (*RisMessageUpdate, bool) {
+	if m.Type != "ris_message" {
+		return nil, false
+	}
+	u, ok := m.Data.(*RisMessageUpdate)
+	return u, ok && u != nil
+}
+
+// AsKeepalive returns the KEEPALIVE payload of a ris_message.
+func (m *RisLiveMessage) AsKeepalive() (*RisMessageKeepalive, bool) {
+	if m.Type != "ris_message" {
+		return nil, false
+	}
+	k, ok := m.Data.(*RisMessageKeepalive)
+	return k, ok && k != nil
+}
+
+// AsNotification returns the NOTIFICATION payload of a ris_message.
+func (m *RisLiveMessage) AsNotification() (*RisMessageNotification, bool) {
+	if m.Type != "ris_message" {
+		return nil, false
+	}
+	n, ok := m.Data.(*RisMessageNotification)
+	return n, ok && n != nil
+}
+
+// AsPeerState returns the RIS_PEER_STATE payload of a ris_message.
+func (m *RisLiveMessage) AsPeerState() (*RisMessageRisPeerState, bool) {
+	if m.Type != "ris_message" {
+		return nil, false
+	}
+	r, ok := m.Data.(*RisMessageRisPeerState)
+	return r, ok && r != nil
+}
+
+// AsError returns the payload of a ris_error.
+func (m *RisLiveMessage) AsError() (*RisError, bool) {
+	if m.Type != "ris_error" {
+		return nil, false
+	}
+	switch e := m.Data.(type) {
+	case *RisError:
+		return e, e != nil
+	case RisError:
+		return &e, true
+	}
+	return nil, false
+}
+
+// AsRrcList returns the payload of a ris_rrc_list. Both value and pointer
+// forms of Data are accepted.
+func (m *RisLiveMessage) AsRrcList() (RisRrcList, bool) {
+	if m.Type != "ris_rrc_list" {
+		return nil, false
+	}
+	switch l := m.Data.(type) {
+	case RisRrcList:
+		return l, true
+	case *RisRrcList:
+		if l == nil {
+			return nil, false
+		}
+		return *l, true
+	}
+	return nil, false
+}
+
+// Handler receives decoded messages from Dispatch, one method per message kind.
+type Handler interface {
+	HandleOpen(*RisMessageOpen)
+	HandleUpdate(*RisMessageUpdate)
+	HandleKeepalive(*RisMessageKeepalive)
+	HandleNotification(*RisMessageNotification)
+	HandlePeerState(*RisMessageRisPeerState)
+	HandleError(*RisError)
+	HandleRrcList(RisRrcList)
+	HandlePong()
+}
+
+// NopHandler implements Handler by ignoring everything. Embed it to handle
+// only the message kinds of interest.
+type NopHandler struct{}
+
+func (NopHandler) HandleOpen(*RisMessageOpen)                 {}
+func (NopHandler) HandleUpdate(*RisMessageUpdate)             {}
+func (NopHandler) HandleKeepalive(*RisMessageKeepalive)       {}
+func (NopHandler) HandleNotification(*RisMessageNotification) {}
+func (NopHandler) HandlePeerState(*RisMessageRisPeerState)    {}
+func (NopHandler) HandleError(*RisError)                      {}
+func (NopHandler) HandleRrcList(RisRrcList)                   {}
+func (NopHandler) HandlePong()                                {}
+
+// Dispatch calls the Handler method matching the message kind. Messages
+// without a Handler method, such as ris_subscribe_ok, are ignored. An error
+// is returned when Data does not hold the payload expected for Type.
+func (m *RisLiveMessage) Dispatch(h Handler) error {
+	switch m.Type {
+	case "ris_message":
+		if o, ok := m.AsOpen(); ok {
+			h.HandleOpen(o)
+		} else if u, ok := m.AsUpdate(); ok {
+			h.HandleUpdate(u)
+		} else if k, ok := m.AsKeepalive(); ok {
+			h.HandleKeepalive(k)
+		} else if n, ok := m.AsNotification(); ok {
+			h.HandleNotification(n)
+		} else if r, ok := m.AsPeerState(); ok {
+			h.HandlePeerState(r)
+		} else {
+			return fmt.Errorf("unexpected ris_message data: %s", m.BgpMsgType)
+		}
+	case "ris_error":
+		e, ok := m.AsError()
+		if !ok {
+			return fmt.Errorf("unexpected ris_error data: %T", m.Data)
+		}
+		h.HandleError(e)
+	case "ris_rrc_list":
+		l, ok := m.AsRrcList()
+		if !ok {
+			return fmt.Errorf("unexpected ris_rrc_list data: %T", m.Data)
+		}
+		h.HandleRrcList(l)
+	case "pong":
+		h.HandlePong()
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package rislive
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingHandler struct {
+	NopHandler
+	called []string
+}
+
+func (h *recordingHandler) HandleOpen(*RisMessageOpen)     { h.called = append(h.called, "OPEN") }
+func (h *recordingHandler) HandleUpdate(*RisMessageUpdate) { h.called = append(h.called, "UPDATE") }
+func (h *recordingHandler) HandleKeepalive(*RisMessageKeepalive) {
+	h.called = append(h.called, "KEEPALIVE")
+}
+func (h *recordingHandler) HandleNotification(*RisMessageNotification) {
+	h.called = append(h.called, "NOTIFICATION")
+}
+func (h *recordingHandler) HandlePeerState(*RisMessageRisPeerState) {
+	h.called = append(h.called, "RIS_PEER_STATE")
+}
+func (h *recordingHandler) HandleError(*RisError)    { h.called = append(h.called, "ris_error") }
+func (h *recordingHandler) HandleRrcList(RisRrcList) { h.called = append(h.called, "ris_rrc_list") }
+func (h *recordingHandler) HandlePong()              { h.called = append(h.called, "pong") }
+
+func TestAccessors(t *testing.T) {
+	for _, ex := range examples {
+		t.Run(ex.Description, func(t *testing.T) {
+			assert := assert.New(t)
+			var r RisLiveMessage
+			assert.NoError(json.Unmarshal([]byte(ex.ReceivedMsg), &r))
+
+			_, isOpen := r.AsOpen()
+			_, isUpdate := r.AsUpdate()
+			_, isKeepalive := r.AsKeepalive()
+			_, isNotification := r.AsNotification()
+			_, isPeerState := r.AsPeerState()
+			_, isError := r.AsError()
+			_, isRrcList := r.AsRrcList()
+			assert.Equal(ex.BgpMsgType == "OPEN", isOpen)
+			assert.Equal(ex.BgpMsgType == "UPDATE", isUpdate)
+			assert.Equal(ex.BgpMsgType == "KEEPALIVE", isKeepalive)
+			assert.Equal(ex.BgpMsgType == "NOTIFICATION", isNotification)
+			assert.Equal(ex.BgpMsgType == "RIS_PEER_STATE", isPeerState)
+			assert.Equal(ex.Type == "ris_error", isError)
+			assert.Equal(ex.Type == "ris_rrc_list", isRrcList)
+
+			h := &recordingHandler{}
+			assert.NoError(r.Dispatch(h))
+			expected := ex.Type
+			if ex.Type == "ris_message" {
+				expected = ex.BgpMsgType
+			}
+			assert.Equal([]string{expected}, h.called)
+		})
+	}
+}
+
+func TestAsRrcListPointer(t *testing.T) {
+	assert := assert.New(t)
+	l := RisRrcList{"rrc00", "rrc01"}
+	m := &RisLiveMessage{Type: "ris_rrc_list", Data: &l}
+	got, ok := m.AsRrcList()
+	assert.True(ok)
+	assert.Equal(l, got)
+}
+
+func TestDispatchMismatch(t *testing.T) {
+	m := &RisLiveMessage{Type: "ris_message", BgpMsgType: "UPDATE", Data: RisError{}}
+	assert.Error(t, m.Dispatch(NopHandler{}))
+	_, ok := m.AsUpdate()
+	assert.False(t, ok)
+}
+
+func TestDispatchIgnored(t *testing.T) {
+	assert := assert.New(t)
+	var m RisLiveMessage
+	assert.NoError(json.Unmarshal([]byte(`{"type": "ris_subscribe_ok", "data": {"subscription": {"host": "rrc00", "prefix": "192.0.2.0/24", "moreSpecific": true}, "socketOptions": {"includeRaw": true}}}`), &m))
+	ok, _ := m.Data.(*RisSubscribeOk)
+	if assert.NotNil(ok) {
+		assert.Equal("192.0.2.0/24", ok.Subscription.Prefix)
+		assert.True(ok.SocketOptions.IncludeRaw)
+	}
+	h := &recordingHandler{}
+	assert.NoError(m.Dispatch(h))
+	assert.NoError(NewRisPing().Dispatch(h))
+	assert.Empty(h.called)
+}
@@ -0,0 +1,131 @@
+package rislive
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PathHop is a single element of an AS path. Set is non-nil when the
+// element is an AS_SET, in which case ASN is zero.
+type PathHop struct {
+	ASN uint32
+	Set []uint32
+}
+
+func (h PathHop) IsSet() bool {
+	return h.Set != nil
+}
+
+func (h PathHop) String() string {
+	if !h.IsSet() {
+		return strconv.FormatUint(uint64(h.ASN), 10)
+	}
+	s := make([]string, len(h.Set))
+	for i, asn := range h.Set {
+		s[i] = strconv.FormatUint(uint64(asn), 10)
+	}
+	return "{" + strings.Join(s, ",") + "}"
+}
+
+// ASPath is the typed form of the path of an UPDATE, ordered from the
+// peer towards the origin.
+type ASPath []PathHop
+
+// ParseASPath converts the path elements of an UPDATE, which are either
+// ASNs or arrays of ASNs for AS_SETs.
+func ParseASPath(path []json.RawMessage) (ASPath, error) {
+	if len(path) == 0 {
+		return nil, nil
+	}
+	p := make(ASPath, 0, len(path))
+	for _, raw := range path {
+		var asn uint32
+		if err := json.Unmarshal(raw, &asn); err == nil {
+			p = append(p, PathHop{ASN: asn})
+			continue
+		}
+		set := []uint32{}
+		if err := json.Unmarshal(raw, &set); err != nil {
+			return nil, fmt.Errorf("invalid path element: %s", string(raw))
+		}
+		p = append(p, PathHop{Set: set})
+	}
+	return p, nil
+}
+
+// Origin returns the origin ASN. It fails when the path is empty or ends
+// with an AS_SET.
+func (p ASPath) Origin() (uint32, bool) {
+	if len(p) == 0 || p[len(p)-1].IsSet() {
+		return 0, false
+	}
+	return p[len(p)-1].ASN, true
+}
+
+// Contains reports whether asn appears anywhere in the path, including
+// inside AS_SETs.
+func (p ASPath) Contains(asn uint32) bool {
+	for _, h := range p {
+		if h.ASN == asn && !h.IsSet() {
+			return true
+		}
+		for _, a := range h.Set {
+			if a == asn {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Dedup returns the path with consecutive prepends collapsed.
+func (p ASPath) Dedup() ASPath {
+	d := make(ASPath, 0, len(p))
+	for _, h := range p {
+		if len(d) > 0 && !h.IsSet() && !d[len(d)-1].IsSet() && d[len(d)-1].ASN == h.ASN {
+			continue
+		}
+		d = append(d, h)
+	}
+	return d
+}
+
+func (p ASPath) String() string {
+	s := make([]string, len(p))
+	for i, h := range p {
+		s[i] = h.String()
+	}
+	return strings.Join(s, " ")
+}
+
+func (p ASPath) MarshalJSON() ([]byte, error) {
+	elems := make([]interface{}, len(p))
+	for i, h := range p {
+		if h.IsSet() {
+			elems[i] = h.Set
+		} else {
+			elems[i] = h.ASN
+		}
+	}
+	return json.Marshal(elems)
+}
+
+func (p *ASPath) UnmarshalJSON(buf []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(buf, &raw); err != nil {
+		return err
+	}
+	path, err := ParseASPath(raw)
+	if err != nil {
+		return err
+	}
+	*p = path
+	return nil
+}
+
+// ASPath returns the typed path of the UPDATE.
+func (m *RisMessageUpdate) ASPath() (ASPath, error) {
+	return ParseASPath(m.Path)
+}
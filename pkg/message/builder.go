@@ -0,0 +1,253 @@
+package rislive
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+var builderSeq uint64
+
+// Builder constructs synthetic ris_messages. It is created for one BGP
+// message type and setters that do not apply to that type are ignored.
+//
+//	msg := NewUpdate().Peer("192.0.2.1", 64496).Path(64496, 64511).
+//		Announce("192.0.2.1", "198.51.100.0/24").Withdraw("203.0.113.0/24").MustMessage()
+type Builder struct {
+	msgType   string
+	timestamp float64
+	peer      string
+	peerASN   uint32
+	id        string
+	host      string
+	raw       string
+
+	path          []interface{}
+	communities   [][]uint16
+	origin        string
+	med           *uint32
+	announcements []Announcement
+	withdrawals   []string
+
+	direction string
+	routerID  string
+	holdTime  int
+
+	code    uint8
+	subcode uint8
+	data    string
+
+	state string
+}
+
+func newBuilder(msgType string) *Builder {
+	return &Builder{
+		msgType:   msgType,
+		timestamp: float64(time.Now().UnixNano()/int64(time.Millisecond)) / 1e3,
+		peer:      "192.0.2.1",
+		peerASN:   64496,
+		host:      "rrc00",
+	}
+}
+
+// NewUpdate starts an UPDATE with origin IGP.
+func NewUpdate() *Builder {
+	b := newBuilder("UPDATE")
+	b.origin = "igp"
+	return b
+}
+
+// NewOpen starts a received OPEN with a hold time of 180 seconds.
+func NewOpen() *Builder {
+	b := newBuilder("OPEN")
+	b.direction = "received"
+	b.holdTime = 180
+	b.routerID = "192.0.2.1"
+	return b
+}
+
+func NewKeepalive() *Builder {
+	return newBuilder("KEEPALIVE")
+}
+
+func NewNotification(code, subcode uint8) *Builder {
+	b := newBuilder("NOTIFICATION")
+	b.code, b.subcode = code, subcode
+	b.data = fmt.Sprintf("%02x%02x", code, subcode)
+	return b
+}
+
+// NewPeerState starts a RIS_PEER_STATE, state being "connected" or "down".
+func NewPeerState(state string) *Builder {
+	b := newBuilder("RIS_PEER_STATE")
+	b.state = state
+	return b
+}
+
+func (b *Builder) Host(host string) *Builder {
+	b.host = host
+	return b
+}
+
+func (b *Builder) Peer(addr string, asn uint32) *Builder {
+	b.peer, b.peerASN = addr, asn
+	return b
+}
+
+func (b *Builder) ID(id string) *Builder {
+	b.id = id
+	return b
+}
+
+func (b *Builder) Time(t time.Time) *Builder {
+	b.timestamp = float64(t.UnixNano()/int64(time.Microsecond)) / 1e6
+	return b
+}
+
+func (b *Builder) Timestamp(ts float64) *Builder {
+	b.timestamp = ts
+	return b
+}
+
+// Raw sets the hex encoded raw BGP message.
+func (b *Builder) Raw(raw string) *Builder {
+	b.raw = raw
+	return b
+}
+
+// Path appends ASNs to the AS path.
+func (b *Builder) Path(asns ...uint32) *Builder {
+	for _, asn := range asns {
+		b.path = append(b.path, asn)
+	}
+	return b
+}
+
+// PathSet appends an AS_SET to the AS path.
+func (b *Builder) PathSet(asns ...uint32) *Builder {
+	b.path = append(b.path, append([]uint32{}, asns...))
+	return b
+}
+
+func (b *Builder) Community(asn, value uint16) *Builder {
+	b.communities = append(b.communities, []uint16{asn, value})
+	return b
+}
+
+func (b *Builder) Origin(origin string) *Builder {
+	b.origin = origin
+	return b
+}
+
+func (b *Builder) MED(med uint32) *Builder {
+	b.med = &med
+	return b
+}
+
+func (b *Builder) Announce(nextHop string, prefixes ...string) *Builder {
+	b.announcements = append(b.announcements, Announcement{NextHop: nextHop, Prefixes: prefixes})
+	return b
+}
+
+func (b *Builder) Withdraw(prefixes ...string) *Builder {
+	b.withdrawals = append(b.withdrawals, prefixes...)
+	return b
+}
+
+func (b *Builder) Direction(direction string) *Builder {
+	b.direction = direction
+	return b
+}
+
+func (b *Builder) RouterID(id string) *Builder {
+	b.routerID = id
+	return b
+}
+
+func (b *Builder) HoldTime(seconds int) *Builder {
+	b.holdTime = seconds
+	return b
+}
+
+// NotificationData sets the hex encoded notification data, which in RIS
+// Live is the whole message body, starting with the code and subcode.
+func (b *Builder) NotificationData(data string) *Builder {
+	b.data = data
+	return b
+}
+
+// MustJSON returns the message in the RIS Live wire format. Unless set, an
+// ID is generated from peer, timestamp and a counter on the first call. It
+// panics if the message cannot be encoded.
+func (b *Builder) MustJSON() []byte {
+	if b.id == "" {
+		b.id = fmt.Sprintf("%s-%s-%d", b.peer, strconv.FormatFloat(b.timestamp, 'f', -1, 64), atomic.AddUint64(&builderSeq, 1))
+	}
+	data := map[string]interface{}{
+		"timestamp": b.timestamp,
+		"peer":      b.peer,
+		"peer_asn":  strconv.FormatUint(uint64(b.peerASN), 10),
+		"id":        b.id,
+		"host":      b.host,
+		"type":      b.msgType,
+	}
+	if b.raw != "" {
+		data["raw"] = b.raw
+	}
+	switch b.msgType {
+	case "UPDATE":
+		if len(b.announcements) > 0 {
+			path := b.path
+			if path == nil {
+				path = []interface{}{}
+			}
+			data["path"] = path
+			data["origin"] = b.origin
+			if len(b.communities) > 0 {
+				data["community"] = b.communities
+			}
+			if b.med != nil {
+				data["med"] = *b.med
+			}
+			data["announcements"] = b.announcements
+		}
+		if len(b.withdrawals) > 0 {
+			data["withdrawals"] = b.withdrawals
+		}
+	case "OPEN":
+		data["direction"] = b.direction
+		data["version"] = 4
+		data["asn"] = b.peerASN
+		data["hold_time"] = b.holdTime
+		data["router_id"] = b.routerID
+		data["capabilities"] = map[string]interface{}{}
+	case "NOTIFICATION":
+		data["notification"] = map[string]interface{}{
+			"code":    b.code,
+			"subcode": b.subcode,
+			"data":    b.data,
+		}
+	case "RIS_PEER_STATE":
+		data["state"] = b.state
+	}
+	buf, err := json.Marshal(map[string]interface{}{
+		"type": "ris_message",
+		"data": data,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return buf
+}
+
+// MustMessage returns the message as decoded from its JSON. It panics if
+// the message cannot be encoded or decoded.
+func (b *Builder) MustMessage() *RisLiveMessage {
+	var m RisLiveMessage
+	if err := json.Unmarshal(b.MustJSON(), &m); err != nil {
+		panic(err)
+	}
+	return &m
+}
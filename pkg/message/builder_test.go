@@ -0,0 +1,75 @@
+package rislive
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateBuilder(t *testing.T) {
+	assert := assert.New(t)
+	b := NewUpdate().
+		Host("rrc13").
+		Peer("195.208.208.147", 28917).
+		Time(time.Date(2019, 7, 11, 5, 17, 13, 680000000, time.UTC)).
+		Path(28917, 3257).PathSet(64500, 64501).
+		Community(28917, 4000).
+		MED(0).
+		Announce("195.208.208.147", "177.23.116.0/24", "177.23.119.0/24").
+		Withdraw("141.136.32.0/20")
+	m := b.MustMessage()
+	assert.Equal("ris_message", m.Type)
+	assert.Equal("UPDATE", m.BgpMsgType)
+	assert.Equal(1562822233.68, m.Timestamp)
+	assert.Equal("28917", m.PeerASN)
+	assert.Equal("rrc13", m.Host)
+
+	u, ok := m.AsUpdate()
+	assert.True(ok)
+	path, err := u.ASPath()
+	assert.NoError(err)
+	assert.Equal("28917 3257 {64500,64501}", path.String())
+	assert.Equal([][]uint16{{28917, 4000}}, u.Communities)
+	assert.Equal("igp", u.Origin)
+	assert.Len(u.RouteEvents(), 3)
+
+	var wire map[string]interface{}
+	assert.NoError(json.Unmarshal(b.MustJSON(), &wire))
+	data := wire["data"].(map[string]interface{})
+	assert.Equal(float64(0), data["med"])
+	assert.Equal(m.ID, data["id"])
+	assert.Contains(m.ID, "195.208.208.147-1562822233.68-")
+
+	w := NewUpdate().Withdraw("10.0.0.0/8").MustMessage()
+	u, ok = w.AsUpdate()
+	assert.True(ok)
+	assert.Nil(u.Path)
+	assert.Equal([]string{"10.0.0.0/8"}, u.Withdrawals)
+}
+
+func TestOtherBuilders(t *testing.T) {
+	assert := assert.New(t)
+
+	o, ok := NewOpen().Peer("2001:db8::1", 196608).RouterID("192.0.2.9").HoldTime(90).MustMessage().AsOpen()
+	assert.True(ok)
+	assert.Equal("192.0.2.9", o.RouterID)
+	assert.Equal(90, o.HoldTime)
+	assert.Equal("received", o.Direction)
+
+	_, ok = NewKeepalive().Raw("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF001304").MustMessage().AsKeepalive()
+	assert.True(ok)
+
+	n, ok := NewNotification(6, 5).MustMessage().AsNotification()
+	assert.True(ok)
+	assert.Equal(uint8(6), n.Notification.Code)
+	assert.Equal("0605", n.Notification.Data)
+
+	m := NewPeerState("down").ID("x").MustMessage()
+	s, ok := m.AsPeerState()
+	assert.True(ok)
+	assert.Equal("down", s.State)
+	assert.Equal("down", m.State)
+	assert.Equal("x", m.ID)
+}
@@ -0,0 +1,211 @@
+package rislive
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Validate checks the path and prefix of the filter.
+func (f *Filter) Validate() error {
+	if f.Path != "" {
+		if _, err := parsePathFilter(f.Path); err != nil {
+			return err
+		}
+	}
+	if f.Prefix != "" {
+		if _, _, err := net.ParseCIDR(f.Prefix); err != nil {
+			return fmt.Errorf("invalid prefix: %s", f.Prefix)
+		}
+	}
+	switch f.Require {
+	case "", "announcements", "withdrawals":
+	default:
+		return fmt.Errorf("invalid require: %s", f.Require)
+	}
+	return nil
+}
+
+type pathFilter struct {
+	asns       []uint32
+	start, end bool
+}
+
+// parsePathFilter parses an AS path pattern such as "64500",
+// "^64500,64510" or "64510,64520$".
+func parsePathFilter(s string) (*pathFilter, error) {
+	p := &pathFilter{}
+	if strings.HasPrefix(s, "^") {
+		p.start, s = true, s[1:]
+	}
+	if strings.HasSuffix(s, "$") {
+		p.end, s = true, s[:len(s)-1]
+	}
+	for _, e := range strings.Split(s, ",") {
+		asn, err := strconv.ParseUint(strings.TrimSpace(e), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path: %s", s)
+		}
+		p.asns = append(p.asns, uint32(asn))
+	}
+	return p, nil
+}
+
+func (p *pathFilter) match(path ASPath) bool {
+	asns := make([]uint32, 0, len(path))
+	for _, h := range path {
+		if h.IsSet() {
+			// An AS_SET never matches a pattern element.
+			asns = append(asns, 0)
+			continue
+		}
+		asns = append(asns, h.ASN)
+	}
+	for i := 0; i+len(p.asns) <= len(asns); i++ {
+		if p.start && i > 0 {
+			break
+		}
+		if p.end && i+len(p.asns) != len(asns) {
+			continue
+		}
+		match := true
+		for j, asn := range p.asns {
+			if asns[i+j] != asn {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Filter) matchPrefix(prefixes []string, filter *net.IPNet) bool {
+	fOnes, _ := filter.Mask.Size()
+	for _, s := range prefixes {
+		_, p, err := net.ParseCIDR(s)
+		if err != nil || len(p.IP) != len(filter.IP) {
+			continue
+		}
+		ones, _ := p.Mask.Size()
+		switch {
+		case ones == fOnes && p.IP.Equal(filter.IP):
+			return true
+		case f.MoreSpecific && ones > fOnes && filter.Contains(p.IP):
+			return true
+		case f.LessSpecific && ones < fOnes && p.Contains(filter.IP):
+			return true
+		}
+	}
+	return false
+}
+
+// MatchPrefix reports whether a single prefix passes the prefix part of the
+// filter. It is true when the filter has no prefix.
+func (f *Filter) MatchPrefix(prefix string) bool {
+	if f.Prefix == "" {
+		return true
+	}
+	_, filter, err := net.ParseCIDR(f.Prefix)
+	if err != nil {
+		return false
+	}
+	return f.matchPrefix([]string{prefix}, filter)
+}
+
+// Match reports whether a message passes the filter, with the semantics of
+// a RIS Live subscription. Messages other than ris_message always match.
+func (f *Filter) Match(m *RisLiveMessage) bool {
+	if m.Type != "ris_message" {
+		return true
+	}
+	if f.Host != "" && f.Host != m.Host {
+		return false
+	}
+	if f.Type != "" && !strings.EqualFold(f.Type, m.BgpMsgType) {
+		return false
+	}
+	if f.Peer != "" && f.Peer != m.Peer {
+		return false
+	}
+	if f.Require == "" && f.Path == "" && f.Prefix == "" {
+		return true
+	}
+	u, ok := m.AsUpdate()
+	if !ok {
+		return false
+	}
+	switch f.Require {
+	case "announcements":
+		if len(u.Announcements) == 0 {
+			return false
+		}
+	case "withdrawals":
+		if len(u.Withdrawals) == 0 {
+			return false
+		}
+	}
+	if f.Path != "" {
+		pf, err := parsePathFilter(f.Path)
+		if err != nil || len(u.Announcements) == 0 {
+			return false
+		}
+		path, err := u.ASPath()
+		if err != nil || !pf.match(path) {
+			return false
+		}
+	}
+	if f.Prefix != "" {
+		_, filter, err := net.ParseCIDR(f.Prefix)
+		if err != nil {
+			return false
+		}
+		matched := f.matchPrefix(u.Withdrawals, filter)
+		for _, a := range u.Announcements {
+			matched = matched || f.matchPrefix(a.Prefixes, filter)
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// FilterFromValues builds a filter from query parameters named after the
+// JSON fields of Filter, as accepted by the RIS Live /v1/stream endpoint.
+func FilterFromValues(v url.Values) (*Filter, error) {
+	f := NewFilter()
+	f.Host = v.Get("host")
+	f.Type = v.Get("type")
+	f.Require = v.Get("require")
+	f.Peer = v.Get("peer")
+	f.Path = v.Get("path")
+	f.Prefix = v.Get("prefix")
+	for name, dst := range map[string]*bool{
+		"moreSpecific": &f.MoreSpecific,
+		"lessSpecific": &f.LessSpecific,
+	} {
+		if s := v.Get(name); s != "" {
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %s", name, s)
+			}
+			*dst = b
+		}
+	}
+	if s := v.Get("includeRaw"); s != "" {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid includeRaw: %s", s)
+		}
+		f.SetSocketOptions(b)
+	}
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
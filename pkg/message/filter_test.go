@@ -0,0 +1,114 @@
+package rislive
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterMatch(t *testing.T) {
+	var update, keepalive RisLiveMessage
+	assert.NoError(t, json.Unmarshal([]byte(examples[1].ReceivedMsg), &update))
+	assert.NoError(t, json.Unmarshal([]byte(examples[3].ReceivedMsg), &keepalive))
+
+	tests := []struct {
+		Description string
+		Filter      Filter
+		Update      bool
+		Keepalive   bool
+	}{
+		{"empty", Filter{}, true, true},
+		{"host", Filter{Host: "rrc13"}, true, false},
+		{"type", Filter{Type: "UPDATE"}, true, false},
+		{"type lowercase", Filter{Type: "keepalive"}, false, true},
+		{"peer", Filter{Peer: "195.66.224.31"}, false, true},
+		{"require announcements", Filter{Require: "announcements"}, true, false},
+		{"require withdrawals", Filter{Require: "withdrawals"}, true, false},
+		{"path asn", Filter{Path: "1299"}, true, false},
+		{"path sequence", Filter{Path: "3257,1299"}, true, false},
+		{"path wrong order", Filter{Path: "1299,3257"}, false, false},
+		{"path start", Filter{Path: "^28917,3257"}, true, false},
+		{"path start mismatch", Filter{Path: "^3257"}, false, false},
+		{"path end", Filter{Path: "262893$"}, true, false},
+		{"path end mismatch", Filter{Path: "267613$"}, false, false},
+		{"prefix exact", Filter{Prefix: "177.23.116.0/24"}, true, false},
+		{"prefix withdrawal", Filter{Prefix: "141.136.32.0/20"}, true, false},
+		{"prefix covering only", Filter{Prefix: "177.23.0.0/16"}, false, false},
+		{"prefix more specific", Filter{Prefix: "177.23.0.0/16", MoreSpecific: true}, true, false},
+		{"prefix less specific", Filter{Prefix: "177.23.116.128/25", LessSpecific: true}, true, false},
+		{"prefix other family", Filter{Prefix: "2001:db8::/32", MoreSpecific: true}, false, false},
+		{"combined mismatch", Filter{Host: "rrc13", Peer: "192.0.2.1"}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Description, func(t *testing.T) {
+			assert.Equal(t, tt.Update, tt.Filter.Match(&update))
+			assert.Equal(t, tt.Keepalive, tt.Filter.Match(&keepalive))
+		})
+	}
+	assert.True(t, (&Filter{Host: "rrc99"}).Match(NewRisPing()))
+}
+
+func TestFilterValidate(t *testing.T) {
+	assert := assert.New(t)
+	assert.NoError((&Filter{Path: "^1,2$", Prefix: "10.0.0.0/8", Require: "withdrawals"}).Validate())
+	assert.Error((&Filter{Path: "1,x"}).Validate())
+	assert.Error((&Filter{Prefix: "10.0.0.0"}).Validate())
+	assert.Error((&Filter{Require: "path"}).Validate())
+}
+
+func TestFilterMatchPrefix(t *testing.T) {
+	assert := assert.New(t)
+	assert.True((&Filter{}).MatchPrefix("10.0.0.0/8"))
+	f := &Filter{Prefix: "10.0.0.0/8", MoreSpecific: true}
+	assert.True(f.MatchPrefix("10.0.0.0/8"))
+	assert.True(f.MatchPrefix("10.1.0.0/16"))
+	assert.False(f.MatchPrefix("10.0.0.0/7"))
+	assert.False(f.MatchPrefix("2001:db8::/32"))
+}
+
+func TestFilterFromValues(t *testing.T) {
+	assert := assert.New(t)
+	v, _ := url.ParseQuery("host=rrc00&type=UPDATE&require=announcements&peer=192.0.2.1&path=64500&prefix=10.0.0.0/8&moreSpecific=true&includeRaw=1")
+	f, err := FilterFromValues(v)
+	assert.NoError(err)
+	assert.Equal(&Filter{
+		Host: "rrc00", Type: "UPDATE", Require: "announcements", Peer: "192.0.2.1", Path: "64500",
+		Prefix: "10.0.0.0/8", MoreSpecific: true, SocketOptions: &RisSocketOptions{IncludeRaw: true},
+	}, f)
+
+	// As in RIS Live, more specifics match unless turned off.
+	v, _ = url.ParseQuery("prefix=10.0.0.0/8")
+	f, err = FilterFromValues(v)
+	assert.NoError(err)
+	assert.True(f.MoreSpecific)
+	v, _ = url.ParseQuery("prefix=10.0.0.0/8&moreSpecific=false")
+	f, err = FilterFromValues(v)
+	assert.NoError(err)
+	assert.False(f.MoreSpecific)
+
+	v, _ = url.ParseQuery("lessSpecific=maybe")
+	_, err = FilterFromValues(v)
+	assert.Error(err)
+	v, _ = url.ParseQuery("prefix=bad")
+	_, err = FilterFromValues(v)
+	assert.Error(err)
+}
+
+func TestUnmarshalSubscribe(t *testing.T) {
+	assert := assert.New(t)
+	var m RisLiveMessage
+	assert.NoError(json.Unmarshal([]byte(`{"type": "ris_subscribe", "data": {"host": "rrc00", "moreSpecific": true, "socketOptions": {"includeRaw": true}}}`), &m))
+	assert.Equal(&Filter{Host: "rrc00", MoreSpecific: true, SocketOptions: &RisSocketOptions{IncludeRaw: true}}, m.Data)
+	assert.NoError(json.Unmarshal([]byte(`{"type": "ris_unsubscribe"}`), &m))
+	assert.Equal(NewFilter(), m.Data)
+
+	assert.NoError(json.Unmarshal([]byte(`{"type": "ris_subscribe", "data": {"prefix": "10.0.0.0/8"}}`), &m))
+	assert.Equal(&Filter{Prefix: "10.0.0.0/8", MoreSpecific: true}, m.Data)
+	// A false moreSpecific is sent rather than left to the default.
+	buf, err := json.Marshal(NewRisSubscribe(&Filter{Prefix: "10.0.0.0/8"}))
+	assert.NoError(err)
+	assert.NoError(json.Unmarshal(buf, &m))
+	assert.Equal(&Filter{Prefix: "10.0.0.0/8"}, m.Data)
+}
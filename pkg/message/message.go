@@ -36,10 +36,14 @@ func (m *RisLiveMessage) UnmarshalJSON(buf []byte) error {
 		return err
 	}
 	switch m.Type {
-	case "ris_subscribe":
-		// To Be Implemented
-	case "ris_unsubscribe":
-		// To Be Implemented
+	case "ris_subscribe", "ris_unsubscribe":
+		f := NewFilter()
+		if len(a.Data) > 0 && string(a.Data) != "null" {
+			if err := json.Unmarshal(a.Data, f); err != nil {
+				return err
+			}
+		}
+		m.Data = f
 	case "request_rrc_list":
 		m.Data = nil
 	case "ping":
@@ -101,6 +105,14 @@ func (m *RisLiveMessage) UnmarshalJSON(buf []byte) error {
 			return err
 		}
 		m.Data = rrl
+	case "ris_subscribe_ok":
+		var ok RisSubscribeOk
+		if len(a.Data) > 0 && string(a.Data) != "null" {
+			if err := json.Unmarshal(a.Data, &ok); err != nil {
+				return err
+			}
+		}
+		m.Data = &ok
 	case "pong":
 		m.Data = nil
 	default:
@@ -110,6 +122,38 @@ func (m *RisLiveMessage) UnmarshalJSON(buf []byte) error {
 	return nil
 }
 
+// MarshalJSON encodes the message in the RIS Live wire format. For a
+// ris_message, Data is authoritative when set; otherwise the data object is
+// built from the common fields of the message.
+func (m RisLiveMessage) MarshalJSON() ([]byte, error) {
+	type Alias RisLiveMessage
+	if m.Type != "ris_message" || m.Data != nil {
+		return json.Marshal(Alias(m))
+	}
+	data := struct {
+		RisMessageCommon
+		State string `json:"state,omitempty"`
+	}{
+		RisMessageCommon: RisMessageCommon{
+			Type:      m.BgpMsgType,
+			Timestamp: m.Timestamp,
+			Peer:      m.Peer,
+			PeerASN:   m.PeerASN,
+			ID:        m.ID,
+			Host:      m.Host,
+			Raw:       m.Raw,
+		},
+		State: m.State,
+	}
+	return json.Marshal(struct {
+		Type string      `json:"type"`
+		Data interface{} `json:"data"`
+	}{
+		Type: m.Type,
+		Data: data,
+	})
+}
+
 type Filter struct {
 	Host          string            `json:"host,omitempty"`
 	Type          string            `json:"type,omitempty"`
@@ -117,7 +161,7 @@ type Filter struct {
 	Peer          string            `json:"peer,omitempty"`
 	Path          string            `json:"path,omitempty"`
 	Prefix        string            `json:"prefix,omitempty"`
-	MoreSpecific  bool              `json:"moreSpecific,omitempty"`
+	MoreSpecific  bool              `json:"moreSpecific"`
 	LessSpecific  bool              `json:"lessSpecific,omitempty"`
 	SocketOptions *RisSocketOptions `json:"socketOptions,omitempty"`
 }
@@ -126,8 +170,11 @@ type RisSocketOptions struct {
 	IncludeRaw bool `json:"includeRaw,omitempty"`
 }
 
+// NewFilter returns a filter with the RIS Live defaults, which match more
+// specifics of Prefix. A subscription without moreSpecific decodes to the
+// same default.
 func NewFilter() *Filter {
-	return &Filter{}
+	return &Filter{MoreSpecific: true}
 }
 
 func (f *Filter) Dummy() {
@@ -213,7 +260,8 @@ type RisMessageOpen struct {
 	Direction    string                         `json:"direction"`
 	RouterID     string                         `json:"router_id"`
 	Version      int                            `json:"version"`
-	Capabilities map[string]CapabilityInterface `json:"capabilities"`
+	ASN          uint32                         `json:"asn,omitempty"`
+	Capabilities map[string]CapabilityInterface `json:"capabilities,omitempty"`
 	HoldTime     int                            `json:"hold_time"`
 }
 
@@ -256,13 +304,23 @@ type Announcement struct {
 
 type RisError struct {
 	Message     string `json:"message"`
-	BufferSize  uint64 `json:"bufferSize",omitempty"`
-	CommandType string `json:"command_type",omitempty`
+	BufferSize  uint64 `json:"bufferSize,omitempty"`
+	CommandType string `json:"command_type,omitempty"`
 }
 
 func (m RisError) Dummy() {
 }
 
+// RisSubscribeOk acknowledges a ris_subscribe with the subscription in
+// effect.
+type RisSubscribeOk struct {
+	Subscription  *Filter           `json:"subscription,omitempty"`
+	SocketOptions *RisSocketOptions `json:"socketOptions,omitempty"`
+}
+
+func (m RisSubscribeOk) Dummy() {
+}
+
 type RisRrcList []string
 
 func (m RisRrcList) Dummy() {
@@ -283,3 +283,43 @@ func TestUnmarshalJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestMarshalJSON(t *testing.T) {
+	for _, ex := range examples {
+		t.Run(ex.Description, func(t *testing.T) {
+			assert := assert.New(t)
+			var r RisLiveMessage
+			assert.NoError(json.Unmarshal([]byte(ex.ReceivedMsg), &r))
+			buf, err := json.Marshal(&r)
+			assert.NoError(err)
+			assert.JSONEq(ex.ReceivedMsg, string(buf))
+			buf, err = json.Marshal(r)
+			assert.NoError(err)
+			assert.JSONEq(ex.ReceivedMsg, string(buf))
+		})
+	}
+}
+
+func TestMarshalJSONWithoutData(t *testing.T) {
+	m := &RisLiveMessage{
+		Type:       "ris_message",
+		BgpMsgType: "RIS_PEER_STATE",
+		Timestamp:  1562823052.55,
+		Peer:       "2001:43f8:6d0::55",
+		PeerASN:    "327991",
+		ID:         "2001:43f8:6d0::55-1562823052.55-1007659",
+		Host:       "rrc19",
+		State:      "connected",
+	}
+	buf, err := json.Marshal(m)
+	assert.NoError(t, err)
+	assert.JSONEq(t, examples[4].ReceivedMsg, string(buf))
+
+	for _, c := range []*RisLiveMessage{NewRisPing(), NewRisRequestRrcList(), NewRisSubscribe(&Filter{Host: "rrc00"})} {
+		buf, err := json.Marshal(c)
+		assert.NoError(t, err)
+		var back RisLiveMessage
+		assert.NoError(t, json.Unmarshal(buf, &back))
+		assert.Equal(t, c.Type, back.Type)
+	}
+}
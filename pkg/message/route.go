@@ -0,0 +1,105 @@
+package rislive
+
+import (
+	"math"
+	"time"
+)
+
+// FloatToTime converts a RIS Live timestamp (seconds with fractional part)
+// to time.Time, keeping microsecond precision.
+func FloatToTime(f float64) time.Time {
+	sec, frac := math.Modf(f)
+	usec := math.Round(frac * 1e6)
+	return time.Unix(int64(sec), int64(usec)*int64(time.Microsecond)).UTC()
+}
+
+func (m RisMessageCommon) GetTimestamp() time.Time {
+	return FloatToTime(m.Timestamp)
+}
+
+func (m RisMessageCommon) GetType() string {
+	return m.Type
+}
+
+type RouteEventKind int
+
+const (
+	RouteAnnouncement RouteEventKind = iota
+	RouteWithdrawal
+)
+
+func (k RouteEventKind) String() string {
+	switch k {
+	case RouteAnnouncement:
+		return "announcement"
+	case RouteWithdrawal:
+		return "withdrawal"
+	}
+	return "unknown"
+}
+
+// RouteEvent is a single prefix announced or withdrawn by an UPDATE.
+// NextHop, Path and Communities are only set for announcements.
+type RouteEvent struct {
+	Kind        RouteEventKind
+	Prefix      string
+	NextHop     string
+	Path        ASPath
+	Communities [][]uint16
+	Peer        string
+	PeerASN     string
+	Host        string
+	Time        time.Time
+}
+
+// EachRouteEvent calls fn for every withdrawn and announced prefix of the
+// UPDATE, withdrawals first as in the BGP message. Iteration stops when fn
+// returns false. A path that cannot be parsed is left nil.
+func (m *RisMessageUpdate) EachRouteEvent(fn func(RouteEvent) bool) {
+	t := m.GetTimestamp()
+	for _, prefix := range m.Withdrawals {
+		ev := RouteEvent{
+			Kind:    RouteWithdrawal,
+			Prefix:  prefix,
+			Peer:    m.Peer,
+			PeerASN: m.PeerASN,
+			Host:    m.Host,
+			Time:    t,
+		}
+		if !fn(ev) {
+			return
+		}
+	}
+	if len(m.Announcements) == 0 {
+		return
+	}
+	path, _ := m.ASPath()
+	for _, a := range m.Announcements {
+		for _, prefix := range a.Prefixes {
+			ev := RouteEvent{
+				Kind:        RouteAnnouncement,
+				Prefix:      prefix,
+				NextHop:     a.NextHop,
+				Path:        path,
+				Communities: m.Communities,
+				Peer:        m.Peer,
+				PeerASN:     m.PeerASN,
+				Host:        m.Host,
+				Time:        t,
+			}
+			if !fn(ev) {
+				return
+			}
+		}
+	}
+}
+
+// RouteEvents expands the UPDATE into one RouteEvent per prefix.
+func (m *RisMessageUpdate) RouteEvents() []RouteEvent {
+	events := []RouteEvent{}
+	m.EachRouteEvent(func(ev RouteEvent) bool {
+		events = append(events, ev)
+		return true
+	})
+	return events
+}
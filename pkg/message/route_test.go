@@ -0,0 +1,65 @@
+package rislive
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseASPath(t *testing.T) {
+	assert := assert.New(t)
+	var raw []json.RawMessage
+	assert.NoError(json.Unmarshal([]byte(`[3333, 1299, 1299, [65000, 65001]]`), &raw))
+	p, err := ParseASPath(raw)
+	assert.NoError(err)
+	assert.Equal(ASPath{{ASN: 3333}, {ASN: 1299}, {ASN: 1299}, {Set: []uint32{65000, 65001}}}, p)
+	assert.Equal("3333 1299 1299 {65000,65001}", p.String())
+	_, ok := p.Origin()
+	assert.False(ok)
+	assert.True(p.Contains(65001))
+	assert.Len(p.Dedup(), 3)
+
+	buf, err := json.Marshal(p)
+	assert.NoError(err)
+	assert.JSONEq(`[3333, 1299, 1299, [65000, 65001]]`, string(buf))
+
+	_, err = ParseASPath([]json.RawMessage{json.RawMessage(`"x"`)})
+	assert.Error(err)
+}
+
+func TestRouteEvents(t *testing.T) {
+	assert := assert.New(t)
+	var m RisLiveMessage
+	assert.NoError(json.Unmarshal([]byte(examples[1].ReceivedMsg), &m))
+	u, ok := m.AsUpdate()
+	assert.True(ok)
+
+	events := u.RouteEvents()
+	assert.Len(events, 9)
+
+	w := events[0]
+	assert.Equal(RouteWithdrawal, w.Kind)
+	assert.Equal("141.136.32.0/20", w.Prefix)
+	assert.Nil(w.Path)
+
+	a := events[1]
+	assert.Equal(RouteAnnouncement, a.Kind)
+	assert.Equal("177.23.116.0/24", a.Prefix)
+	assert.Equal("195.208.208.147", a.NextHop)
+	assert.Equal("28917", a.PeerASN)
+	assert.Equal("rrc13", a.Host)
+	assert.Equal([][]uint16{{28917, 4000}, {28917, 4003}}, a.Communities)
+	origin, ok := a.Path.Origin()
+	assert.True(ok)
+	assert.Equal(uint32(262893), origin)
+	assert.Equal(time.Date(2019, 7, 11, 5, 17, 13, 680000000, time.UTC), a.Time)
+
+	n := 0
+	u.EachRouteEvent(func(RouteEvent) bool {
+		n++
+		return n < 3
+	})
+	assert.Equal(3, n)
+}
@@ -0,0 +1,361 @@
+// Package moas tracks prefixes announced with multiple origin ASNs (MOAS)
+// across the peers of all collectors, as seen in the RIS Live update
+// stream.
+package moas
+
+import (
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/a16/go-rislive/pkg/rib"
+)
+
+type EventKind int
+
+const (
+	// MOASStarted is emitted when a prefix gets a second origin.
+	MOASStarted EventKind = iota
+	// MOASEnded is emitted when a prefix is back to a single origin, or to
+	// none.
+	MOASEnded
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case MOASStarted:
+		return "started"
+	case MOASEnded:
+		return "ended"
+	}
+	return "unknown"
+}
+
+// Origin is an origin ASN of a prefix and the peers that saw it.
+type Origin struct {
+	ASN       uint32
+	Peers     []rib.PeerKey
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// Conflict is a period during which a prefix had several origins.
+type Conflict struct {
+	Prefix string
+	Start  time.Time
+	// End is zero while the conflict lasts.
+	End time.Time
+	// Origins holds the origins seen during the conflict, ordered by ASN.
+	Origins []Origin
+}
+
+// Duration returns the length of an ended conflict, and zero for an
+// ongoing one.
+func (c Conflict) Duration() time.Duration {
+	if c.End.IsZero() {
+		return 0
+	}
+	return c.End.Sub(c.Start)
+}
+
+type Event struct {
+	Kind EventKind
+	Conflict
+}
+
+// sighting is an origin seen by a peer. It is current while the route of
+// the peer has that origin.
+type sighting struct {
+	first, last time.Time
+	current     bool
+}
+
+type prefixState struct {
+	// origins holds the sightings of each origin by peer.
+	origins map[uint32]map[rib.PeerKey]*sighting
+	// routes holds the current origin of each peer.
+	routes map[rib.PeerKey]uint32
+	start  time.Time
+	// seen accumulates the sightings during a conflict.
+	seen map[uint32]map[rib.PeerKey]*sighting
+}
+
+func (s *prefixState) merge() {
+	for origin, peers := range s.origins {
+		seen := s.seen[origin]
+		if seen == nil {
+			seen = map[rib.PeerKey]*sighting{}
+			s.seen[origin] = seen
+		}
+		for peer, sg := range peers {
+			if prev, ok := seen[peer]; ok && prev.first.Before(sg.first) {
+				seen[peer] = &sighting{first: prev.first, last: sg.last}
+			} else {
+				seen[peer] = &sighting{first: sg.first, last: sg.last}
+			}
+		}
+	}
+}
+
+func snapshot(origins map[uint32]map[rib.PeerKey]*sighting) []Origin {
+	list := make([]Origin, 0, len(origins))
+	for asn, peers := range origins {
+		o := Origin{ASN: asn}
+		for peer, sg := range peers {
+			o.Peers = append(o.Peers, peer)
+			if o.FirstSeen.IsZero() || sg.first.Before(o.FirstSeen) {
+				o.FirstSeen = sg.first
+			}
+			if sg.last.After(o.LastSeen) {
+				o.LastSeen = sg.last
+			}
+		}
+		sort.Slice(o.Peers, func(i, j int) bool {
+			a, b := o.Peers[i], o.Peers[j]
+			if a.Host != b.Host {
+				return a.Host < b.Host
+			}
+			return a.Peer < b.Peer
+		})
+		list = append(list, o)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ASN < list[j].ASN })
+	return list
+}
+
+// Tracker keeps the origins of every prefix seen over a sliding window: an
+// origin counts while a peer has a route with it, and for Window after the
+// route was withdrawn or replaced. The window runs on the timestamps of the
+// messages rather than the wall clock, so a replayed feed yields the same
+// conflicts. All methods may be called from several goroutines.
+type Tracker struct {
+	Window time.Duration
+
+	mu       sync.Mutex
+	prefixes map[string]*prefixState
+	now      time.Time
+	swept    time.Time
+}
+
+func NewTracker(window time.Duration) *Tracker {
+	return &Tracker{
+		Window:   window,
+		prefixes: map[string]*prefixState{},
+	}
+}
+
+// Apply records the origins announced or withdrawn by an UPDATE. When the
+// session of a peer ends with an OPEN or a RIS_PEER_STATE "down", the
+// origins it saw stop counting at once. The conflicts started or ended are
+// returned.
+func (t *Tracker) Apply(m *rislive.RisLiveMessage) ([]Event, error) {
+	var events []Event
+	err := m.Dispatch(rib.PeerEvents{
+		Update: func(u *rislive.RisMessageUpdate) { events = t.ApplyUpdate(u) },
+		Flush:  func(peer rib.PeerKey, now time.Time) { events = t.flushPeer(peer, now) },
+	})
+	return events, err
+}
+
+// advance moves the clock to now and expires old sightings once every
+// tenth of the window.
+func (t *Tracker) advance(events []Event, now time.Time) []Event {
+	if now.After(t.now) {
+		t.now = now
+	}
+	if t.now.Sub(t.swept) >= t.Window/10 {
+		t.swept = t.now
+		events = t.expire(events)
+	}
+	return events
+}
+
+// ApplyUpdate records the origins announced in an UPDATE and the end of
+// the routes it withdraws or replaces. Announcements whose path ends in
+// an AS_SET are treated as withdrawals.
+func (t *Tracker) ApplyUpdate(u *rislive.RisMessageUpdate) []Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	events := t.advance(nil, u.GetTimestamp())
+	u.EachRouteEvent(func(ev rislive.RouteEvent) bool {
+		_, prefix, err := net.ParseCIDR(ev.Prefix)
+		if err != nil {
+			return true
+		}
+		key := prefix.String()
+		peer := rib.PeerKey{Host: ev.Host, Peer: ev.Peer}
+		origin, ok := ev.Path.Origin()
+		if ev.Kind == rislive.RouteWithdrawal || !ok {
+			if s := t.prefixes[key]; s != nil {
+				t.release(s, peer, ev.Time)
+				events = t.check(events, key, s)
+			}
+			return true
+		}
+		s := t.prefixes[key]
+		if s == nil {
+			s = &prefixState{
+				origins: map[uint32]map[rib.PeerKey]*sighting{},
+				routes:  map[rib.PeerKey]uint32{},
+			}
+			t.prefixes[key] = s
+		}
+		if prev, ok := s.routes[peer]; ok && prev != origin {
+			t.release(s, peer, ev.Time)
+		}
+		peers := s.origins[origin]
+		if peers == nil {
+			peers = map[rib.PeerKey]*sighting{}
+			s.origins[origin] = peers
+		}
+		sg := peers[peer]
+		if sg == nil {
+			sg = &sighting{first: ev.Time}
+			peers[peer] = sg
+		}
+		sg.last, sg.current = ev.Time, true
+		s.routes[peer] = origin
+		events = t.check(events, key, s)
+		return true
+	})
+	return events
+}
+
+// release ends the current route of a peer, whose sighting then lasts for
+// the window.
+func (t *Tracker) release(s *prefixState, peer rib.PeerKey, now time.Time) {
+	origin, ok := s.routes[peer]
+	if !ok {
+		return
+	}
+	delete(s.routes, peer)
+	if sg := s.origins[origin][peer]; sg != nil {
+		sg.last, sg.current = now, false
+	}
+}
+
+// check emits an event when the number of origins of a prefix crosses
+// two, and drops the prefix once it has none.
+func (t *Tracker) check(events []Event, key string, s *prefixState) []Event {
+	switch {
+	case len(s.origins) > 1 && s.start.IsZero():
+		s.start = t.now
+		s.seen = map[uint32]map[rib.PeerKey]*sighting{}
+		s.merge()
+		events = append(events, Event{Kind: MOASStarted, Conflict: Conflict{
+			Prefix: key, Start: s.start, Origins: snapshot(s.origins),
+		}})
+	case len(s.origins) > 1:
+		s.merge()
+	case !s.start.IsZero():
+		s.merge()
+		events = append(events, Event{Kind: MOASEnded, Conflict: Conflict{
+			Prefix: key, Start: s.start, End: t.now, Origins: snapshot(s.seen),
+		}})
+		s.start, s.seen = time.Time{}, nil
+	}
+	if len(s.origins) == 0 {
+		delete(t.prefixes, key)
+	}
+	return events
+}
+
+// expire drops the sightings that ended more than a window ago.
+func (t *Tracker) expire(events []Event) []Event {
+	for key, s := range t.prefixes {
+		changed := false
+		for origin, peers := range s.origins {
+			for peer, sg := range peers {
+				if !sg.current && t.now.Sub(sg.last) > t.Window {
+					delete(peers, peer)
+					changed = true
+				}
+			}
+			if len(peers) == 0 {
+				delete(s.origins, origin)
+			}
+		}
+		if changed {
+			events = t.check(events, key, s)
+		}
+	}
+	return events
+}
+
+// Expire moves the clock to now and drops the sightings that ended more
+// than a window before. Conflicts otherwise only end as UPDATEs arrive, so
+// a quiet feed should be expired from a timer.
+func (t *Tracker) Expire(now time.Time) []Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if now.After(t.now) {
+		t.now = now
+	}
+	t.swept = t.now
+	return t.expire(nil)
+}
+
+// FlushPeer drops every sighting of a peer at once, as when its session
+// goes down.
+func (t *Tracker) FlushPeer(peer rib.PeerKey) []Event {
+	return t.flushPeer(peer, time.Time{})
+}
+
+func (t *Tracker) flushPeer(peer rib.PeerKey, now time.Time) []Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	events := t.advance(nil, now)
+	for key, s := range t.prefixes {
+		changed := false
+		delete(s.routes, peer)
+		for origin, peers := range s.origins {
+			if _, ok := peers[peer]; ok {
+				delete(peers, peer)
+				changed = true
+			}
+			if len(peers) == 0 {
+				delete(s.origins, origin)
+			}
+		}
+		if changed {
+			events = t.check(events, key, s)
+		}
+	}
+	return events
+}
+
+// Conflicts returns the ongoing conflicts ordered by start.
+func (t *Tracker) Conflicts() []Conflict {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var list []Conflict
+	for key, s := range t.prefixes {
+		if !s.start.IsZero() {
+			list = append(list, Conflict{Prefix: key, Start: s.start, Origins: snapshot(s.seen)})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].Start.Equal(list[j].Start) {
+			return list[i].Start.Before(list[j].Start)
+		}
+		return list[i].Prefix < list[j].Prefix
+	})
+	return list
+}
+
+// Origins returns the origins currently counted for prefix.
+func (t *Tracker) Origins(prefix string) []Origin {
+	_, p, err := net.ParseCIDR(prefix)
+	if err != nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := t.prefixes[p.String()]
+	if s == nil {
+		return nil
+	}
+	return snapshot(s.origins)
+}
@@ -0,0 +1,103 @@
+package moas
+
+import (
+	"testing"
+	"time"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/a16/go-rislive/pkg/rib"
+	"github.com/a16/go-rislive/pkg/rislivetest"
+	"github.com/stretchr/testify/assert"
+)
+
+// The tests follow the origins of prefix as seen from two vantage points.
+const prefix = "198.51.100.0/24"
+
+var (
+	noon = time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	ams  = rib.PeerKey{Host: "rrc03", Peer: "2001:db8::a"}
+	sao  = rib.PeerKey{Host: "rrc15", Peer: "2001:db8::b"}
+)
+
+func TestTracker(t *testing.T) {
+	assert := assert.New(t)
+	tr := NewTracker(time.Hour)
+
+	assert.Empty(tr.ApplyUpdate(rislivetest.Announce(ams, noon, []uint32{64496, 64500}, prefix)))
+	events := tr.ApplyUpdate(rislivetest.Announce(sao, noon.Add(time.Minute), []uint32{64497, 64501}, prefix))
+	if assert.Len(events, 1) {
+		e := events[0]
+		assert.Equal(MOASStarted, e.Kind)
+		assert.Equal(prefix, e.Prefix)
+		assert.Equal(noon.Add(time.Minute), e.Start)
+		assert.Equal(time.Duration(0), e.Duration())
+		assert.Equal([]Origin{
+			{ASN: 64500, Peers: []rib.PeerKey{ams}, FirstSeen: noon, LastSeen: noon},
+			{ASN: 64501, Peers: []rib.PeerKey{sao}, FirstSeen: noon.Add(time.Minute), LastSeen: noon.Add(time.Minute)},
+		}, e.Origins)
+	}
+	assert.Len(tr.Conflicts(), 1)
+
+	// The withdrawn origin still counts during the window.
+	assert.Empty(tr.ApplyUpdate(rislivetest.Withdraw(sao, noon.Add(2*time.Minute), prefix)))
+	assert.Len(tr.Origins(prefix), 2)
+	assert.Empty(tr.ApplyUpdate(rislivetest.Announce(ams, noon.Add(30*time.Minute), []uint32{64496, 64500}, prefix)))
+
+	events = tr.ApplyUpdate(rislivetest.Announce(ams, noon.Add(63*time.Minute), []uint32{64496, 64500}, prefix))
+	if assert.Len(events, 1) {
+		e := events[0]
+		assert.Equal(MOASEnded, e.Kind)
+		assert.Equal(62*time.Minute, e.Duration())
+		assert.Equal([]Origin{
+			{ASN: 64500, Peers: []rib.PeerKey{ams}, FirstSeen: noon, LastSeen: noon.Add(30 * time.Minute)},
+			{ASN: 64501, Peers: []rib.PeerKey{sao}, FirstSeen: noon.Add(time.Minute), LastSeen: noon.Add(2 * time.Minute)},
+		}, e.Origins)
+	}
+	assert.Empty(tr.Conflicts())
+	if origins := tr.Origins(prefix); assert.Len(origins, 1) {
+		assert.Equal(uint32(64500), origins[0].ASN)
+	}
+}
+
+func TestTrackerReplacedOrigin(t *testing.T) {
+	assert := assert.New(t)
+	tr := NewTracker(time.Hour)
+	assert.Empty(tr.ApplyUpdate(rislivetest.Announce(ams, noon, []uint32{64496, 64500}, prefix)))
+	events := tr.ApplyUpdate(rislivetest.Announce(ams, noon.Add(time.Minute), []uint32{64496, 64501}, prefix))
+	if assert.Len(events, 1) {
+		assert.Equal(MOASStarted, events[0].Kind)
+	}
+	assert.Empty(tr.Expire(noon.Add(time.Hour)))
+	events = tr.Expire(noon.Add(2 * time.Hour))
+	if assert.Len(events, 1) {
+		assert.Equal(MOASEnded, events[0].Kind)
+		assert.Equal(noon.Add(2*time.Hour), events[0].End)
+	}
+
+	// A path ending in an AS_SET ends the route of the peer.
+	assert.Empty(tr.ApplyUpdate(rislivetest.Update(rislive.NewUpdate().Host(ams.Host).Peer(ams.Peer, 64496).Time(noon.Add(3*time.Hour)).
+		Path(64496).PathSet(64500, 64501).Announce(ams.Peer, prefix))))
+	tr.Expire(noon.Add(5 * time.Hour))
+	assert.Empty(tr.Origins(prefix))
+}
+
+func TestTrackerPeerDown(t *testing.T) {
+	assert := assert.New(t)
+	tr := NewTracker(time.Hour)
+	tr.ApplyUpdate(rislivetest.Announce(ams, noon, []uint32{64496, 64500}, prefix))
+	tr.ApplyUpdate(rislivetest.Announce(sao, noon.Add(time.Minute), []uint32{64497, 64501}, prefix))
+
+	events, err := tr.Apply(rislivetest.PeerState(sao, noon.Add(2*time.Minute), "connected"))
+	assert.NoError(err)
+	assert.Empty(events)
+	events, err = tr.Apply(rislivetest.PeerState(sao, noon.Add(5*time.Minute), "down"))
+	assert.NoError(err)
+	if assert.Len(events, 1) {
+		assert.Equal(MOASEnded, events[0].Kind)
+		assert.Equal(4*time.Minute, events[0].Duration())
+	}
+
+	events = tr.FlushPeer(ams)
+	assert.Empty(events)
+	assert.Empty(tr.Origins(prefix))
+}
@@ -0,0 +1,363 @@
+package mrt
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/a16/go-rislive/pkg/bgp"
+	rislive "github.com/a16/go-rislive/pkg/message"
+)
+
+// Further BGP4MP subtypes, written by some collectors for messages sent by
+// the collector itself.
+const (
+	SubtypeBGP4MPMessageLocal    = 6
+	SubtypeBGP4MPMessageAS4Local = 7
+)
+
+// BGP4MP is a BGP4MP message or state change record. Message holds the
+// full BGP message for message records.
+type BGP4MP struct {
+	Subtype   uint16
+	PeerAS    uint32
+	LocalAS   uint32
+	Interface uint16
+	PeerIP    net.IP
+	LocalIP   net.IP
+	OldState  uint16
+	NewState  uint16
+	Message   []byte
+	AS4       bool
+}
+
+func (b *BGP4MP) IsStateChange() bool {
+	return b.Message == nil
+}
+
+// IsLocal reports whether the message was sent by the collector rather
+// than received from the peer. The peer fields still describe the peer.
+func (b *BGP4MP) IsLocal() bool {
+	return b.Subtype == SubtypeBGP4MPMessageLocal || b.Subtype == SubtypeBGP4MPMessageAS4Local
+}
+
+// ParseBGP4MP parses BGP4MP and BGP4MP_ET records.
+func ParseBGP4MP(rec *Record) (*BGP4MP, error) {
+	if rec.Type != TypeBGP4MP && rec.Type != TypeBGP4MPET {
+		return nil, fmt.Errorf("mrt: not a BGP4MP record: type %d", rec.Type)
+	}
+	m := &BGP4MP{Subtype: rec.Subtype}
+	stateChange := false
+	switch rec.Subtype {
+	case SubtypeBGP4MPStateChange:
+		stateChange = true
+	case SubtypeBGP4MPStateChangeAS4:
+		stateChange, m.AS4 = true, true
+	case SubtypeBGP4MPMessage, SubtypeBGP4MPMessageLocal:
+	case SubtypeBGP4MPMessageAS4, SubtypeBGP4MPMessageAS4Local:
+		m.AS4 = true
+	default:
+		return nil, fmt.Errorf("mrt: unsupported BGP4MP subtype %d", rec.Subtype)
+	}
+	b := rec.Data
+	asLen := 2
+	if m.AS4 {
+		asLen = 4
+	}
+	if len(b) < 2*asLen+4 {
+		return nil, errors.New("mrt: truncated BGP4MP record")
+	}
+	if m.AS4 {
+		m.PeerAS = binary.BigEndian.Uint32(b)
+		m.LocalAS = binary.BigEndian.Uint32(b[4:])
+	} else {
+		m.PeerAS = uint32(binary.BigEndian.Uint16(b))
+		m.LocalAS = uint32(binary.BigEndian.Uint16(b[2:]))
+	}
+	b = b[2*asLen:]
+	m.Interface = binary.BigEndian.Uint16(b)
+	ipLen := net.IPv4len
+	switch binary.BigEndian.Uint16(b[2:]) {
+	case bgp.AFIIPv4:
+	case bgp.AFIIPv6:
+		ipLen = net.IPv6len
+	default:
+		return nil, errors.New("mrt: unsupported BGP4MP address family")
+	}
+	b = b[4:]
+	if len(b) < 2*ipLen {
+		return nil, errors.New("mrt: truncated BGP4MP record")
+	}
+	m.PeerIP = net.IP(append([]byte{}, b[:ipLen]...))
+	m.LocalIP = net.IP(append([]byte{}, b[ipLen:2*ipLen]...))
+	b = b[2*ipLen:]
+	if stateChange {
+		if len(b) < 4 {
+			return nil, errors.New("mrt: truncated BGP4MP state change")
+		}
+		m.OldState = binary.BigEndian.Uint16(b)
+		m.NewState = binary.BigEndian.Uint16(b[2:])
+		return m, nil
+	}
+	m.Message = append([]byte{}, b...)
+	return m, nil
+}
+
+var capabilityNames = map[uint8]string{
+	1:   "multiprotocol",
+	2:   "route-refresh",
+	64:  "graceful restart",
+	65:  "asn4",
+	69:  "add-path",
+	70:  "enhanced route refresh",
+	128: "route-refresh",
+}
+
+// ToRisLive converts a BGP4MP record into the RisLiveMessage the RIS Live
+// decoder produces for it, with host as the collector name and id as the
+// message ID. It returns nil without error for records RIS Live does not
+// report, such as state changes other than to Established or Idle and
+// messages sent by the collector other than OPENs.
+func ToRisLive(rec *Record, host, id string) (*rislive.RisLiveMessage, error) {
+	b, err := ParseBGP4MP(rec)
+	if err != nil {
+		return nil, err
+	}
+	return toRisLive(rec, b, host, id)
+}
+
+func recordTimestamp(rec *Record) float64 {
+	ts, _ := strconv.ParseFloat(fmt.Sprintf("%d.%06d", rec.Timestamp.Unix(), rec.Timestamp.Nanosecond()/1000), 64)
+	return ts
+}
+
+func toRisLive(rec *Record, b *BGP4MP, host, id string) (*rislive.RisLiveMessage, error) {
+	ts := recordTimestamp(rec)
+	common := rislive.RisMessageCommon{
+		Timestamp: ts,
+		Peer:      b.PeerIP.String(),
+		PeerASN:   strconv.FormatUint(uint64(b.PeerAS), 10),
+		ID:        id,
+		Host:      host,
+	}
+	m := &rislive.RisLiveMessage{Type: "ris_message"}
+
+	if b.IsStateChange() {
+		var state string
+		switch b.NewState {
+		case StateEstablished:
+			state = "connected"
+		case StateIdle:
+			state = "down"
+		default:
+			return nil, nil
+		}
+		common.Type = "RIS_PEER_STATE"
+		m.Data = &rislive.RisMessageRisPeerState{RisMessageCommon: common, State: state}
+		m.State = state
+		setCommon(m, common)
+		return m, nil
+	}
+
+	hdr, body, err := bgp.DecodeHeader(b.Message)
+	if err != nil {
+		return nil, err
+	}
+	if b.IsLocal() && hdr.Type != bgp.MsgOpen {
+		return nil, nil
+	}
+	common.Raw = strings.ToUpper(hex.EncodeToString(b.Message[:hdr.Length]))
+	switch hdr.Type {
+	case bgp.MsgUpdate:
+		u, err := bgp.DecodeUpdate(body, bgp.DecodeOptions{AS2: !b.AS4})
+		if err != nil {
+			return nil, err
+		}
+		common.Type = "UPDATE"
+		m.Data = convertUpdate(common, u)
+	case bgp.MsgOpen:
+		o, err := bgp.DecodeOpen(body)
+		if err != nil {
+			return nil, err
+		}
+		common.Type = "OPEN"
+		caps := map[string]rislive.CapabilityInterface{}
+		for _, c := range o.Capabilities {
+			name, ok := capabilityNames[c.Code]
+			if !ok {
+				name = "unknown"
+			}
+			caps[strconv.Itoa(int(c.Code))] = map[string]interface{}{
+				"name": name,
+				"raw":  strings.ToUpper(hex.EncodeToString(c.Value)),
+			}
+		}
+		direction := "received"
+		if b.IsLocal() {
+			direction = "sent"
+		}
+		m.Data = &rislive.RisMessageOpen{
+			RisMessageCommon: common,
+			Direction:        direction,
+			RouterID:         o.BGPID.String(),
+			Version:          int(o.Version),
+			ASN:              o.AS(),
+			Capabilities:     caps,
+			HoldTime:         int(o.HoldTime),
+		}
+	case bgp.MsgNotification:
+		n, err := bgp.DecodeNotification(body)
+		if err != nil {
+			return nil, err
+		}
+		common.Type = "NOTIFICATION"
+		data := &rislive.RisMessageNotification{RisMessageCommon: common}
+		data.Notification.Code = n.Code
+		data.Notification.Subcode = n.Subcode
+		data.Notification.Data = hex.EncodeToString(body)
+		m.Data = data
+	case bgp.MsgKeepalive:
+		common.Type = "KEEPALIVE"
+		m.Data = &rislive.RisMessageKeepalive{RisMessageCommon: common}
+	default:
+		return nil, nil
+	}
+	setCommon(m, common)
+	return m, nil
+}
+
+func setCommon(m *rislive.RisLiveMessage, c rislive.RisMessageCommon) {
+	m.BgpMsgType = c.Type
+	m.Timestamp = c.Timestamp
+	m.Peer = c.Peer
+	m.PeerASN = c.PeerASN
+	m.ID = c.ID
+	m.Host = c.Host
+	m.Raw = c.Raw
+}
+
+func convertUpdate(common rislive.RisMessageCommon, u *bgp.Update) *rislive.RisMessageUpdate {
+	a := u.Attributes
+	r := &rislive.RisMessageUpdate{
+		RisMessageCommon: common,
+		Communities:      a.Communities,
+		Origin:           a.Origin,
+		MED:              a.MED,
+	}
+	for _, h := range a.Path {
+		var raw []byte
+		if h.IsSet() {
+			raw, _ = json.Marshal(h.Set)
+		} else {
+			raw = []byte(strconv.FormatUint(uint64(h.ASN), 10))
+		}
+		r.Path = append(r.Path, json.RawMessage(raw))
+	}
+	for _, p := range u.Withdrawn {
+		r.Withdrawals = append(r.Withdrawals, p.String())
+	}
+	if a.MPUnreach != nil {
+		for _, p := range a.MPUnreach.Withdrawn {
+			r.Withdrawals = append(r.Withdrawals, p.String())
+		}
+	}
+	if len(u.NLRI) > 0 && a.NextHop != nil {
+		r.Announcements = append(r.Announcements, rislive.Announcement{
+			NextHop:  a.NextHop.String(),
+			Prefixes: prefixStrings(u.NLRI),
+		})
+	}
+	if a.MPReach != nil && len(a.MPReach.NLRI) > 0 {
+		hops := make([]string, len(a.MPReach.NextHop))
+		for i, ip := range a.MPReach.NextHop {
+			hops[i] = ip.String()
+		}
+		r.Announcements = append(r.Announcements, rislive.Announcement{
+			NextHop:  strings.Join(hops, ","),
+			Prefixes: prefixStrings(a.MPReach.NLRI),
+		})
+	}
+	return r
+}
+
+func prefixStrings(prefixes []*net.IPNet) []string {
+	s := make([]string, len(prefixes))
+	for i, p := range prefixes {
+		s[i] = p.String()
+	}
+	return s
+}
+
+// UpdatesReader reads RIS updates files and returns their records as
+// RisLiveMessages.
+type UpdatesReader struct {
+	r *Reader
+	// Host is reported as the collector of every message.
+	Host string
+	// OnError, if set, is called with the error of each record that is
+	// skipped because it cannot be decoded.
+	OnError func(error)
+	n       uint64
+}
+
+func NewUpdatesReader(r *Reader, host string) *UpdatesReader {
+	return &UpdatesReader{r: r, Host: host}
+}
+
+// OpenUpdates opens a possibly compressed updates file, deriving Host from
+// the file path.
+func OpenUpdates(path string) (*UpdatesReader, error) {
+	r, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewUpdatesReader(r, HostFromPath(path)), nil
+}
+
+// Next returns the next message, skipping records without a RIS Live
+// equivalent and records that cannot be decoded. It returns io.EOF at the
+// end of the file, and an error only if the file itself is unreadable.
+func (u *UpdatesReader) Next() (*rislive.RisLiveMessage, error) {
+	for {
+		rec, err := u.r.Next()
+		if err != nil {
+			return nil, err
+		}
+		if rec.Type != TypeBGP4MP && rec.Type != TypeBGP4MPET {
+			continue
+		}
+		u.n++
+		b, err := ParseBGP4MP(rec)
+		if err != nil {
+			u.skip(err)
+			continue
+		}
+		// IDs follow the RIS Live form of peer, timestamp and a counter.
+		ts := strconv.FormatFloat(recordTimestamp(rec), 'f', -1, 64)
+		id := fmt.Sprintf("%s-%s-%d", b.PeerIP, ts, u.n)
+		m, err := toRisLive(rec, b, u.Host, id)
+		if err != nil {
+			u.skip(err)
+			continue
+		}
+		if m != nil {
+			return m, nil
+		}
+	}
+}
+
+func (u *UpdatesReader) skip(err error) {
+	if u.OnError != nil {
+		u.OnError(fmt.Errorf("mrt: record %d skipped: %v", u.n, err))
+	}
+}
+
+// Close closes the file opened by OpenUpdates.
+func (u *UpdatesReader) Close() error {
+	return u.r.Close()
+}
@@ -0,0 +1,155 @@
+package mrt
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/a16/go-rislive/pkg/bgp"
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/stretchr/testify/assert"
+)
+
+const rawUpdate = `{"type": "ris_message", "data": {"timestamp": 1562822233.68, "peer": "195.208.208.147", "peer_asn": "28917", "id": "x", "host": "rrc13", "type": "UPDATE",
+	"raw": "FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF006A020004148D8820002F400101004002160205000070F500000CB9000005130004155D000402ED400304C3D0D093C0080870F50FA070F50FA318B1177418B1177718B1177018A879C518B1260D18A879C718B1260A18B1260F",
+	"path": [28917, 3257, 1299, 267613, 262893], "community": [[28917, 4000], [28917, 4003]], "origin": "igp",
+	"announcements": [{"next_hop": "195.208.208.147", "prefixes": ["177.23.116.0/24", "177.23.119.0/24", "177.23.112.0/24", "168.121.197.0/24", "177.38.13.0/24", "168.121.199.0/24", "177.38.10.0/24", "177.38.15.0/24"]}],
+	"withdrawals": ["141.136.32.0/20"]}}`
+
+func TestUpdatesReaderRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+	inputs := []string{rawKeepalive, rawUpdate, plainUpdate, peerDown, peerUp}
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.ExtendedTimestamp = true
+	for _, s := range inputs {
+		assert.NoError(w.WriteMessage(decode(t, s)))
+	}
+
+	r := NewUpdatesReader(NewReader(&buf), "rrc13")
+	for _, s := range inputs {
+		expected := decode(t, s)
+		m, err := r.Next()
+		assert.NoError(err)
+		assert.Equal("ris_message", m.Type)
+		assert.Equal(expected.BgpMsgType, m.BgpMsgType)
+		assert.Equal(expected.Timestamp, m.Timestamp)
+		assert.Equal(expected.Peer, m.Peer)
+		assert.Equal(expected.PeerASN, m.PeerASN)
+		assert.Equal(expected.State, m.State)
+		assert.Equal("rrc13", m.Host)
+		assert.Contains(m.ID, expected.Peer+"-")
+		if expected.Raw != "" {
+			assert.Equal(expected.Raw, m.Raw)
+		}
+
+		if eu, ok := expected.AsUpdate(); ok {
+			u, ok := m.AsUpdate()
+			assert.True(ok)
+			ep, _ := eu.ASPath()
+			p, _ := u.ASPath()
+			assert.Equal(ep, p)
+			assert.Equal(eu.Announcements, u.Announcements)
+			assert.Equal(eu.Withdrawals, u.Withdrawals)
+			assert.Equal(eu.Communities, u.Communities)
+			assert.Equal(eu.Origin, u.Origin)
+		}
+	}
+	_, err := r.Next()
+	assert.Equal(io.EOF, err)
+}
+
+func TestUpdatesReaderSkipsCorrupt(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	assert.NoError(w.WriteMessage(decode(t, rawKeepalive)))
+	ts := time.Unix(1562822767, 0)
+	// A truncated BGP4MP header, then a valid header with a bad BGP marker.
+	assert.NoError(w.WriteRecord(&Record{Header: Header{Timestamp: ts, Type: TypeBGP4MP, Subtype: SubtypeBGP4MPMessageAS4}, Data: []byte{0, 0}}))
+	hdr, err := bgp4mpHeader(decode(t, rawKeepalive).Data.(*rislive.RisMessageKeepalive).RisMessageCommon)
+	assert.NoError(err)
+	assert.NoError(w.WriteRecord(&Record{Header: Header{Timestamp: ts, Type: TypeBGP4MP, Subtype: SubtypeBGP4MPMessageAS4}, Data: append(hdr, make([]byte, 19)...)}))
+	assert.NoError(w.WriteMessage(decode(t, peerUp)))
+
+	r := NewUpdatesReader(NewReader(&buf), "rrc01")
+	var errs []error
+	r.OnError = func(err error) { errs = append(errs, err) }
+	m, err := r.Next()
+	assert.NoError(err)
+	assert.Equal("KEEPALIVE", m.BgpMsgType)
+	m, err = r.Next()
+	assert.NoError(err)
+	assert.Equal("connected", m.State)
+	_, err = r.Next()
+	assert.Equal(io.EOF, err)
+	if assert.Len(errs, 2) {
+		assert.EqualError(errs[0], "mrt: record 2 skipped: mrt: truncated BGP4MP record")
+		assert.Contains(errs[1].Error(), "mrt: record 3 skipped: ")
+	}
+}
+
+func TestToRisLiveNotification(t *testing.T) {
+	assert := assert.New(t)
+	n := decode(t, `{"type": "ris_message", "data": {"timestamp": 1562822895.4, "peer": "2606:6d00:eb0::254", "peer_asn": "1403", "id": "x",
+		"host": "rrc00", "type": "NOTIFICATION", "notification": {"code": 6, "subcode": 5, "data": "0605"}}}`)
+	var buf bytes.Buffer
+	assert.NoError(NewWriter(&buf).WriteMessage(n))
+	rec, err := NewReader(&buf).Next()
+	assert.NoError(err)
+	m, err := ToRisLive(rec, "rrc00", "id")
+	assert.NoError(err)
+	got, ok := m.AsNotification()
+	assert.True(ok)
+	assert.Equal(uint8(6), got.Notification.Code)
+	assert.Equal("0605", got.Notification.Data)
+	assert.Equal("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF0015030605", m.Raw)
+}
+
+func TestToRisLiveOpen(t *testing.T) {
+	assert := assert.New(t)
+	o := decode(t, `{"type": "ris_message", "data": {"timestamp": 1562841440.23, "peer": "2001:7f8:4::1ad2:1", "peer_asn": "196608", "id": "x",
+		"host": "rrc01", "type": "OPEN", "direction": "received", "version": 4, "hold_time": 180, "router_id": "195.14.152.101"}}`)
+	var buf bytes.Buffer
+	assert.NoError(NewWriter(&buf).WriteMessage(o))
+	rec, err := NewReader(&buf).Next()
+	assert.NoError(err)
+	m, err := ToRisLive(rec, "rrc01", "id")
+	assert.NoError(err)
+	got, ok := m.AsOpen()
+	assert.True(ok)
+	assert.Equal("196608", got.PeerASN)
+	assert.Equal("195.14.152.101", got.RouterID)
+	assert.Equal(180, got.HoldTime)
+	assert.Equal(map[string]interface{}{"name": "asn4", "raw": "00030000"}, got.Capabilities["65"])
+	assert.Equal(1562841440.0, m.Timestamp)
+	assert.Equal("received", got.Direction)
+}
+
+func TestToRisLiveSent(t *testing.T) {
+	assert := assert.New(t)
+	o := decode(t, `{"type": "ris_message", "data": {"timestamp": 1562841440.23, "peer": "2001:7f8:4::1ad2:1", "peer_asn": "196608", "id": "x",
+		"host": "rrc01", "type": "OPEN", "direction": "sent", "version": 4, "asn": 12654, "hold_time": 180, "router_id": "193.0.4.28"}}`)
+	var buf bytes.Buffer
+	assert.NoError(NewWriter(&buf).WriteMessage(o))
+	rec, err := NewReader(&buf).Next()
+	assert.NoError(err)
+	assert.Equal(uint16(SubtypeBGP4MPMessageAS4Local), rec.Subtype)
+	m, err := ToRisLive(rec, "rrc01", "id")
+	assert.NoError(err)
+	got, ok := m.AsOpen()
+	assert.True(ok)
+	assert.Equal("sent", got.Direction)
+	assert.Equal(uint32(12654), got.ASN)
+	assert.Equal("196608", got.PeerASN)
+	assert.Equal("2001:7f8:4::1ad2:1", got.Peer)
+
+	// Other messages sent by the collector are not reported.
+	hdr, err := bgp4mpHeader(got.RisMessageCommon)
+	assert.NoError(err)
+	rec.Data = append(hdr, bgp.EncodeKeepalive()...)
+	m, err = ToRisLive(rec, "rrc01", "id")
+	assert.NoError(err)
+	assert.Nil(m)
+}
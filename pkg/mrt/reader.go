@@ -0,0 +1,148 @@
+// Package mrt reads and writes MRT routing information export files
+// (RFC 6396), as published by the RIPE NCC RIS collectors.
+package mrt
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"time"
+)
+
+// MRT types.
+const (
+	TypeTableDumpV2 = 13
+	TypeBGP4MP      = 16
+	TypeBGP4MPET    = 17
+)
+
+// TABLE_DUMP_V2 subtypes.
+const (
+	SubtypePeerIndexTable   = 1
+	SubtypeRIBIPv4Unicast   = 2
+	SubtypeRIBIPv4Multicast = 3
+	SubtypeRIBIPv6Unicast   = 4
+	SubtypeRIBIPv6Multicast = 5
+)
+
+const headerLen = 12
+
+// maxRecordLen bounds the record length accepted by Reader.
+const maxRecordLen = 1 << 24
+
+type Header struct {
+	Timestamp time.Time
+	Type      uint16
+	Subtype   uint16
+	Length    uint32
+}
+
+// Record is a raw MRT record. For extended timestamp types the
+// microseconds are folded into Timestamp and removed from Data.
+type Record struct {
+	Header
+	Data []byte
+}
+
+type Reader struct {
+	r      *bufio.Reader
+	closer io.Closer
+	hdr    [headerLen]byte
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Open opens an MRT file, transparently decompressing gzip and bzip2.
+func Open(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := newDecompressingReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	r.closer = f
+	return r, nil
+}
+
+func newDecompressingReader(f io.Reader) (*Reader, error) {
+	br := bufio.NewReader(f)
+	magic, err := br.Peek(3)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return NewReader(gz), nil
+	case bytes.HasPrefix(magic, []byte("BZh")):
+		return NewReader(bzip2.NewReader(br)), nil
+	}
+	return &Reader{r: br}, nil
+}
+
+// Close closes the file opened by Open.
+func (r *Reader) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
+
+// Next returns the next record, or io.EOF at the end of the input.
+func (r *Reader) Next() (*Record, error) {
+	if _, err := io.ReadFull(r.r, r.hdr[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, errors.New("mrt: truncated header")
+		}
+		return nil, err
+	}
+	rec := &Record{Header: Header{
+		Timestamp: time.Unix(int64(binary.BigEndian.Uint32(r.hdr[0:4])), 0).UTC(),
+		Type:      binary.BigEndian.Uint16(r.hdr[4:6]),
+		Subtype:   binary.BigEndian.Uint16(r.hdr[6:8]),
+		Length:    binary.BigEndian.Uint32(r.hdr[8:12]),
+	}}
+	if rec.Length > maxRecordLen {
+		return nil, fmt.Errorf("mrt: record length %d too large", rec.Length)
+	}
+	rec.Data = make([]byte, rec.Length)
+	if _, err := io.ReadFull(r.r, rec.Data); err != nil {
+		return nil, fmt.Errorf("mrt: truncated record: %v", err)
+	}
+	if rec.Type == TypeBGP4MPET {
+		if len(rec.Data) < 4 {
+			return nil, errors.New("mrt: truncated extended timestamp")
+		}
+		usec := binary.BigEndian.Uint32(rec.Data)
+		rec.Timestamp = rec.Timestamp.Add(time.Duration(usec) * time.Microsecond)
+		rec.Data = rec.Data[4:]
+	}
+	return rec, nil
+}
+
+var hostPattern = regexp.MustCompile(`rrc[0-9]+`)
+
+// HostFromPath returns the RIS collector name (e.g. "rrc00") found in an
+// MRT file path, or an empty string.
+func HostFromPath(path string) string {
+	m := hostPattern.FindAllString(path, -1)
+	if len(m) == 0 {
+		return ""
+	}
+	return m[len(m)-1]
+}
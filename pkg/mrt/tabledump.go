@@ -0,0 +1,136 @@
+package mrt
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/a16/go-rislive/pkg/bgp"
+)
+
+type PeerEntry struct {
+	BGPID net.IP
+	IP    net.IP
+	AS    uint32
+}
+
+// PeerIndexTable is the PEER_INDEX_TABLE record of a TABLE_DUMP_V2 file.
+type PeerIndexTable struct {
+	CollectorID net.IP
+	ViewName    string
+	Peers       []PeerEntry
+}
+
+type RIBEntry struct {
+	PeerIndex      uint16
+	OriginatedTime time.Time
+	Attributes     *bgp.Attributes
+}
+
+// RIB is a RIB_IPV4_UNICAST or RIB_IPV6_UNICAST record.
+type RIB struct {
+	Sequence uint32
+	Prefix   *net.IPNet
+	Entries  []RIBEntry
+}
+
+var errTruncated = errors.New("mrt: truncated TABLE_DUMP_V2 record")
+
+func ParsePeerIndexTable(b []byte) (*PeerIndexTable, error) {
+	if len(b) < 6 {
+		return nil, errTruncated
+	}
+	t := &PeerIndexTable{CollectorID: net.IP(append([]byte{}, b[:4]...))}
+	nameLen := int(binary.BigEndian.Uint16(b[4:]))
+	b = b[6:]
+	if len(b) < nameLen+2 {
+		return nil, errTruncated
+	}
+	t.ViewName = string(b[:nameLen])
+	count := int(binary.BigEndian.Uint16(b[nameLen:]))
+	b = b[nameLen+2:]
+	t.Peers = make([]PeerEntry, 0, count)
+	for i := 0; i < count; i++ {
+		if len(b) < 5 {
+			return nil, errTruncated
+		}
+		typ := b[0]
+		p := PeerEntry{BGPID: net.IP(append([]byte{}, b[1:5]...))}
+		b = b[5:]
+		ipLen, asLen := net.IPv4len, 2
+		if typ&0x01 != 0 {
+			ipLen = net.IPv6len
+		}
+		if typ&0x02 != 0 {
+			asLen = 4
+		}
+		if len(b) < ipLen+asLen {
+			return nil, errTruncated
+		}
+		p.IP = net.IP(append([]byte{}, b[:ipLen]...))
+		if asLen == 4 {
+			p.AS = binary.BigEndian.Uint32(b[ipLen:])
+		} else {
+			p.AS = uint32(binary.BigEndian.Uint16(b[ipLen:]))
+		}
+		b = b[ipLen+asLen:]
+		t.Peers = append(t.Peers, p)
+	}
+	return t, nil
+}
+
+// ParseRIB parses a RIB_IPV4_UNICAST or RIB_IPV6_UNICAST record.
+func ParseRIB(rec *Record) (*RIB, error) {
+	if rec.Type != TypeTableDumpV2 {
+		return nil, fmt.Errorf("mrt: not a TABLE_DUMP_V2 record: type %d", rec.Type)
+	}
+	var addrLen int
+	switch rec.Subtype {
+	case SubtypeRIBIPv4Unicast:
+		addrLen = net.IPv4len
+	case SubtypeRIBIPv6Unicast:
+		addrLen = net.IPv6len
+	default:
+		return nil, fmt.Errorf("mrt: unsupported TABLE_DUMP_V2 subtype %d", rec.Subtype)
+	}
+	b := rec.Data
+	if len(b) < 4 {
+		return nil, errTruncated
+	}
+	r := &RIB{Sequence: binary.BigEndian.Uint32(b)}
+	prefix, n, err := bgp.DecodePrefix(b[4:], addrLen)
+	if err != nil {
+		return nil, err
+	}
+	r.Prefix = prefix
+	b = b[4+n:]
+	if len(b) < 2 {
+		return nil, errTruncated
+	}
+	count := int(binary.BigEndian.Uint16(b))
+	b = b[2:]
+	r.Entries = make([]RIBEntry, 0, count)
+	for i := 0; i < count; i++ {
+		if len(b) < 8 {
+			return nil, errTruncated
+		}
+		e := RIBEntry{
+			PeerIndex:      binary.BigEndian.Uint16(b),
+			OriginatedTime: time.Unix(int64(binary.BigEndian.Uint32(b[2:])), 0).UTC(),
+		}
+		attrLen := int(binary.BigEndian.Uint16(b[6:]))
+		b = b[8:]
+		if len(b) < attrLen {
+			return nil, errTruncated
+		}
+		e.Attributes, err = bgp.DecodeAttributes(b[:attrLen], bgp.DecodeOptions{TableDump: true})
+		if err != nil {
+			return nil, err
+		}
+		b = b[attrLen:]
+		r.Entries = append(r.Entries, e)
+	}
+	return r, nil
+}
@@ -0,0 +1,76 @@
+package mrt
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadTableDump(t *testing.T) {
+	for _, path := range []string{
+		"testdata/rrc00/bview.20190711.0000",
+		"testdata/rrc00/bview.20190711.0000.gz",
+		"testdata/rrc00/bview.20190711.0000.bz2",
+	} {
+		t.Run(path, func(t *testing.T) {
+			assert := assert.New(t)
+			r, err := Open(path)
+			assert.NoError(err)
+			defer r.Close()
+
+			rec, err := r.Next()
+			assert.NoError(err)
+			assert.Equal(uint16(TypeTableDumpV2), rec.Type)
+			assert.Equal(uint16(SubtypePeerIndexTable), rec.Subtype)
+			assert.Equal(time.Date(2019, 7, 11, 0, 0, 0, 0, time.UTC), rec.Timestamp)
+			pit, err := ParsePeerIndexTable(rec.Data)
+			assert.NoError(err)
+			assert.Equal("192.0.2.250", pit.CollectorID.String())
+			assert.Equal("test", pit.ViewName)
+			assert.Equal([]PeerEntry{
+				{BGPID: pit.Peers[0].BGPID, IP: pit.Peers[0].IP, AS: 64500},
+				{BGPID: pit.Peers[1].BGPID, IP: pit.Peers[1].IP, AS: 200000},
+			}, pit.Peers)
+			assert.Equal("2001:db8::1", pit.Peers[1].IP.String())
+
+			rec, err = r.Next()
+			assert.NoError(err)
+			rib, err := ParseRIB(rec)
+			assert.NoError(err)
+			assert.Equal("10.0.0.0/8", rib.Prefix.String())
+			assert.Len(rib.Entries, 2)
+			assert.Equal(rislive.ASPath{{ASN: 64500}, {ASN: 64510}}, rib.Entries[0].Attributes.Path)
+			assert.Equal("192.0.2.1", rib.Entries[0].Attributes.NextHop.String())
+			assert.Equal("incomplete", rib.Entries[1].Attributes.Origin)
+			assert.Equal(uint32(5), rib.Entries[1].Attributes.MED)
+
+			rec, err = r.Next()
+			assert.NoError(err)
+			rib, err = ParseRIB(rec)
+			assert.NoError(err)
+			assert.Equal("2001:db8:100::/40", rib.Prefix.String())
+			assert.Equal("2001:db8::1", rib.Entries[0].Attributes.MPReach.NextHop[0].String())
+
+			_, err = r.Next()
+			assert.Equal(io.EOF, err)
+		})
+	}
+}
+
+func TestParseRIBErrors(t *testing.T) {
+	assert := assert.New(t)
+	_, err := ParseRIB(&Record{Header: Header{Type: TypeBGP4MP}})
+	assert.Error(err)
+	_, err = ParseRIB(&Record{Header: Header{Type: TypeTableDumpV2, Subtype: SubtypeRIBIPv4Unicast}, Data: []byte{0, 0, 0, 1, 8}})
+	assert.Error(err)
+	_, err = ParsePeerIndexTable([]byte{192, 0, 2, 1, 0})
+	assert.Error(err)
+}
+
+func TestHostFromPath(t *testing.T) {
+	assert.Equal(t, "rrc21", HostFromPath("/data/ris/rrc21/2019.07/bview.20190711.0000.gz"))
+	assert.Equal(t, "", HostFromPath("bview.20190711.0000.gz"))
+}
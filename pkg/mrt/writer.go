@@ -0,0 +1,301 @@
+package mrt
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/a16/go-rislive/pkg/bgp"
+	rislive "github.com/a16/go-rislive/pkg/message"
+)
+
+// BGP4MP subtypes.
+const (
+	SubtypeBGP4MPStateChange    = 0
+	SubtypeBGP4MPMessage        = 1
+	SubtypeBGP4MPMessageAS4     = 4
+	SubtypeBGP4MPStateChangeAS4 = 5
+)
+
+// BGP FSM states used in state change records.
+const (
+	StateIdle        = 1
+	StateConnect     = 2
+	StateActive      = 3
+	StateOpenSent    = 4
+	StateOpenConfirm = 5
+	StateEstablished = 6
+)
+
+// Writer writes MRT records. It is not safe for concurrent use.
+type Writer struct {
+	w io.Writer
+	// ExtendedTimestamp selects BGP4MP_ET records, which keep the
+	// microseconds of RIS timestamps.
+	ExtendedTimestamp bool
+	states            map[string]uint16
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w, states: map[string]uint16{}}
+}
+
+// Reset directs further records to w, keeping the peer state tracking.
+func (w *Writer) Reset(out io.Writer) {
+	w.w = out
+}
+
+// WriteRecord writes rec. Data must not include the microseconds field of
+// extended timestamp records, it is taken from Timestamp.
+func (w *Writer) WriteRecord(rec *Record) error {
+	data := rec.Data
+	if rec.Type == TypeBGP4MPET {
+		usec := make([]byte, 4)
+		binary.BigEndian.PutUint32(usec, uint32(rec.Timestamp.Nanosecond()/1000))
+		data = append(usec, data...)
+	}
+	hdr := make([]byte, headerLen, headerLen+len(data))
+	binary.BigEndian.PutUint32(hdr[0:], uint32(rec.Timestamp.Unix()))
+	binary.BigEndian.PutUint16(hdr[4:], rec.Type)
+	binary.BigEndian.PutUint16(hdr[6:], rec.Subtype)
+	binary.BigEndian.PutUint32(hdr[8:], uint32(len(data)))
+	_, err := w.w.Write(append(hdr, data...))
+	return err
+}
+
+// WriteMessage writes a ris_message as BGP4MP_MESSAGE_AS4 records, or
+// BGP4MP_MESSAGE_AS4_LOCAL for a sent OPEN, or as a
+// BGP4MP_STATE_CHANGE_AS4 record for RIS_PEER_STATE. The raw BGP message is
+// used when present, otherwise it is re-encoded from the JSON fields. Other
+// message types are ignored.
+func (w *Writer) WriteMessage(m *rislive.RisLiveMessage) error {
+	if m.Type != "ris_message" {
+		return nil
+	}
+	if s, ok := m.AsPeerState(); ok {
+		return w.writeStateChange(s)
+	}
+	common, msgs, err := encodeMessage(m)
+	if err != nil {
+		return err
+	}
+	hdr, err := bgp4mpHeader(common)
+	if err != nil {
+		return err
+	}
+	subtype := uint16(SubtypeBGP4MPMessageAS4)
+	if o, ok := m.AsOpen(); ok && o.Direction == "sent" {
+		subtype = SubtypeBGP4MPMessageAS4Local
+	}
+	for _, msg := range msgs {
+		data := append(append([]byte{}, hdr...), msg...)
+		if err := w.WriteRecord(w.record(common, subtype, data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeMessage returns the BGP messages of a ris_message, preferring the
+// raw message.
+func encodeMessage(m *rislive.RisLiveMessage) (rislive.RisMessageCommon, [][]byte, error) {
+	if u, ok := m.AsUpdate(); ok {
+		if raw, ok := bgp.RawMessage(u.RisMessageCommon); ok {
+			return u.RisMessageCommon, [][]byte{raw}, nil
+		}
+		msgs, err := bgp.EncodeUpdate(u)
+		return u.RisMessageCommon, msgs, err
+	}
+	if o, ok := m.AsOpen(); ok {
+		if raw, ok := bgp.RawMessage(o.RisMessageCommon); ok {
+			return o.RisMessageCommon, [][]byte{raw}, nil
+		}
+		msg, err := bgp.EncodeOpen(o)
+		return o.RisMessageCommon, [][]byte{msg}, err
+	}
+	if n, ok := m.AsNotification(); ok {
+		if raw, ok := bgp.RawMessage(n.RisMessageCommon); ok {
+			return n.RisMessageCommon, [][]byte{raw}, nil
+		}
+		msg, err := bgp.EncodeNotification(n)
+		return n.RisMessageCommon, [][]byte{msg}, err
+	}
+	if k, ok := m.AsKeepalive(); ok {
+		return k.RisMessageCommon, [][]byte{bgp.EncodeKeepalive()}, nil
+	}
+	return rislive.RisMessageCommon{}, nil, fmt.Errorf("mrt: unsupported ris_message: %s", m.BgpMsgType)
+}
+
+func (w *Writer) writeStateChange(s *rislive.RisMessageRisPeerState) error {
+	var state uint16
+	switch s.State {
+	case "connected":
+		state = StateEstablished
+	case "down":
+		state = StateIdle
+	default:
+		return fmt.Errorf("mrt: unknown peer state: %q", s.State)
+	}
+	key := s.Host + "|" + s.Peer
+	old, ok := w.states[key]
+	if !ok {
+		old = StateIdle
+		if state == StateIdle {
+			old = StateEstablished
+		}
+	}
+	w.states[key] = state
+	data, err := bgp4mpHeader(s.RisMessageCommon)
+	if err != nil {
+		return err
+	}
+	data = append(data, byte(old>>8), byte(old), byte(state>>8), byte(state))
+	return w.WriteRecord(w.record(s.RisMessageCommon, SubtypeBGP4MPStateChangeAS4, data))
+}
+
+func (w *Writer) record(c rislive.RisMessageCommon, subtype uint16, data []byte) *Record {
+	typ := uint16(TypeBGP4MP)
+	if w.ExtendedTimestamp {
+		typ = TypeBGP4MPET
+	}
+	return &Record{
+		Header: Header{Timestamp: c.GetTimestamp(), Type: typ, Subtype: subtype},
+		Data:   data,
+	}
+}
+
+// bgp4mpHeader encodes the peer part of BGP4MP AS4 records. The local AS
+// and address of the collector are not known and written as zero.
+func bgp4mpHeader(c rislive.RisMessageCommon) ([]byte, error) {
+	asn, err := strconv.ParseUint(c.PeerASN, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("mrt: invalid peer ASN: %q", c.PeerASN)
+	}
+	peer := net.ParseIP(c.Peer)
+	if peer == nil {
+		return nil, fmt.Errorf("mrt: invalid peer address: %q", c.Peer)
+	}
+	b := make([]byte, 12)
+	binary.BigEndian.PutUint32(b[0:], uint32(asn))
+	if peer4 := peer.To4(); peer4 != nil {
+		binary.BigEndian.PutUint16(b[10:], bgp.AFIIPv4)
+		b = append(b, peer4...)
+		return append(b, net.IPv4zero.To4()...), nil
+	}
+	binary.BigEndian.PutUint16(b[10:], bgp.AFIIPv6)
+	b = append(b, peer.To16()...)
+	return append(b, net.IPv6zero...), nil
+}
+
+// RotatingWriter writes messages to files covering fixed intervals of
+// message time. File names are produced by formatting the interval start
+// with Pattern, a time layout relative to Dir such as
+// "2006.01/updates.20060102.1504.gz"; files ending in ".gz" are gzip
+// compressed. It is safe for concurrent use.
+type RotatingWriter struct {
+	Dir      string
+	Pattern  string
+	Interval time.Duration
+
+	mu     sync.Mutex
+	w      *Writer
+	period time.Time
+	file   *os.File
+	buf    *bufio.Writer
+	gz     *gzip.Writer
+}
+
+func NewRotatingWriter(dir, pattern string, interval time.Duration) *RotatingWriter {
+	return &RotatingWriter{
+		Dir:      dir,
+		Pattern:  pattern,
+		Interval: interval,
+		w:        NewWriter(nil),
+	}
+}
+
+// SetExtendedTimestamp selects BGP4MP_ET records.
+func (r *RotatingWriter) SetExtendedTimestamp(et bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.w.ExtendedTimestamp = et
+}
+
+// WriteMessage writes m to the file of the interval its timestamp falls in.
+// As for Writer, this is the timestamp of the BGP message in Data.
+func (r *RotatingWriter) WriteMessage(m *rislive.RisLiveMessage) error {
+	if m.Type != "ris_message" {
+		return nil
+	}
+	ts := rislive.FloatToTime(m.Timestamp)
+	if c, ok := m.Data.(rislive.RisMessageInterface); ok {
+		ts = c.GetTimestamp()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	period := ts.Truncate(r.Interval)
+	if r.file == nil || !period.Equal(r.period) {
+		if err := r.rotate(period); err != nil {
+			return err
+		}
+	}
+	return r.w.WriteMessage(m)
+}
+
+func (r *RotatingWriter) rotate(period time.Time) error {
+	if err := r.close(); err != nil {
+		return err
+	}
+	name := filepath.Join(r.Dir, period.UTC().Format(r.Pattern))
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	r.file, r.period = f, period
+	r.buf = bufio.NewWriter(f)
+	if strings.HasSuffix(name, ".gz") {
+		r.gz = gzip.NewWriter(r.buf)
+		r.w.Reset(r.gz)
+	} else {
+		r.w.Reset(r.buf)
+	}
+	return nil
+}
+
+func (r *RotatingWriter) close() error {
+	if r.file == nil {
+		return nil
+	}
+	var err error
+	if r.gz != nil {
+		err = r.gz.Close()
+		r.gz = nil
+	}
+	if ferr := r.buf.Flush(); err == nil {
+		err = ferr
+	}
+	if cerr := r.file.Close(); err == nil {
+		err = cerr
+	}
+	r.file, r.buf = nil, nil
+	return err
+}
+
+// Close flushes and closes the current file.
+func (r *RotatingWriter) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.close()
+}
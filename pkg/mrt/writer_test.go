@@ -0,0 +1,119 @@
+package mrt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/stretchr/testify/assert"
+)
+
+func decode(t *testing.T, s string) *rislive.RisLiveMessage {
+	var m rislive.RisLiveMessage
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		t.Fatal(err)
+	}
+	return &m
+}
+
+const (
+	rawKeepalive = `{"type": "ris_message", "data": {"timestamp": 1562822767.1, "peer": "195.66.224.31", "peer_asn": "32787", "id": "a",
+		"raw": "FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF001304", "host": "rrc01", "type": "KEEPALIVE"}}`
+	plainUpdate = `{"type": "ris_message", "data": {"timestamp": 1562822768.25, "peer": "2001:db8::1", "peer_asn": "200000", "id": "b", "host": "rrc01", "type": "UPDATE",
+		"path": [200000, 64510], "origin": "igp", "announcements": [{"next_hop": "2001:db8::1", "prefixes": ["2001:db8:1::/48"]}]}}`
+	peerDown = `{"type": "ris_message", "data": {"timestamp": 1562823052.55, "peer": "195.66.224.31", "peer_asn": "32787", "id": "c", "host": "rrc01", "type": "RIS_PEER_STATE", "state": "down"}}`
+	peerUp   = `{"type": "ris_message", "data": {"timestamp": 1562823060, "peer": "195.66.224.31", "peer_asn": "32787", "id": "d", "host": "rrc01", "type": "RIS_PEER_STATE", "state": "connected"}}`
+)
+
+func TestWriteMessage(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for _, s := range []string{rawKeepalive, plainUpdate, peerDown, peerUp} {
+		assert.NoError(w.WriteMessage(decode(t, s)))
+	}
+	assert.NoError(w.WriteMessage(rislive.NewRisPing()))
+
+	r := NewReader(&buf)
+	rec, err := r.Next()
+	assert.NoError(err)
+	assert.Equal(uint16(TypeBGP4MP), rec.Type)
+	assert.Equal(uint16(SubtypeBGP4MPMessageAS4), rec.Subtype)
+	assert.Equal(time.Unix(1562822767, 0).UTC(), rec.Timestamp)
+	assert.Equal(uint32(32787), binary.BigEndian.Uint32(rec.Data))
+	assert.Equal([]byte{195, 66, 224, 31}, rec.Data[12:16])
+	assert.Equal([]byte{0xff, 0xff, 0, 0x13, 4}, rec.Data[34:])
+
+	rec, err = r.Next()
+	assert.NoError(err)
+	assert.Equal(uint16(2), binary.BigEndian.Uint16(rec.Data[10:]))
+	assert.Len(rec.Data, 12+32+19+4+len(rec.Data[12+32+19+4:]))
+
+	rec, err = r.Next()
+	assert.NoError(err)
+	assert.Equal(uint16(SubtypeBGP4MPStateChangeAS4), rec.Subtype)
+	assert.Equal([]byte{0, StateEstablished, 0, StateIdle}, rec.Data[20:])
+
+	rec, err = r.Next()
+	assert.NoError(err)
+	assert.Equal([]byte{0, StateIdle, 0, StateEstablished}, rec.Data[20:])
+
+	_, err = r.Next()
+	assert.Equal(io.EOF, err)
+}
+
+func TestWriteExtendedTimestamp(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.ExtendedTimestamp = true
+	assert.NoError(w.WriteMessage(decode(t, plainUpdate)))
+	rec, err := NewReader(&buf).Next()
+	assert.NoError(err)
+	assert.Equal(uint16(TypeBGP4MPET), rec.Type)
+	assert.Equal(time.Date(2019, 7, 11, 5, 26, 8, 250000000, time.UTC), rec.Timestamp)
+}
+
+func TestRotatingWriter(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "mrt")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	w := NewRotatingWriter(filepath.Join(dir, "rrc01"), "2006.01/updates.20060102.1504.gz", 5*time.Minute)
+	for _, s := range []string{rawKeepalive, plainUpdate, peerDown, peerUp} {
+		assert.NoError(w.WriteMessage(decode(t, s)))
+	}
+	// A message built in code has only the timestamp of its payload.
+	m := decode(t, plainUpdate)
+	m.Timestamp = 0
+	assert.NoError(w.WriteMessage(m))
+	assert.NoError(w.Close())
+
+	files, err := filepath.Glob(filepath.Join(dir, "rrc01", "*", "*"))
+	assert.NoError(err)
+	assert.Equal([]string{
+		filepath.Join(dir, "rrc01", "2019.07", "updates.20190711.0525.gz"),
+		filepath.Join(dir, "rrc01", "2019.07", "updates.20190711.0530.gz"),
+	}, files)
+
+	r, err := Open(files[0])
+	assert.NoError(err)
+	defer r.Close()
+	n := 0
+	for {
+		if _, err := r.Next(); err != nil {
+			assert.Equal(io.EOF, err)
+			break
+		}
+		n++
+	}
+	assert.Equal(3, n)
+}
@@ -0,0 +1,296 @@
+// Package prefixtree implements a path-compressed binary trie keyed by IP
+// prefixes, supporting exact, longest-match and covering/covered lookups.
+// A Tree is not safe for concurrent use.
+package prefixtree
+
+import (
+	"net"
+)
+
+type key struct {
+	addr [16]byte
+	bits int
+}
+
+type node struct {
+	key   key
+	value interface{}
+	set   bool
+	child [2]*node
+}
+
+type Tree struct {
+	v4  *node
+	v6  *node
+	len int
+}
+
+func New() *Tree {
+	return &Tree{}
+}
+
+func toKey(p *net.IPNet) (key, bool) {
+	var k key
+	ones, bits := p.Mask.Size()
+	if ip4 := p.IP.To4(); ip4 != nil && bits == 32 {
+		copy(k.addr[:], ip4)
+	} else if ip6 := p.IP.To16(); ip6 != nil && bits == 128 {
+		copy(k.addr[:], ip6)
+	} else {
+		return k, false
+	}
+	k.bits = ones
+	k = truncate(k, ones)
+	return k, true
+}
+
+func (k key) ipnet(v4 bool) *net.IPNet {
+	if v4 {
+		ip := make(net.IP, 4)
+		copy(ip, k.addr[:4])
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(k.bits, 32)}
+	}
+	ip := make(net.IP, 16)
+	copy(ip, k.addr[:])
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(k.bits, 128)}
+}
+
+func bitAt(k key, i int) int {
+	return int(k.addr[i/8]>>(7-uint(i%8))) & 1
+}
+
+func truncate(k key, bits int) key {
+	t := key{bits: bits}
+	for i := 0; i < 16; i++ {
+		switch {
+		case bits >= (i+1)*8:
+			t.addr[i] = k.addr[i]
+		case bits > i*8:
+			t.addr[i] = k.addr[i] & ^byte(0xff>>uint(bits-i*8))
+		}
+	}
+	return t
+}
+
+func commonLen(a, b key) int {
+	max := a.bits
+	if b.bits < max {
+		max = b.bits
+	}
+	n := 0
+	for i := 0; i < 16 && n < max; i++ {
+		x := a.addr[i] ^ b.addr[i]
+		if x == 0 {
+			n += 8
+			continue
+		}
+		for x&0x80 == 0 {
+			n++
+			x <<= 1
+		}
+		break
+	}
+	if n > max {
+		n = max
+	}
+	return n
+}
+
+func (t *Tree) root(p *net.IPNet) (**node, key, bool) {
+	k, ok := toKey(p)
+	if !ok {
+		return nil, k, false
+	}
+	if p.IP.To4() != nil && len(p.Mask) == net.IPv4len {
+		return &t.v4, k, true
+	}
+	return &t.v6, k, true
+}
+
+// Len returns the number of prefixes stored.
+func (t *Tree) Len() int {
+	return t.len
+}
+
+// Insert stores v for p, replacing any existing value. It returns false for
+// an invalid prefix.
+func (t *Tree) Insert(p *net.IPNet, v interface{}) bool {
+	n, k, ok := t.root(p)
+	if !ok {
+		return false
+	}
+	for {
+		cur := *n
+		if cur == nil {
+			*n = &node{key: k, value: v, set: true}
+			t.len++
+			return true
+		}
+		cl := commonLen(cur.key, k)
+		switch {
+		case cl == cur.key.bits && cl == k.bits:
+			if !cur.set {
+				t.len++
+			}
+			cur.value, cur.set = v, true
+			return true
+		case cl == cur.key.bits:
+			n = &cur.child[bitAt(k, cl)]
+			continue
+		case cl == k.bits:
+			nn := &node{key: k, value: v, set: true}
+			nn.child[bitAt(cur.key, cl)] = cur
+			*n = nn
+		default:
+			glue := &node{key: truncate(k, cl)}
+			glue.child[bitAt(k, cl)] = &node{key: k, value: v, set: true}
+			glue.child[bitAt(cur.key, cl)] = cur
+			*n = glue
+		}
+		t.len++
+		return true
+	}
+}
+
+// Get returns the value stored for exactly p.
+func (t *Tree) Get(p *net.IPNet) (interface{}, bool) {
+	n, k, ok := t.root(p)
+	if !ok {
+		return nil, false
+	}
+	cur := *n
+	for cur != nil {
+		cl := commonLen(cur.key, k)
+		if cl < cur.key.bits {
+			return nil, false
+		}
+		if cur.key.bits == k.bits {
+			return cur.value, cur.set
+		}
+		cur = cur.child[bitAt(k, cur.key.bits)]
+	}
+	return nil, false
+}
+
+// Delete removes p and reports whether it was present.
+func (t *Tree) Delete(p *net.IPNet) bool {
+	n, k, ok := t.root(p)
+	if !ok {
+		return false
+	}
+	if !del(n, k) {
+		return false
+	}
+	t.len--
+	return true
+}
+
+func del(n **node, k key) bool {
+	cur := *n
+	if cur == nil || commonLen(cur.key, k) < cur.key.bits {
+		return false
+	}
+	if cur.key.bits == k.bits {
+		if !cur.set {
+			return false
+		}
+		cur.value, cur.set = nil, false
+	} else if !del(&cur.child[bitAt(k, cur.key.bits)], k) {
+		return false
+	}
+	if !cur.set {
+		switch {
+		case cur.child[0] == nil && cur.child[1] == nil:
+			*n = nil
+		case cur.child[0] == nil:
+			*n = cur.child[1]
+		case cur.child[1] == nil:
+			*n = cur.child[0]
+		}
+	}
+	return true
+}
+
+// LongestMatch returns the most specific prefix containing ip.
+func (t *Tree) LongestMatch(ip net.IP) (*net.IPNet, interface{}, bool) {
+	var (
+		prefix *net.IPNet
+		value  interface{}
+		found  bool
+	)
+	t.Covering(hostPrefix(ip), func(p *net.IPNet, v interface{}) bool {
+		prefix, value, found = p, v, true
+		return true
+	})
+	return prefix, value, found
+}
+
+func hostPrefix(ip net.IP) *net.IPNet {
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}
+	}
+	return &net.IPNet{IP: ip.To16(), Mask: net.CIDRMask(128, 128)}
+}
+
+// Covering calls fn for every stored prefix equal to or less specific than
+// p, from the least to the most specific, until fn returns false.
+func (t *Tree) Covering(p *net.IPNet, fn func(*net.IPNet, interface{}) bool) {
+	n, k, ok := t.root(p)
+	if !ok {
+		return
+	}
+	v4 := n == &t.v4
+	cur := *n
+	for cur != nil && cur.key.bits <= k.bits {
+		if commonLen(cur.key, k) < cur.key.bits {
+			return
+		}
+		if cur.set && !fn(cur.key.ipnet(v4), cur.value) {
+			return
+		}
+		if cur.key.bits == k.bits {
+			return
+		}
+		cur = cur.child[bitAt(k, cur.key.bits)]
+	}
+}
+
+// Covered calls fn for every stored prefix equal to or more specific than
+// p, in depth-first order, until fn returns false.
+func (t *Tree) Covered(p *net.IPNet, fn func(*net.IPNet, interface{}) bool) {
+	n, k, ok := t.root(p)
+	if !ok {
+		return
+	}
+	v4 := n == &t.v4
+	cur := *n
+	for cur != nil {
+		cl := commonLen(cur.key, k)
+		if cl == k.bits {
+			walk(cur, v4, fn)
+			return
+		}
+		if cl < cur.key.bits {
+			return
+		}
+		cur = cur.child[bitAt(k, cur.key.bits)]
+	}
+}
+
+// Walk calls fn for every stored prefix, IPv4 first, until fn returns
+// false.
+func (t *Tree) Walk(fn func(*net.IPNet, interface{}) bool) {
+	if walk(t.v4, true, fn) {
+		walk(t.v6, false, fn)
+	}
+}
+
+func walk(n *node, v4 bool, fn func(*net.IPNet, interface{}) bool) bool {
+	if n == nil {
+		return true
+	}
+	if n.set && !fn(n.key.ipnet(v4), n.value) {
+		return false
+	}
+	return walk(n.child[0], v4, fn) && walk(n.child[1], v4, fn)
+}
@@ -0,0 +1,86 @@
+package prefixtree
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func cidr(s string) *net.IPNet {
+	_, p, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func collect(f func(*net.IPNet, func(*net.IPNet, interface{}) bool), p string) []string {
+	r := []string{}
+	f(cidr(p), func(n *net.IPNet, v interface{}) bool {
+		r = append(r, n.String())
+		return true
+	})
+	return r
+}
+
+func TestTree(t *testing.T) {
+	assert := assert.New(t)
+	tree := New()
+	for _, p := range []string{"10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24", "10.2.0.0/16", "192.168.0.0/16", "2001:db8::/32", "2001:db8:1::/48", "0.0.0.0/0"} {
+		assert.True(tree.Insert(cidr(p), p))
+	}
+	assert.Equal(8, tree.Len())
+	tree.Insert(cidr("10.1.0.0/16"), "again")
+	assert.Equal(8, tree.Len())
+
+	v, ok := tree.Get(cidr("10.1.0.0/16"))
+	assert.True(ok)
+	assert.Equal("again", v)
+	_, ok = tree.Get(cidr("10.1.0.0/17"))
+	assert.False(ok)
+	_, ok = tree.Get(cidr("10.0.0.0/7"))
+	assert.False(ok)
+
+	p, v, ok := tree.LongestMatch(net.ParseIP("10.1.2.3"))
+	assert.True(ok)
+	assert.Equal("10.1.2.0/24", p.String())
+	assert.Equal("10.1.2.0/24", v)
+	p, _, ok = tree.LongestMatch(net.ParseIP("11.0.0.1"))
+	assert.True(ok)
+	assert.Equal("0.0.0.0/0", p.String())
+	p, _, ok = tree.LongestMatch(net.ParseIP("2001:db8:1::1"))
+	assert.True(ok)
+	assert.Equal("2001:db8:1::/48", p.String())
+	_, _, ok = tree.LongestMatch(net.ParseIP("2001:db9::1"))
+	assert.False(ok)
+
+	assert.Equal([]string{"0.0.0.0/0", "10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24"}, collect(tree.Covering, "10.1.2.0/24"))
+	assert.Equal([]string{"10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24", "10.2.0.0/16"}, collect(tree.Covered, "10.0.0.0/8"))
+	assert.Equal([]string{"10.1.0.0/16", "10.1.2.0/24"}, collect(tree.Covered, "10.0.0.0/15"))
+	assert.Equal([]string{}, collect(tree.Covered, "172.16.0.0/12"))
+
+	assert.True(tree.Delete(cidr("10.1.0.0/16")))
+	assert.False(tree.Delete(cidr("10.1.0.0/16")))
+	assert.False(tree.Delete(cidr("10.3.0.0/16")))
+	assert.Equal(7, tree.Len())
+	assert.Equal([]string{"0.0.0.0/0", "10.0.0.0/8", "10.1.2.0/24"}, collect(tree.Covering, "10.1.2.0/24"))
+
+	n := 0
+	tree.Walk(func(*net.IPNet, interface{}) bool {
+		n++
+		return true
+	})
+	assert.Equal(7, n)
+}
+
+func TestDeleteCollapse(t *testing.T) {
+	assert := assert.New(t)
+	tree := New()
+	tree.Insert(cidr("10.0.0.0/24"), 1)
+	tree.Insert(cidr("10.0.1.0/24"), 2)
+	assert.True(tree.Delete(cidr("10.0.0.0/24")))
+	assert.True(tree.Delete(cidr("10.0.1.0/24")))
+	assert.Equal(0, tree.Len())
+	assert.Nil(tree.v4)
+}
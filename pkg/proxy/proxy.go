@@ -0,0 +1,117 @@
+// Package proxy re-broadcasts a single upstream RIS Live feed to many
+// downstream clients speaking the RIS Live WebSocket protocol. Each
+// client's subscriptions are applied locally.
+package proxy
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/a16/go-rislive/internal/fanout"
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// DefaultMaxBuffer is the number of messages queued for a client before
+	// it is disconnected as a slow consumer.
+	DefaultMaxBuffer = 1024
+	// DefaultWriteTimeout bounds a single write to a client.
+	DefaultWriteTimeout = 10 * time.Second
+)
+
+type Server struct {
+	MaxBuffer    int
+	WriteTimeout time.Duration
+	// ErrorLog receives the frames that could not be delivered. The log
+	// package's standard logger is used when nil.
+	ErrorLog *log.Logger
+
+	hub      *fanout.Hub
+	upgrader websocket.Upgrader
+}
+
+func NewServer() *Server {
+	return &Server{
+		MaxBuffer:    DefaultMaxBuffer,
+		WriteTimeout: DefaultWriteTimeout,
+		hub:          fanout.NewHub(),
+		upgrader: websocket.Upgrader{
+			CheckOrigin: func(*http.Request) bool { return true },
+		},
+	}
+}
+
+// Handler returns the HTTP handler serving the WebSocket endpoint on
+// /v1/ws/ and the HTTP stream on /v1/stream/ as RIS Live does.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/ws/", s.ServeWebSocket)
+	mux.HandleFunc("/v1/stream/", s.ServeStream)
+	return mux
+}
+
+// SetRrcList sets the collectors returned for request_rrc_list.
+func (s *Server) SetRrcList(rrcs rislive.RisRrcList) {
+	s.hub.SetRrcList(rrcs)
+}
+
+// Clients returns the number of connected clients.
+func (s *Server) Clients() int {
+	return s.hub.Len()
+}
+
+// Close disconnects all clients.
+func (s *Server) Close() {
+	s.hub.CloseAll()
+}
+
+func (s *Server) logf(format string, args ...interface{}) {
+	if s.ErrorLog != nil {
+		s.ErrorLog.Printf(format, args...)
+	} else {
+		log.Printf(format, args...)
+	}
+}
+
+// HandleUpstream consumes a frame of the upstream connection. It has the
+// signature of client.Handler. ris_message frames are delivered to the
+// matching clients and ris_rrc_list updates the collector list; anything
+// else concerns the upstream session only and is dropped.
+func (s *Server) HandleUpstream(frame []byte, m *rislive.RisLiveMessage) {
+	if m == nil {
+		return
+	}
+	switch m.Type {
+	case "ris_message":
+		if err := s.hub.Publish(frame, m); err != nil {
+			s.logf("proxy: frame not delivered to clients without raw: %v", err)
+		}
+	case "ris_rrc_list":
+		if l, ok := m.AsRrcList(); ok {
+			s.SetRrcList(l)
+		}
+	}
+}
+
+func (s *Server) addClient(filters ...*rislive.Filter) *fanout.Subscriber {
+	size := s.MaxBuffer
+	if size <= 0 {
+		size = DefaultMaxBuffer
+	}
+	return s.hub.Add(size, filters...)
+}
+
+// ServeWebSocket upgrades the request and serves the client until it
+// disconnects or falls behind.
+func (s *Server) ServeWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	c := s.addClient()
+	defer s.hub.Remove(c)
+	c.ServeWebSocket(conn, s.WriteTimeout)
+}
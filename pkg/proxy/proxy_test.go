@@ -0,0 +1,152 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/a16/go-rislive/pkg/client"
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/a16/go-rislive/pkg/rislivetest"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func dial(t *testing.T, ts *httptest.Server) *websocket.Conn {
+	u := "ws" + strings.TrimPrefix(ts.URL, "http") + "/v1/ws/"
+	conn, _, err := websocket.DefaultDialer.Dial(u, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return conn
+}
+
+func read(t *testing.T, conn *websocket.Conn) *rislive.RisLiveMessage {
+	_, p, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m rislive.RisLiveMessage
+	if err := json.Unmarshal(p, &m); err != nil {
+		t.Fatal(err)
+	}
+	return &m
+}
+
+func subscribe(t *testing.T, conn *websocket.Conn, f *rislive.Filter) {
+	if err := conn.WriteJSON(rislive.NewRisSubscribe(f)); err != nil {
+		t.Fatal(err)
+	}
+	// A ping round trip guarantees the subscription is in place.
+	if err := conn.WriteJSON(rislive.NewRisPing()); err != nil {
+		t.Fatal(err)
+	}
+	if m := read(t, conn); m.Type != "pong" {
+		t.Fatalf("unexpected %s", m.Type)
+	}
+}
+
+func waitClients(t *testing.T, s *Server, n int) {
+	for i := 0; i < 500 && s.Clients() != n; i++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if s.Clients() != n {
+		t.Fatalf("%d clients, want %d", s.Clients(), n)
+	}
+}
+
+func TestProxy(t *testing.T) {
+	assert := assert.New(t)
+	upstream := rislivetest.NewServer()
+	defer upstream.Close()
+	upstream.SetRrcList("rrc00", "rrc13")
+
+	s := NewServer()
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+	defer s.Close()
+
+	f := rislive.NewFilter()
+	f.SetSocketOptions(true)
+	c := client.New(f)
+	c.URL = upstream.WebSocketURL()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx, s.HandleUpstream)
+	assert.NoError(upstream.WaitForSubscribers(1, 5*time.Second))
+
+	rrc13 := dial(t, ts)
+	defer rrc13.Close()
+	f13 := rislive.NewFilter()
+	f13.SetHost("rrc13")
+	subscribe(t, rrc13, f13)
+
+	raw := dial(t, ts)
+	defer raw.Close()
+	fraw := rislive.NewFilter()
+	fraw.SetSocketOptions(true)
+	subscribe(t, raw, fraw)
+
+	assert.NoError(raw.WriteJSON(rislive.NewRisRequestRrcList()))
+	l, ok := read(t, raw).AsRrcList()
+	assert.True(ok)
+	assert.Equal(rislive.RisRrcList{"rrc00", "rrc13"}, l)
+
+	assert.NoError(upstream.PublishMessages(
+		rislive.NewUpdate().Host("rrc00").Peer("192.0.2.1", 64496).Raw("FFFF").Path(64496).Announce("192.0.2.1", "198.51.100.0/24").MustMessage(),
+		rislive.NewUpdate().Host("rrc13").Peer("192.0.2.2", 64497).Raw("FFFF").Path(64497).Announce("192.0.2.2", "203.0.113.0/24").MustMessage(),
+	))
+
+	m := read(t, raw)
+	assert.Equal("rrc00", m.Host)
+	assert.Equal("FFFF", m.Raw)
+	assert.Equal("rrc13", read(t, raw).Host)
+
+	m = read(t, rrc13)
+	assert.Equal("rrc13", m.Host)
+	assert.Equal("", m.Raw)
+
+	assert.NoError(rrc13.WriteJSON(map[string]string{"type": "bogus"}))
+	e, ok := read(t, rrc13).AsError()
+	assert.True(ok)
+	assert.Equal("bogus", e.CommandType)
+}
+
+func TestSlowClient(t *testing.T) {
+	assert := assert.New(t)
+	s := NewServer()
+	s.MaxBuffer = 2
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+	defer s.Close()
+
+	conn := dial(t, ts)
+	defer conn.Close()
+	subscribe(t, conn, rislive.NewFilter())
+	waitClients(t, s, 1)
+
+	m := rislive.NewKeepalive().Host("rrc00").Peer("192.0.2.1", 64496).MustMessage()
+	frame, err := json.Marshal(m)
+	assert.NoError(err)
+	for i := 0; i < 100; i++ {
+		s.HandleUpstream(frame, m)
+	}
+
+	var last *rislive.RisLiveMessage
+	for {
+		_, p, err := conn.ReadMessage()
+		if err != nil {
+			break
+		}
+		last = &rislive.RisLiveMessage{}
+		assert.NoError(json.Unmarshal(p, last))
+	}
+	e, ok := last.AsError()
+	assert.True(ok)
+	assert.Equal(uint64(2), e.BufferSize)
+	waitClients(t, s, 0)
+}
@@ -0,0 +1,88 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+)
+
+// ServeStream streams the messages matching the filter given as query
+// parameters, as in RIS Live's /v1/stream/. The format parameter selects
+// Server-Sent Events ("sse", the default) or newline delimited JSON
+// ("json"). Each event carries a whole frame with its type as event name.
+// Every write is bounded by WriteTimeout, so that a client that stops
+// reading is disconnected.
+func (s *Server) ServeStream(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var write func(http.ResponseWriter, []byte) error
+	switch q.Get("format") {
+	case "", "sse":
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		write = writeEvent
+	case "json":
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		write = writeLine
+	default:
+		http.Error(w, "unsupported format", http.StatusBadRequest)
+		return
+	}
+	f, err := rislive.FilterFromValues(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	flusher, _ := w.(http.Flusher)
+	w.WriteHeader(http.StatusOK)
+	if flusher != nil {
+		flusher.Flush()
+	}
+
+	c := s.addClient(f)
+	defer s.hub.Remove(c)
+	c.CloseOnDone(r.Context())
+	rc := http.NewResponseController(w)
+	c.WriteLoop(func(frame []byte) error {
+		if s.WriteTimeout > 0 {
+			rc.SetWriteDeadline(time.Now().Add(s.WriteTimeout))
+		}
+		if err := write(w, frame); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+}
+
+// writeLine writes frame and a newline. The frame is shared with other
+// clients and must not be appended to.
+func writeLine(w http.ResponseWriter, frame []byte) error {
+	if _, err := w.Write(frame); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func writeEvent(w http.ResponseWriter, frame []byte) error {
+	var head struct {
+		Type string `json:"type"`
+	}
+	json.Unmarshal(frame, &head)
+	var b bytes.Buffer
+	if head.Type != "" {
+		fmt.Fprintf(&b, "event: %s\n", head.Type)
+	}
+	b.WriteString("data: ")
+	b.Write(frame)
+	b.WriteString("\n\n")
+	_, err := w.Write(b.Bytes())
+	return err
+}
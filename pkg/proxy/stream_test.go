@@ -0,0 +1,133 @@
+package proxy
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/stretchr/testify/assert"
+)
+
+func publish(t *testing.T, s *Server, msgs ...*rislive.RisLiveMessage) {
+	for _, m := range msgs {
+		frame, err := json.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.HandleUpstream(frame, m)
+	}
+}
+
+func TestStream(t *testing.T) {
+	s := NewServer()
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+	defer s.Close()
+
+	rrc00 := rislive.NewUpdate().Host("rrc00").Peer("192.0.2.1", 64496).Raw("FFFF").Path(64496).Announce("192.0.2.1", "198.51.100.0/24").MustMessage()
+	rrc13 := rislive.NewUpdate().Host("rrc13").Peer("192.0.2.2", 64497).Raw("FFFF").Path(64497).Announce("192.0.2.2", "203.0.113.0/24").MustMessage()
+
+	t.Run("ndjson", func(t *testing.T) {
+		assert := assert.New(t)
+		resp, err := http.Get(ts.URL + "/v1/stream/?format=json&host=rrc13&includeRaw=true")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		assert.Equal("application/x-ndjson", resp.Header.Get("Content-Type"))
+		waitClients(t, s, 1)
+		publish(t, s, rrc00, rrc13)
+
+		sc := bufio.NewScanner(resp.Body)
+		assert.True(sc.Scan())
+		var m rislive.RisLiveMessage
+		assert.NoError(json.Unmarshal(sc.Bytes(), &m))
+		assert.Equal("rrc13", m.Host)
+		assert.Equal("FFFF", m.Raw)
+	})
+	waitClients(t, s, 0)
+
+	t.Run("sse", func(t *testing.T) {
+		assert := assert.New(t)
+		resp, err := http.Get(ts.URL + "/v1/stream/?prefix=198.51.100.0/22&moreSpecific=true")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		assert.Equal("text/event-stream", resp.Header.Get("Content-Type"))
+		waitClients(t, s, 1)
+		publish(t, s, rrc13, rrc00)
+
+		rd := bufio.NewReader(resp.Body)
+		line, _ := rd.ReadString('\n')
+		assert.Equal("event: ris_message\n", line)
+		line, _ = rd.ReadString('\n')
+		assert.True(strings.HasPrefix(line, "data: "))
+		var m rislive.RisLiveMessage
+		assert.NoError(json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &m))
+		assert.Equal("rrc00", m.Host)
+		assert.Equal("", m.Raw)
+		line, _ = rd.ReadString('\n')
+		assert.Equal("\n", line)
+	})
+}
+
+func TestStreamStalledClient(t *testing.T) {
+	s := NewServer()
+	s.WriteTimeout = 100 * time.Millisecond
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+	defer s.Close()
+
+	// The client sends its request and then never reads.
+	conn, err := net.Dial("tcp", ts.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	fmt.Fprintf(conn, "GET /v1/stream/?format=json HTTP/1.1\r\nHost: %s\r\n\r\n", ts.Listener.Addr())
+	waitClients(t, s, 1)
+
+	prefixes := make([]string, 1000)
+	for i := range prefixes {
+		prefixes[i] = fmt.Sprintf("10.%d.%d.0/24", i/256, i%256)
+	}
+	m := rislive.NewUpdate().Host("rrc00").Peer("192.0.2.1", 64496).Path(64496).Announce("192.0.2.1", prefixes...).MustMessage()
+	// Fill the socket buffers without overflowing the queue, so that only
+	// the write deadline can disconnect the client.
+	for i := 0; i < DefaultMaxBuffer/2 && s.Clients() > 0; i++ {
+		publish(t, s, m)
+	}
+	waitClients(t, s, 0)
+}
+
+func TestStreamBadRequest(t *testing.T) {
+	s := NewServer()
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	for _, q := range []string{"format=xml", "moreSpecific=maybe", "prefix=bogus"} {
+		resp, err := http.Get(ts.URL + "/v1/stream/?" + q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, q)
+	}
+}
+
+func TestWriteLineKeepsFrame(t *testing.T) {
+	// Spare capacity must not be written to, as other clients share it.
+	frame := append(make([]byte, 0, 8), `{}`...)
+	rec := httptest.NewRecorder()
+	assert.NoError(t, writeLine(rec, frame))
+	assert.Equal(t, "{}\n", rec.Body.String())
+	assert.Equal(t, byte(0), frame[:3][2])
+}
@@ -0,0 +1,239 @@
+// Package replay records raw RIS Live frames with their receive time to
+// gzip compressed JSON lines files and feeds them back through the
+// decoder.
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+)
+
+// Frame is a received WebSocket message or stream line.
+type Frame struct {
+	Time time.Time
+	Data []byte
+}
+
+// line is the file representation of a Frame. Frames that are valid JSON
+// on a single line are embedded byte for byte, anything else is kept as a
+// string in Text.
+type line struct {
+	Time time.Time       `json:"time"`
+	Data json.RawMessage `json:"data,omitempty"`
+	Text *string         `json:"text,omitempty"`
+}
+
+// Recorder writes frames to a gzip compressed JSON lines stream. It is
+// safe for concurrent use.
+type Recorder struct {
+	mu     sync.Mutex
+	gz     *gzip.Writer
+	enc    *json.Encoder
+	closer io.Closer
+}
+
+func NewRecorder(w io.Writer) *Recorder {
+	gz := gzip.NewWriter(w)
+	enc := json.NewEncoder(gz)
+	enc.SetEscapeHTML(false)
+	return &Recorder{gz: gz, enc: enc}
+}
+
+// CreateRecorder creates or truncates the file at path.
+func CreateRecorder(path string) (*Recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	r := NewRecorder(f)
+	r.closer = f
+	return r, nil
+}
+
+// embeddable tells whether a frame can be embedded in a line and read back
+// unchanged: json.RawMessage drops surrounding whitespace, and a line
+// cannot hold newlines.
+func embeddable(frame []byte) bool {
+	return json.Valid(frame) && len(bytes.TrimSpace(frame)) == len(frame) && bytes.IndexAny(frame, "\r\n") < 0
+}
+
+// Record writes a frame received at t.
+func (r *Recorder) Record(t time.Time, frame []byte) error {
+	if !embeddable(frame) {
+		s := string(frame)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return r.enc.Encode(&line{Time: t, Text: &s})
+	}
+	ts, err := t.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	b := append([]byte(`{"time":`), ts...)
+	b = append(b, `,"data":`...)
+	b = append(b, frame...)
+	b = append(b, "}\n"...)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, err = r.gz.Write(b)
+	return err
+}
+
+// Flush writes buffered frames so that they can be read back.
+func (r *Recorder) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.gz.Flush()
+}
+
+// Close finishes the stream and closes the file opened by CreateRecorder.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	err := r.gz.Close()
+	if r.closer != nil {
+		if cerr := r.closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// Reader reads frames written by a Recorder.
+type Reader struct {
+	gz      *gzip.Reader
+	scanner *bufio.Scanner
+	closer  io.Closer
+}
+
+// maxLineLen bounds the length of a recorded frame.
+const maxLineLen = 16 << 20
+
+func NewReader(r io.Reader) (*Reader, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	s := bufio.NewScanner(gz)
+	s.Buffer(make([]byte, 64*1024), maxLineLen)
+	return &Reader{gz: gz, scanner: s}, nil
+}
+
+func Open(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	r.closer = f
+	return r, nil
+}
+
+// Next returns the next frame, or io.EOF at the end of the recording.
+func (r *Reader) Next() (*Frame, error) {
+	for r.scanner.Scan() {
+		buf := r.scanner.Bytes()
+		if len(buf) == 0 {
+			continue
+		}
+		var l line
+		if err := json.Unmarshal(buf, &l); err != nil {
+			return nil, fmt.Errorf("replay: invalid line: %v", err)
+		}
+		f := &Frame{Time: l.Time, Data: []byte(l.Data)}
+		if l.Text != nil {
+			f.Data = []byte(*l.Text)
+		}
+		return f, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func (r *Reader) Close() error {
+	err := r.gz.Close()
+	if r.closer != nil {
+		if cerr := r.closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// Replayer feeds recorded frames back. Speed scales the delays between
+// frames: 1 replays in real time, 10 ten times faster, and 0 as fast as
+// possible.
+type Replayer struct {
+	Speed float64
+}
+
+// ReplayFrames calls fn for every frame of r, paced according to Speed,
+// until the recording ends, fn returns an error or ctx is done.
+func (p *Replayer) ReplayFrames(ctx context.Context, r *Reader, fn func(*Frame) error) error {
+	if p.Speed < 0 {
+		return errors.New("replay: negative speed")
+	}
+	var first time.Time
+	var start time.Time
+	for {
+		f, err := r.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if p.Speed > 0 {
+			if start.IsZero() {
+				first, start = f.Time, time.Now()
+			}
+			offset := time.Duration(float64(f.Time.Sub(first)) / p.Speed)
+			if wait := time.Until(start.Add(offset)); wait > 0 {
+				t := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					t.Stop()
+					return ctx.Err()
+				case <-t.C:
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+}
+
+// Replay decodes every frame and calls fn with the message. Replay stops
+// with an error at the first frame that fails to decode.
+func (p *Replayer) Replay(ctx context.Context, r *Reader, fn func(*rislive.RisLiveMessage) error) error {
+	return p.ReplayFrames(ctx, r, func(f *Frame) error {
+		var m rislive.RisLiveMessage
+		if err := json.Unmarshal(f.Data, &m); err != nil {
+			return fmt.Errorf("replay: frame at %s: %v", f.Time.Format(time.RFC3339Nano), err)
+		}
+		return fn(&m)
+	})
+}
@@ -0,0 +1,151 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/stretchr/testify/assert"
+)
+
+var frames = []string{
+	`{"type":"ris_message","data":{"timestamp":1562822767.1,"peer":"195.66.224.31","peer_asn":"32787","id":"a","host":"rrc01","type":"KEEPALIVE"}}`,
+	`{"type":"pong"}`,
+	`{"type":"ris_rrc_list","data":["rrc00","rrc01"]}`,
+}
+
+func record(t *testing.T, start time.Time, step time.Duration) *bytes.Buffer {
+	var buf bytes.Buffer
+	r := NewRecorder(&buf)
+	for i, f := range frames {
+		if err := r.Record(start.Add(time.Duration(i)*step), []byte(f)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func TestRecordAndRead(t *testing.T) {
+	assert := assert.New(t)
+	start := time.Date(2019, 7, 11, 5, 0, 0, 123456789, time.UTC)
+	buf := record(t, start, time.Second)
+
+	r, err := NewReader(buf)
+	assert.NoError(err)
+	for i, f := range frames {
+		got, err := r.Next()
+		assert.NoError(err)
+		assert.True(start.Add(time.Duration(i) * time.Second).Equal(got.Time))
+		assert.JSONEq(f, string(got.Data))
+	}
+	_, err = r.Next()
+	assert.Equal(io.EOF, err)
+}
+
+func TestRecordRaw(t *testing.T) {
+	assert := assert.New(t)
+	raw := [][]byte{
+		[]byte(`{"type": "ris_error",  "data": {"message": "a & b <c>"}}`),
+		[]byte("{\n\t\"type\": \"pong\"\n}"),
+		[]byte(` {"type":"pong"}`),
+	}
+	var buf bytes.Buffer
+	rec := NewRecorder(&buf)
+	for _, f := range raw {
+		assert.NoError(rec.Record(time.Now(), f))
+	}
+	assert.NoError(rec.Close())
+
+	r, err := NewReader(&buf)
+	assert.NoError(err)
+	for _, f := range raw {
+		got, err := r.Next()
+		assert.NoError(err)
+		assert.Equal(f, got.Data)
+	}
+}
+
+func TestRecordInvalidFrame(t *testing.T) {
+	assert := assert.New(t)
+	var buf bytes.Buffer
+	rec := NewRecorder(&buf)
+	assert.NoError(rec.Record(time.Now(), []byte("{broken")))
+	assert.NoError(rec.Close())
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()))
+	assert.NoError(err)
+	f, err := r.Next()
+	assert.NoError(err)
+	assert.Equal("{broken", string(f.Data))
+
+	r, err = NewReader(bytes.NewReader(buf.Bytes()))
+	assert.NoError(err)
+	err = (&Replayer{}).Replay(context.Background(), r, func(*rislive.RisLiveMessage) error { return nil })
+	assert.Error(err)
+}
+
+func TestReplay(t *testing.T) {
+	assert := assert.New(t)
+	buf := record(t, time.Now(), time.Second)
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()))
+	assert.NoError(err)
+	types := []string{}
+	err = (&Replayer{}).Replay(context.Background(), r, func(m *rislive.RisLiveMessage) error {
+		types = append(types, m.Type)
+		return nil
+	})
+	assert.NoError(err)
+	assert.Equal([]string{"ris_message", "pong", "ris_rrc_list"}, types)
+
+	r, err = NewReader(bytes.NewReader(buf.Bytes()))
+	assert.NoError(err)
+	began := time.Now()
+	err = (&Replayer{Speed: 20}).Replay(context.Background(), r, func(*rislive.RisLiveMessage) error { return nil })
+	assert.NoError(err)
+	elapsed := time.Since(began)
+	assert.True(elapsed >= 100*time.Millisecond, "replayed too fast: %v", elapsed)
+
+	r, err = NewReader(bytes.NewReader(buf.Bytes()))
+	assert.NoError(err)
+	stop := errors.New("stop")
+	err = (&Replayer{}).Replay(context.Background(), r, func(*rislive.RisLiveMessage) error { return stop })
+	assert.Equal(stop, err)
+
+	r, err = NewReader(bytes.NewReader(buf.Bytes()))
+	assert.NoError(err)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = (&Replayer{Speed: 1}).Replay(ctx, r, func(*rislive.RisLiveMessage) error { return nil })
+	assert.Equal(context.DeadlineExceeded, err)
+}
+
+func TestRecorderFile(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "replay")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "session.jsonl.gz")
+
+	rec, err := CreateRecorder(path)
+	assert.NoError(err)
+	assert.NoError(rec.Record(time.Now(), []byte(frames[1])))
+	assert.NoError(rec.Close())
+
+	r, err := Open(path)
+	assert.NoError(err)
+	defer r.Close()
+	f, err := r.Next()
+	assert.NoError(err)
+	assert.Equal(frames[1], string(f.Data))
+}
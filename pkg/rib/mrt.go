@@ -0,0 +1,84 @@
+package rib
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/a16/go-rislive/pkg/mrt"
+)
+
+// LoadMRT seeds the RIB from a TABLE_DUMP_V2 stream such as a RIS bview
+// file. Routes are keyed by host and the peer address, matching the keys of
+// live UPDATEs. It returns the number of routes loaded.
+func (r *RIB) LoadMRT(rd *mrt.Reader, host string) (int, error) {
+	var peers *mrt.PeerIndexTable
+	n := 0
+	for {
+		rec, err := rd.Next()
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		if rec.Type != mrt.TypeTableDumpV2 {
+			continue
+		}
+		switch rec.Subtype {
+		case mrt.SubtypePeerIndexTable:
+			if peers, err = mrt.ParsePeerIndexTable(rec.Data); err != nil {
+				return n, err
+			}
+		case mrt.SubtypeRIBIPv4Unicast, mrt.SubtypeRIBIPv6Unicast:
+			if peers == nil {
+				return n, fmt.Errorf("rib: RIB record before PEER_INDEX_TABLE")
+			}
+			entries, err := mrt.ParseRIB(rec)
+			if err != nil {
+				return n, err
+			}
+			r.mu.Lock()
+			for _, e := range entries.Entries {
+				if int(e.PeerIndex) >= len(peers.Peers) {
+					r.mu.Unlock()
+					return n, fmt.Errorf("rib: peer index %d out of range", e.PeerIndex)
+				}
+				peer := peers.Peers[e.PeerIndex]
+				a := e.Attributes
+				rt := &Route{
+					Prefix:      entries.Prefix,
+					PeerKey:     PeerKey{Host: host, Peer: peer.IP.String()},
+					PeerASN:     strconv.FormatUint(uint64(peer.AS), 10),
+					Path:        a.Path,
+					Communities: a.Communities,
+					Origin:      a.Origin,
+					MED:         a.MED,
+					Time:        e.OriginatedTime,
+				}
+				if a.NextHop != nil {
+					rt.NextHop = a.NextHop.String()
+				} else if a.MPReach != nil && len(a.MPReach.NextHop) > 0 {
+					rt.NextHop = a.MPReach.NextHop[0].String()
+				}
+				r.insert(rt)
+				n++
+			}
+			r.mu.Unlock()
+		}
+	}
+}
+
+// LoadMRTFile seeds the RIB from a possibly compressed bview file. When
+// host is empty it is derived from the file path.
+func (r *RIB) LoadMRTFile(path, host string) (int, error) {
+	if host == "" {
+		host = mrt.HostFromPath(path)
+	}
+	rd, err := mrt.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer rd.Close()
+	return r.LoadMRT(rd, host)
+}
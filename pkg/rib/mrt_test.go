@@ -0,0 +1,35 @@
+package rib
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadMRTFile(t *testing.T) {
+	assert := assert.New(t)
+	r := New()
+	n, err := r.LoadMRTFile("testdata/rrc00/bview.20190711.0000.gz", "")
+	assert.NoError(err)
+	assert.Equal(3, n)
+	assert.Equal([]PeerKey{{Host: "rrc00", Peer: "192.0.2.1"}, {Host: "rrc00", Peer: "2001:db8::1"}}, r.Peers())
+
+	rt, ok := r.LookupPeer(PeerKey{Host: "rrc00", Peer: "2001:db8::1"}, cidr("2001:db8:100::/40"))
+	assert.True(ok)
+	assert.Equal("200000", rt.PeerASN)
+	assert.Equal("2001:db8::1", rt.NextHop)
+	assert.Equal("200000 64512", rt.Path.String())
+	assert.Equal(time.Date(2019, 7, 10, 23, 58, 0, 0, time.UTC), rt.Time)
+
+	// live updates apply on top of the dump
+	assert.NoError(r.Apply(decode(t, updateA)))
+	routes := r.LongestMatch(net.ParseIP("10.1.0.1"))
+	assert.Len(routes, 2)
+	assert.Equal("10.1.0.0/16", routes[0].Prefix.String())
+	assert.Equal("10.0.0.0/8", routes[1].Prefix.String())
+
+	_, err = r.LoadMRTFile("testdata/missing.gz", "rrc00")
+	assert.Error(err)
+}
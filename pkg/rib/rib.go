@@ -0,0 +1,336 @@
+// Package rib maintains per-peer Adj-RIB-In tables from the RIS Live update
+// stream.
+package rib
+
+import (
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/a16/go-rislive/pkg/prefixtree"
+)
+
+// PeerKey identifies a BGP session of a RIS collector, as in the Host and
+// Peer fields of RisMessageCommon.
+type PeerKey struct {
+	Host string
+	Peer string
+}
+
+type Route struct {
+	Prefix      *net.IPNet
+	PeerKey     PeerKey
+	PeerASN     string
+	NextHop     string
+	Path        rislive.ASPath
+	Communities [][]uint16
+	Origin      string
+	MED         uint32
+	Time        time.Time
+}
+
+// RIB holds the current route of every (Host, Peer) for each prefix. It is
+// safe for concurrent use.
+type RIB struct {
+	mu    sync.RWMutex
+	tree  *prefixtree.Tree
+	peers map[PeerKey]map[string]*net.IPNet
+	count int
+}
+
+// entry is the value stored in the tree for each prefix.
+type entry map[PeerKey]*Route
+
+func New() *RIB {
+	return &RIB{
+		tree:  prefixtree.New(),
+		peers: map[PeerKey]map[string]*net.IPNet{},
+	}
+}
+
+// PeerEvents is a rislive.Handler for the components that, like the RIB,
+// keep state per peer session. UPDATEs are passed to Update, while an OPEN or
+// a RIS_PEER_STATE "down" ends the session of the peer and calls Flush with
+// the time of the message. Nil functions are skipped.
+type PeerEvents struct {
+	rislive.NopHandler
+	Update func(u *rislive.RisMessageUpdate)
+	Flush  func(peer PeerKey, t time.Time)
+}
+
+func (h PeerEvents) HandleUpdate(u *rislive.RisMessageUpdate) {
+	if h.Update != nil {
+		h.Update(u)
+	}
+}
+
+func (h PeerEvents) HandleOpen(o *rislive.RisMessageOpen) {
+	h.flush(o.RisMessageCommon)
+}
+
+func (h PeerEvents) HandlePeerState(s *rislive.RisMessageRisPeerState) {
+	if s.State == "down" {
+		h.flush(s.RisMessageCommon)
+	}
+}
+
+func (h PeerEvents) flush(c rislive.RisMessageCommon) {
+	if h.Flush != nil {
+		h.Flush(PeerKey{Host: c.Host, Peer: c.Peer}, c.GetTimestamp())
+	}
+}
+
+// Apply updates the RIB from a message. UPDATEs modify routes, while an
+// OPEN or a RIS_PEER_STATE "down" flushes the routes of the peer. Other
+// messages are ignored.
+func (r *RIB) Apply(m *rislive.RisLiveMessage) error {
+	return m.Dispatch(PeerEvents{
+		Update: r.ApplyUpdate,
+		Flush:  func(peer PeerKey, _ time.Time) { r.FlushPeer(peer) },
+	})
+}
+
+// ApplyUpdate applies the withdrawals and announcements of an UPDATE. An
+// announcement replaces any route the peer had for the prefix. Prefixes
+// that cannot be parsed are skipped.
+func (r *RIB) ApplyUpdate(u *rislive.RisMessageUpdate) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u.EachRouteEvent(func(ev rislive.RouteEvent) bool {
+		_, prefix, err := net.ParseCIDR(ev.Prefix)
+		if err != nil {
+			return true
+		}
+		key := PeerKey{Host: ev.Host, Peer: ev.Peer}
+		if ev.Kind == rislive.RouteWithdrawal {
+			r.remove(key, prefix)
+			return true
+		}
+		r.insert(&Route{
+			Prefix:      prefix,
+			PeerKey:     key,
+			PeerASN:     ev.PeerASN,
+			NextHop:     ev.NextHop,
+			Path:        ev.Path,
+			Communities: ev.Communities,
+			Origin:      u.Origin,
+			MED:         u.MED,
+			Time:        ev.Time,
+		})
+		return true
+	})
+}
+
+// Insert adds or replaces a route.
+func (r *RIB) Insert(rt Route) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.insert(&rt)
+}
+
+func (r *RIB) insert(rt *Route) {
+	var e entry
+	if v, ok := r.tree.Get(rt.Prefix); ok {
+		e = v.(entry)
+	} else {
+		e = entry{}
+		r.tree.Insert(rt.Prefix, e)
+	}
+	if _, ok := e[rt.PeerKey]; !ok {
+		r.count++
+	}
+	e[rt.PeerKey] = rt
+	prefixes, ok := r.peers[rt.PeerKey]
+	if !ok {
+		prefixes = map[string]*net.IPNet{}
+		r.peers[rt.PeerKey] = prefixes
+	}
+	prefixes[rt.Prefix.String()] = rt.Prefix
+}
+
+func (r *RIB) remove(key PeerKey, prefix *net.IPNet) bool {
+	v, ok := r.tree.Get(prefix)
+	if !ok {
+		return false
+	}
+	e := v.(entry)
+	if _, ok := e[key]; !ok {
+		return false
+	}
+	delete(e, key)
+	r.count--
+	if len(e) == 0 {
+		r.tree.Delete(prefix)
+	}
+	if prefixes, ok := r.peers[key]; ok {
+		delete(prefixes, prefix.String())
+		if len(prefixes) == 0 {
+			delete(r.peers, key)
+		}
+	}
+	return true
+}
+
+// Withdraw removes the route of a peer for prefix.
+func (r *RIB) Withdraw(key PeerKey, prefix *net.IPNet) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.remove(key, prefix)
+}
+
+// FlushPeer removes all routes of a peer and returns how many there were.
+func (r *RIB) FlushPeer(key PeerKey) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, prefix := range r.peers[key] {
+		if r.remove(key, prefix) {
+			n++
+		}
+	}
+	delete(r.peers, key)
+	return n
+}
+
+// Len returns the total number of routes over all peers.
+func (r *RIB) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.count
+}
+
+// Prefixes returns the number of distinct prefixes.
+func (r *RIB) Prefixes() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.tree.Len()
+}
+
+// Peers returns the peers having at least one route, sorted.
+func (r *RIB) Peers() []PeerKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	keys := make([]PeerKey, 0, len(r.peers))
+	for k := range r.peers {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Host != keys[j].Host {
+			return keys[i].Host < keys[j].Host
+		}
+		return keys[i].Peer < keys[j].Peer
+	})
+	return keys
+}
+
+// PeerLen returns the number of routes of a peer.
+func (r *RIB) PeerLen(key PeerKey) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.peers[key])
+}
+
+// Lookup returns the routes of all peers for exactly prefix.
+func (r *RIB) Lookup(prefix *net.IPNet) []Route {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	routes := []Route{}
+	if v, ok := r.tree.Get(prefix); ok {
+		routes = appendEntry(routes, v.(entry))
+	}
+	return routes
+}
+
+// LookupPeer returns the route of a peer for exactly prefix.
+func (r *RIB) LookupPeer(key PeerKey, prefix *net.IPNet) (Route, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if v, ok := r.tree.Get(prefix); ok {
+		if rt, ok := v.(entry)[key]; ok {
+			return *rt, true
+		}
+	}
+	return Route{}, false
+}
+
+// LongestMatch returns, for every peer, its most specific route covering
+// ip.
+func (r *RIB) LongestMatch(ip net.IP) []Route {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	host := &net.IPNet{IP: ip.To16(), Mask: net.CIDRMask(128, 128)}
+	if ip4 := ip.To4(); ip4 != nil {
+		host = &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}
+	}
+	best := entry{}
+	r.tree.Covering(host, func(_ *net.IPNet, v interface{}) bool {
+		for k, rt := range v.(entry) {
+			best[k] = rt
+		}
+		return true
+	})
+	return appendEntry([]Route{}, best)
+}
+
+// Covering returns the routes for prefix and all less specific prefixes.
+func (r *RIB) Covering(prefix *net.IPNet) []Route {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	routes := []Route{}
+	r.tree.Covering(prefix, func(_ *net.IPNet, v interface{}) bool {
+		routes = appendEntry(routes, v.(entry))
+		return true
+	})
+	return routes
+}
+
+// Covered returns the routes for prefix and all more specific prefixes.
+func (r *RIB) Covered(prefix *net.IPNet) []Route {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	routes := []Route{}
+	r.tree.Covered(prefix, func(_ *net.IPNet, v interface{}) bool {
+		routes = appendEntry(routes, v.(entry))
+		return true
+	})
+	return routes
+}
+
+// Walk calls fn for every route until fn returns false. The RIB must not
+// be modified from fn.
+func (r *RIB) Walk(fn func(Route) bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	r.tree.Walk(func(_ *net.IPNet, v interface{}) bool {
+		for _, rt := range sortedEntry(v.(entry)) {
+			if !fn(*rt) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func sortedEntry(e entry) []*Route {
+	routes := make([]*Route, 0, len(e))
+	for _, rt := range e {
+		routes = append(routes, rt)
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		a, b := routes[i].PeerKey, routes[j].PeerKey
+		if a.Host != b.Host {
+			return a.Host < b.Host
+		}
+		return a.Peer < b.Peer
+	})
+	return routes
+}
+
+func appendEntry(routes []Route, e entry) []Route {
+	for _, rt := range sortedEntry(e) {
+		routes = append(routes, *rt)
+	}
+	return routes
+}
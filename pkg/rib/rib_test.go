@@ -0,0 +1,110 @@
+package rib
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/stretchr/testify/assert"
+)
+
+func cidr(s string) *net.IPNet {
+	_, p, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func decode(t *testing.T, s string) *rislive.RisLiveMessage {
+	var m rislive.RisLiveMessage
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		t.Fatal(err)
+	}
+	return &m
+}
+
+const (
+	updateA = `{"type": "ris_message", "data": {"timestamp": 1562822233.68, "peer": "192.0.2.1", "peer_asn": "64500", "id": "a", "host": "rrc00", "type": "UPDATE",
+		"path": [64500, 64510], "origin": "igp",
+		"announcements": [{"next_hop": "192.0.2.1", "prefixes": ["10.0.0.0/8", "10.1.0.0/16"]}]}}`
+	updateB = `{"type": "ris_message", "data": {"timestamp": 1562822234, "peer": "192.0.2.2", "peer_asn": "64501", "id": "b", "host": "rrc01", "type": "UPDATE",
+		"path": [64501, 64511], "origin": "igp",
+		"announcements": [{"next_hop": "192.0.2.2", "prefixes": ["10.1.2.0/24", "10.0.0.0/8"]}]}}`
+	updateAImplicit = `{"type": "ris_message", "data": {"timestamp": 1562822235, "peer": "192.0.2.1", "peer_asn": "64500", "id": "c", "host": "rrc00", "type": "UPDATE",
+		"path": [64500, 64520, 64510], "origin": "igp",
+		"announcements": [{"next_hop": "192.0.2.1", "prefixes": ["10.0.0.0/8"]}],
+		"withdrawals": ["10.1.0.0/16"]}}`
+	peerDownB = `{"type": "ris_message", "data": {"timestamp": 1562822236, "peer": "192.0.2.2", "peer_asn": "64501", "id": "d", "host": "rrc01", "type": "RIS_PEER_STATE", "state": "down"}}`
+	openA     = `{"type": "ris_message", "data": {"timestamp": 1562822237, "peer": "192.0.2.1", "peer_asn": "64500", "id": "e", "host": "rrc00", "type": "OPEN", "direction": "received", "version": 4, "hold_time": 180, "router_id": "192.0.2.1"}}`
+)
+
+var (
+	peerA = PeerKey{Host: "rrc00", Peer: "192.0.2.1"}
+	peerB = PeerKey{Host: "rrc01", Peer: "192.0.2.2"}
+)
+
+func TestApply(t *testing.T) {
+	assert := assert.New(t)
+	r := New()
+	assert.NoError(r.Apply(decode(t, updateA)))
+	assert.NoError(r.Apply(decode(t, updateB)))
+	assert.Equal(4, r.Len())
+	assert.Equal(3, r.Prefixes())
+	assert.Equal([]PeerKey{peerA, peerB}, r.Peers())
+
+	routes := r.Lookup(cidr("10.0.0.0/8"))
+	assert.Len(routes, 2)
+	assert.Equal(peerA, routes[0].PeerKey)
+	assert.Equal("64500 64510", routes[0].Path.String())
+
+	lm := r.LongestMatch(net.ParseIP("10.1.2.3"))
+	assert.Len(lm, 2)
+	assert.Equal("10.1.0.0/16", lm[0].Prefix.String())
+	assert.Equal("10.1.2.0/24", lm[1].Prefix.String())
+
+	assert.Len(r.Covered(cidr("10.1.0.0/16")), 2)
+	assert.Len(r.Covering(cidr("10.1.2.0/24")), 4)
+
+	assert.NoError(r.Apply(decode(t, updateAImplicit)))
+	rt, ok := r.LookupPeer(peerA, cidr("10.0.0.0/8"))
+	assert.True(ok)
+	assert.Equal("64500 64520 64510", rt.Path.String())
+	_, ok = r.LookupPeer(peerA, cidr("10.1.0.0/16"))
+	assert.False(ok)
+	assert.Equal(3, r.Len())
+
+	assert.NoError(r.Apply(decode(t, peerDownB)))
+	assert.Equal(1, r.Len())
+	assert.Equal(0, r.PeerLen(peerB))
+
+	assert.NoError(r.Apply(decode(t, openA)))
+	assert.Equal(0, r.Len())
+	assert.Equal(0, r.Prefixes())
+	assert.Empty(r.Peers())
+}
+
+func TestApplyIgnored(t *testing.T) {
+	assert := assert.New(t)
+	r := New()
+	assert.NoError(r.Apply(decode(t, updateA)))
+	assert.NoError(r.Apply(decode(t, `{"type": "ris_subscribe_ok", "data": {"subscription": {"host": "rrc00"}, "socketOptions": {"includeRaw": false}}}`)))
+	assert.NoError(r.Apply(decode(t, `{"type": "pong"}`)))
+	assert.Equal(2, r.Len())
+}
+
+func TestWalk(t *testing.T) {
+	assert := assert.New(t)
+	r := New()
+	assert.NoError(r.Apply(decode(t, updateA)))
+	assert.NoError(r.Apply(decode(t, updateB)))
+	prefixes := []string{}
+	r.Walk(func(rt Route) bool {
+		prefixes = append(prefixes, rt.Prefix.String())
+		return true
+	})
+	assert.Equal([]string{"10.0.0.0/8", "10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24"}, prefixes)
+	assert.True(r.Withdraw(peerB, cidr("10.1.2.0/24")))
+	assert.False(r.Withdraw(peerB, cidr("10.1.2.0/24")))
+}
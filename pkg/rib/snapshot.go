@@ -0,0 +1,375 @@
+package rib
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+)
+
+// Snapshots are gzip compressed. After the magic and version follow the
+// snapshot time, a table of peers and the routes, all integers encoded as
+// varints and strings as length-prefixed bytes. Times are nanoseconds since
+// the Unix epoch, with 0 standing for the zero time. Version 1 had no such
+// sentinel and is not read.
+const (
+	snapshotMagic   = "RISRIB"
+	snapshotVersion = 2
+)
+
+type peerEntry struct {
+	key     PeerKey
+	peerASN string
+}
+
+type snapshotWriter struct {
+	w   *bufio.Writer
+	buf [binary.MaxVarintLen64]byte
+	err error
+}
+
+func (s *snapshotWriter) uvarint(v uint64) {
+	if s.err != nil {
+		return
+	}
+	n := binary.PutUvarint(s.buf[:], v)
+	_, s.err = s.w.Write(s.buf[:n])
+}
+
+func (s *snapshotWriter) varint(v int64) {
+	if s.err != nil {
+		return
+	}
+	n := binary.PutVarint(s.buf[:], v)
+	_, s.err = s.w.Write(s.buf[:n])
+}
+
+func (s *snapshotWriter) time(t time.Time) {
+	if t.IsZero() {
+		s.varint(0)
+		return
+	}
+	s.varint(t.UnixNano())
+}
+
+func (s *snapshotWriter) bytes(b []byte) {
+	s.uvarint(uint64(len(b)))
+	if s.err != nil {
+		return
+	}
+	_, s.err = s.w.Write(b)
+}
+
+func (s *snapshotWriter) string(v string) {
+	s.bytes([]byte(v))
+}
+
+func (s *snapshotWriter) route(rt *Route, peer uint64) {
+	s.uvarint(peer)
+	ones, _ := rt.Prefix.Mask.Size()
+	ip := rt.Prefix.IP.To4()
+	if ip == nil || len(rt.Prefix.Mask) != net.IPv4len {
+		ip = rt.Prefix.IP.To16()
+	}
+	s.uvarint(uint64(len(ip)))
+	s.uvarint(uint64(ones))
+	s.bytes(ip[:(ones+7)/8])
+	s.string(rt.NextHop)
+	s.uvarint(uint64(len(rt.Path)))
+	for _, h := range rt.Path {
+		if h.IsSet() {
+			s.uvarint(uint64(len(h.Set)) + 1)
+			for _, asn := range h.Set {
+				s.uvarint(uint64(asn))
+			}
+		} else {
+			s.uvarint(0)
+			s.uvarint(uint64(h.ASN))
+		}
+	}
+	s.uvarint(uint64(len(rt.Communities)))
+	for _, c := range rt.Communities {
+		s.uvarint(uint64(len(c)))
+		for _, v := range c {
+			s.uvarint(uint64(v))
+		}
+	}
+	s.string(rt.Origin)
+	s.uvarint(uint64(rt.MED))
+	s.time(rt.Time)
+}
+
+// WriteSnapshot writes the RIB to w, recording t as the snapshot time.
+func (r *RIB) WriteSnapshot(w io.Writer, t time.Time) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	gz := gzip.NewWriter(w)
+	s := &snapshotWriter{w: bufio.NewWriter(gz)}
+	s.w.WriteString(snapshotMagic)
+	s.w.WriteByte(snapshotVersion)
+	s.time(t)
+
+	peers := map[peerEntry]uint64{}
+	table := []peerEntry{}
+	routes := []*Route{}
+	r.tree.Walk(func(_ *net.IPNet, v interface{}) bool {
+		for _, rt := range sortedEntry(v.(entry)) {
+			pe := peerEntry{key: rt.PeerKey, peerASN: rt.PeerASN}
+			if _, ok := peers[pe]; !ok {
+				peers[pe] = uint64(len(table))
+				table = append(table, pe)
+			}
+			routes = append(routes, rt)
+		}
+		return true
+	})
+
+	s.uvarint(uint64(len(table)))
+	for _, pe := range table {
+		s.string(pe.key.Host)
+		s.string(pe.key.Peer)
+		s.string(pe.peerASN)
+	}
+	s.uvarint(uint64(len(routes)))
+	for _, rt := range routes {
+		s.route(rt, peers[peerEntry{key: rt.PeerKey, peerASN: rt.PeerASN}])
+	}
+	if s.err != nil {
+		return s.err
+	}
+	if err := s.w.Flush(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+type snapshotReader struct {
+	r   *bufio.Reader
+	err error
+}
+
+func (s *snapshotReader) uvarint() uint64 {
+	if s.err != nil {
+		return 0
+	}
+	var v uint64
+	v, s.err = binary.ReadUvarint(s.r)
+	return v
+}
+
+func (s *snapshotReader) varint() int64 {
+	if s.err != nil {
+		return 0
+	}
+	var v int64
+	v, s.err = binary.ReadVarint(s.r)
+	return v
+}
+
+func (s *snapshotReader) time() time.Time {
+	v := s.varint()
+	if v == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, v).UTC()
+}
+
+// count reads a length and rejects values larger than max to avoid huge
+// allocations on corrupt input.
+func (s *snapshotReader) count(max uint64) int {
+	n := s.uvarint()
+	if s.err == nil && n > max {
+		s.err = fmt.Errorf("corrupt snapshot: length %d exceeds %d", n, max)
+	}
+	if s.err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+func (s *snapshotReader) bytes() []byte {
+	n := s.count(1 << 16)
+	if s.err != nil {
+		return nil
+	}
+	b := make([]byte, n)
+	_, s.err = io.ReadFull(s.r, b)
+	return b
+}
+
+func (s *snapshotReader) string() string {
+	return string(s.bytes())
+}
+
+func (s *snapshotReader) route(table []peerEntry) *Route {
+	idx := s.uvarint()
+	if s.err == nil && idx >= uint64(len(table)) {
+		s.err = fmt.Errorf("corrupt snapshot: peer index %d out of range", idx)
+	}
+	if s.err != nil {
+		return nil
+	}
+	pe := table[idx]
+	rt := &Route{PeerKey: pe.key, PeerASN: pe.peerASN}
+
+	family := s.uvarint()
+	ones := s.uvarint()
+	prefix := s.bytes()
+	if s.err != nil {
+		return nil
+	}
+	if (family != net.IPv4len && family != net.IPv6len) || ones > family*8 || uint64(len(prefix)) != (ones+7)/8 {
+		s.err = errors.New("corrupt snapshot: invalid prefix")
+		return nil
+	}
+	ip := make(net.IP, family)
+	copy(ip, prefix)
+	rt.Prefix = &net.IPNet{IP: ip, Mask: net.CIDRMask(int(ones), int(family)*8)}
+	rt.NextHop = s.string()
+
+	if n := s.count(1 << 12); n > 0 {
+		rt.Path = make(rislive.ASPath, n)
+		for i := range rt.Path {
+			if setLen := s.count(1<<12 + 1); setLen > 0 {
+				rt.Path[i].Set = make([]uint32, setLen-1)
+				for j := range rt.Path[i].Set {
+					rt.Path[i].Set[j] = uint32(s.uvarint())
+				}
+			} else {
+				rt.Path[i].ASN = uint32(s.uvarint())
+			}
+		}
+	}
+	if n := s.count(1 << 12); n > 0 {
+		rt.Communities = make([][]uint16, n)
+		for i := range rt.Communities {
+			rt.Communities[i] = make([]uint16, s.count(1<<4))
+			for j := range rt.Communities[i] {
+				rt.Communities[i][j] = uint16(s.uvarint())
+			}
+		}
+	}
+	rt.Origin = s.string()
+	rt.MED = uint32(s.uvarint())
+	rt.Time = s.time()
+	return rt
+}
+
+// ReadSnapshot restores a RIB written by WriteSnapshot and returns it with
+// the snapshot time.
+func ReadSnapshot(rd io.Reader) (*RIB, time.Time, error) {
+	gz, err := gzip.NewReader(rd)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer gz.Close()
+	s := &snapshotReader{r: bufio.NewReader(gz)}
+
+	header := make([]byte, len(snapshotMagic)+1)
+	if _, err := io.ReadFull(s.r, header); err != nil {
+		return nil, time.Time{}, err
+	}
+	if string(header[:len(snapshotMagic)]) != snapshotMagic {
+		return nil, time.Time{}, errors.New("not a RIB snapshot")
+	}
+	if v := header[len(snapshotMagic)]; v != snapshotVersion {
+		return nil, time.Time{}, fmt.Errorf("unsupported snapshot version: %d", v)
+	}
+	t := s.time()
+
+	table := make([]peerEntry, s.count(1<<20))
+	for i := range table {
+		table[i].key.Host = s.string()
+		table[i].key.Peer = s.string()
+		table[i].peerASN = s.string()
+	}
+	r := New()
+	n := s.uvarint()
+	for i := uint64(0); i < n && s.err == nil; i++ {
+		if rt := s.route(table); rt != nil {
+			r.insert(rt)
+		}
+	}
+	if s.err != nil {
+		if s.err == io.EOF {
+			s.err = io.ErrUnexpectedEOF
+		}
+		return nil, time.Time{}, s.err
+	}
+	return r, t, nil
+}
+
+// SaveSnapshot atomically writes a snapshot taken now to path. The data is
+// synced before the rename, so that a crash leaves either the previous
+// snapshot or the new one.
+func (r *RIB) SaveSnapshot(path string) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	err = r.WriteSnapshot(f, time.Now())
+	if err == nil {
+		err = f.Sync()
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// LoadSnapshot reads the snapshot at path.
+func LoadSnapshot(path string) (*RIB, time.Time, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer f.Close()
+	return ReadSnapshot(f)
+}
+
+// SnapshotEvery saves a snapshot to path at every interval in the
+// background until the returned function is called. Failures are passed to
+// errFn when it is not nil.
+func (r *RIB) SnapshotEvery(path string, interval time.Duration, errFn func(error)) (stop func()) {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-t.C:
+				if err := r.SaveSnapshot(path); err != nil && errFn != nil {
+					errFn(err)
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+		})
+	}
+}
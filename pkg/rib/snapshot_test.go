@@ -0,0 +1,117 @@
+package rib
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const updateV6 = `{"type": "ris_message", "data": {"timestamp": 1562822238.5, "peer": "2001:db8::1", "peer_asn": "64502", "id": "f", "host": "rrc00", "type": "UPDATE",
+	"path": [64502, [64530, 64531]], "community": [[64502, 100]], "med": 10, "origin": "incomplete",
+	"announcements": [{"next_hop": "2001:db8::1", "prefixes": ["2001:db8:100::/40"]}]}}`
+
+func routes(r *RIB) []Route {
+	rs := []Route{}
+	r.Walk(func(rt Route) bool {
+		rs = append(rs, rt)
+		return true
+	})
+	return rs
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+	r := New()
+	assert.NoError(r.Apply(decode(t, updateA)))
+	assert.NoError(r.Apply(decode(t, updateB)))
+	assert.NoError(r.Apply(decode(t, updateV6)))
+
+	var buf bytes.Buffer
+	ts := time.Date(2019, 7, 11, 6, 0, 0, 0, time.UTC)
+	assert.NoError(r.WriteSnapshot(&buf, ts))
+
+	restored, rts, err := ReadSnapshot(&buf)
+	assert.NoError(err)
+	assert.Equal(ts, rts)
+	assert.Equal(r.Len(), restored.Len())
+	assert.Equal(routes(r), routes(restored))
+	assert.Len(restored.LongestMatch(net.ParseIP("2001:db8:100::1")), 1)
+}
+
+func TestSnapshotZeroTime(t *testing.T) {
+	assert := assert.New(t)
+	r := New()
+	r.insert(&Route{PeerKey: peerA, PeerASN: "64500", Prefix: cidr("10.0.0.0/8"), NextHop: "192.0.2.1"})
+
+	var buf bytes.Buffer
+	assert.NoError(r.WriteSnapshot(&buf, time.Time{}))
+	restored, rts, err := ReadSnapshot(&buf)
+	assert.NoError(err)
+	assert.True(rts.IsZero())
+	if rs := routes(restored); assert.Len(rs, 1) {
+		assert.True(rs[0].Time.IsZero())
+		assert.Equal(routes(r), rs)
+	}
+}
+
+func TestSnapshotCorrupt(t *testing.T) {
+	assert := assert.New(t)
+	r := New()
+	assert.NoError(r.Apply(decode(t, updateA)))
+	var buf bytes.Buffer
+	assert.NoError(r.WriteSnapshot(&buf, time.Now()))
+
+	_, _, err := ReadSnapshot(bytes.NewReader([]byte("not gzip")))
+	assert.Error(err)
+	b := buf.Bytes()
+	_, _, err = ReadSnapshot(bytes.NewReader(b[:len(b)/2]))
+	assert.Error(err)
+
+	// Version 1 snapshots encoded zero times differently.
+	gz, err := gzip.NewReader(bytes.NewReader(b))
+	assert.NoError(err)
+	raw, err := ioutil.ReadAll(gz)
+	assert.NoError(err)
+	raw[len(snapshotMagic)] = 1
+	var old bytes.Buffer
+	w := gzip.NewWriter(&old)
+	w.Write(raw)
+	w.Close()
+	_, _, err = ReadSnapshot(&old)
+	assert.EqualError(err, "unsupported snapshot version: 1")
+}
+
+func TestSnapshotFile(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "rib")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "rib.snap")
+
+	r := New()
+	assert.NoError(r.Apply(decode(t, updateA)))
+	errs := make(chan error, 1)
+	stop := r.SnapshotEvery(path, 10*time.Millisecond, func(err error) { errs <- err })
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(path); err == nil || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	stop()
+	stop()
+	assert.Len(errs, 0)
+
+	restored, ts, err := LoadSnapshot(path)
+	assert.NoError(err)
+	assert.Equal(2, restored.Len())
+	assert.WithinDuration(time.Now(), ts, time.Minute)
+}
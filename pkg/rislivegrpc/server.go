@@ -0,0 +1,171 @@
+// Package rislivegrpc implements the RisLive gRPC service of rislivepb,
+// streaming route events from an upstream RIS Live feed.
+package rislivegrpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/a16/go-rislive/pkg/rislivepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultMaxBuffer is the number of route events queued for a stream
+// before it is ended as a slow consumer.
+const DefaultMaxBuffer = 4096
+
+type Server struct {
+	rislivepb.UnimplementedRisLiveServer
+
+	MaxBuffer int
+	// Collectors returns the collectors for ListCollectors, typically
+	// client.Client.RrcList.
+	Collectors func() rislive.RisRrcList
+
+	mu      sync.Mutex
+	streams map[*stream]struct{}
+}
+
+func NewServer(collectors func() rislive.RisRrcList) *Server {
+	return &Server{
+		MaxBuffer:  DefaultMaxBuffer,
+		Collectors: collectors,
+		streams:    map[*stream]struct{}{},
+	}
+}
+
+// Streams returns the number of active Subscribe calls.
+func (s *Server) Streams() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.streams)
+}
+
+// HandleUpstream consumes a frame of the upstream connection. It has the
+// signature of client.Handler. The route events of UPDATEs are delivered to
+// the matching streams; everything else is ignored.
+func (s *Server) HandleUpstream(frame []byte, m *rislive.RisLiveMessage) {
+	if m == nil {
+		return
+	}
+	u, ok := m.AsUpdate()
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	streams := make([]*stream, 0, len(s.streams))
+	for st := range s.streams {
+		streams = append(streams, st)
+	}
+	s.mu.Unlock()
+
+	var events []*rislivepb.RouteEvent
+	for _, st := range streams {
+		if !st.filter.Match(m) {
+			continue
+		}
+		if events == nil {
+			for _, ev := range u.RouteEvents() {
+				events = append(events, rislivepb.FromRouteEvent(&ev))
+			}
+		}
+		for _, ev := range events {
+			if st.match(ev) {
+				st.enqueue(ev)
+			}
+		}
+	}
+}
+
+// Subscribe streams the route events matching the request until the
+// client goes away or falls behind.
+func (s *Server) Subscribe(req *rislivepb.FilterRequest, ss rislivepb.RisLive_SubscribeServer) error {
+	f := rislivepb.ToFilter(req)
+	if err := f.Validate(); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	size := s.MaxBuffer
+	if size <= 0 {
+		size = DefaultMaxBuffer
+	}
+	st := &stream{
+		filter: f,
+		send:   make(chan *rislivepb.RouteEvent, size),
+		done:   make(chan struct{}),
+	}
+	s.mu.Lock()
+	s.streams[st] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.streams, st)
+		s.mu.Unlock()
+	}()
+
+	ctx := ss.Context()
+	for {
+		select {
+		case ev := <-st.send:
+			if err := ss.Send(ev); err != nil {
+				return err
+			}
+		case <-st.done:
+			return status.Error(codes.ResourceExhausted,
+				fmt.Sprintf("behind by more than %d route events", size))
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// ListCollectors returns the collectors reported by Collectors.
+func (s *Server) ListCollectors(ctx context.Context, req *rislivepb.ListCollectorsRequest) (*rislivepb.ListCollectorsResponse, error) {
+	if s.Collectors == nil {
+		return nil, status.Error(codes.Unavailable, "collectors unknown")
+	}
+	rrcs := s.Collectors()
+	if rrcs == nil {
+		return nil, status.Error(codes.Unavailable, "collectors not received yet")
+	}
+	return &rislivepb.ListCollectorsResponse{Collectors: rrcs}, nil
+}
+
+type stream struct {
+	filter *rislive.Filter
+	send   chan *rislivepb.RouteEvent
+
+	once sync.Once
+	done chan struct{}
+}
+
+// match applies the per prefix parts of the filter, which Filter.Match
+// only checks for the message as a whole.
+func (st *stream) match(ev *rislivepb.RouteEvent) bool {
+	switch st.filter.Require {
+	case "announcements":
+		if ev.GetKind() != rislivepb.RouteEvent_ANNOUNCEMENT {
+			return false
+		}
+	case "withdrawals":
+		if ev.GetKind() != rislivepb.RouteEvent_WITHDRAWAL {
+			return false
+		}
+	}
+	// Only announcements carry the path a path filter applies to.
+	if st.filter.Path != "" && ev.GetKind() != rislivepb.RouteEvent_ANNOUNCEMENT {
+		return false
+	}
+	return st.filter.MatchPrefix(ev.GetPrefix())
+}
+
+func (st *stream) enqueue(ev *rislivepb.RouteEvent) {
+	select {
+	case <-st.done:
+	case st.send <- ev:
+	default:
+		st.once.Do(func() { close(st.done) })
+	}
+}
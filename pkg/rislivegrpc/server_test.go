@@ -0,0 +1,164 @@
+package rislivegrpc
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/a16/go-rislive/pkg/rislivepb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func start(t *testing.T, s *Server) (rislivepb.RisLiveClient, func()) {
+	lis := bufconn.Listen(1 << 20)
+	gs := grpc.NewServer()
+	rislivepb.RegisterRisLiveServer(gs, s)
+	go gs.Serve(lis)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rislivepb.NewRisLiveClient(conn), func() {
+		conn.Close()
+		gs.Stop()
+	}
+}
+
+func publish(t *testing.T, s *Server, msgs ...*rislive.RisLiveMessage) {
+	for _, m := range msgs {
+		frame, err := json.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.HandleUpstream(frame, m)
+	}
+}
+
+func waitStreams(t *testing.T, s *Server, n int) {
+	for i := 0; i < 500 && s.Streams() != n; i++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if s.Streams() != n {
+		t.Fatalf("%d streams, want %d", s.Streams(), n)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	assert := assert.New(t)
+	s := NewServer(nil)
+	c, stop := start(t, s)
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sub, err := c.Subscribe(ctx, &rislivepb.FilterRequest{Prefix: "198.51.100.0/22", MoreSpecific: true})
+	assert.NoError(err)
+	waitStreams(t, s, 1)
+
+	ts := time.Date(2019, 7, 11, 0, 0, 0, 0, time.UTC)
+	publish(t, s,
+		rislive.NewKeepalive().Host("rrc00").Peer("192.0.2.1", 64496).MustMessage(),
+		rislive.NewUpdate().Host("rrc00").Peer("192.0.2.1", 64496).Time(ts).Path(64496, 64500).
+			Community(64496, 100).Announce("192.0.2.1", "198.51.100.0/24", "203.0.113.0/24").
+			Withdraw("198.51.101.0/24").MustMessage(),
+	)
+
+	ev, err := sub.Recv()
+	assert.NoError(err)
+	assert.Equal(rislivepb.RouteEvent_WITHDRAWAL, ev.GetKind())
+	assert.Equal("198.51.101.0/24", ev.GetPrefix())
+
+	ev, err = sub.Recv()
+	assert.NoError(err)
+	got, err := rislivepb.ToRouteEvent(ev)
+	assert.NoError(err)
+	assert.Equal(&rislive.RouteEvent{
+		Kind:        rislive.RouteAnnouncement,
+		Prefix:      "198.51.100.0/24",
+		NextHop:     "192.0.2.1",
+		Path:        rislive.ASPath{{ASN: 64496}, {ASN: 64500}},
+		Communities: [][]uint16{{64496, 100}},
+		Peer:        "192.0.2.1",
+		PeerASN:     "64496",
+		Host:        "rrc00",
+		Time:        ts,
+	}, got)
+
+	cancel()
+	waitStreams(t, s, 0)
+}
+
+func TestSubscribeRequire(t *testing.T) {
+	assert := assert.New(t)
+	s := NewServer(nil)
+	c, stop := start(t, s)
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sub, err := c.Subscribe(ctx, &rislivepb.FilterRequest{Require: "announcements"})
+	assert.NoError(err)
+	waitStreams(t, s, 1)
+	publish(t, s, rislive.NewUpdate().Host("rrc00").Peer("192.0.2.1", 64496).Path(64496).
+		Announce("192.0.2.1", "198.51.100.0/24").Withdraw("203.0.113.0/24").MustMessage())
+	ev, err := sub.Recv()
+	assert.NoError(err)
+	assert.Equal("198.51.100.0/24", ev.GetPrefix())
+}
+
+func TestSubscribeErrors(t *testing.T) {
+	assert := assert.New(t)
+	s := NewServer(nil)
+	s.MaxBuffer = 1
+	c, stop := start(t, s)
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sub, err := c.Subscribe(ctx, &rislivepb.FilterRequest{Prefix: "bogus"})
+	assert.NoError(err)
+	_, err = sub.Recv()
+	assert.Equal(codes.InvalidArgument, status.Code(err))
+
+	sub, err = c.Subscribe(ctx, &rislivepb.FilterRequest{})
+	assert.NoError(err)
+	waitStreams(t, s, 1)
+	prefixes := make([]string, 100)
+	for i := range prefixes {
+		prefixes[i] = "10.0.0.0/8"
+	}
+	publish(t, s, rislive.NewUpdate().Host("rrc00").Peer("192.0.2.1", 64496).Path(64496).
+		Announce("192.0.2.1", prefixes...).MustMessage())
+	for err == nil {
+		_, err = sub.Recv()
+	}
+	assert.Equal(codes.ResourceExhausted, status.Code(err))
+}
+
+func TestListCollectors(t *testing.T) {
+	assert := assert.New(t)
+	var rrcs rislive.RisRrcList
+	s := NewServer(func() rislive.RisRrcList { return rrcs })
+	c, stop := start(t, s)
+	defer stop()
+
+	_, err := c.ListCollectors(context.Background(), &rislivepb.ListCollectorsRequest{})
+	assert.Equal(codes.Unavailable, status.Code(err))
+
+	rrcs = rislive.RisRrcList{"rrc00", "rrc13"}
+	resp, err := c.ListCollectors(context.Background(), &rislivepb.ListCollectorsRequest{})
+	assert.NoError(err)
+	assert.Equal([]string{"rrc00", "rrc13"}, resp.GetCollectors())
+}
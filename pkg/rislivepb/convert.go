@@ -0,0 +1,396 @@
+// Package rislivepb defines a protobuf schema for decoded RIS Live messages
+// and converts between it and the types of the rislive package.
+package rislivepb
+
+//go:generate buf generate
+
+import (
+	"encoding/json"
+	"fmt"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// FromRisLive converts a decoded message into its protobuf form.
+func FromRisLive(m *rislive.RisLiveMessage) (*Message, error) {
+	switch m.Type {
+	case "ris_message":
+		if u, ok := m.AsUpdate(); ok {
+			pu, err := FromUpdate(u)
+			if err != nil {
+				return nil, err
+			}
+			return &Message{Payload: &Message_Update{Update: pu}}, nil
+		}
+		if o, ok := m.AsOpen(); ok {
+			po, err := FromOpen(o)
+			if err != nil {
+				return nil, err
+			}
+			return &Message{Payload: &Message_Open{Open: po}}, nil
+		}
+		if n, ok := m.AsNotification(); ok {
+			return &Message{Payload: &Message_Notification{Notification: FromNotification(n)}}, nil
+		}
+		if k, ok := m.AsKeepalive(); ok {
+			return &Message{Payload: &Message_Keepalive{Keepalive: &Keepalive{Common: fromCommon(&k.RisMessageCommon)}}}, nil
+		}
+		if s, ok := m.AsPeerState(); ok {
+			return &Message{Payload: &Message_PeerState{PeerState: FromPeerState(s)}}, nil
+		}
+		return nil, fmt.Errorf("unsupported ris_message data: %s", m.BgpMsgType)
+	case "ris_error":
+		e, ok := m.AsError()
+		if !ok {
+			return nil, fmt.Errorf("unexpected ris_error data: %T", m.Data)
+		}
+		return &Message{Payload: &Message_Error{Error: FromError(e)}}, nil
+	case "ris_rrc_list":
+		l, ok := m.AsRrcList()
+		if !ok {
+			return nil, fmt.Errorf("unexpected ris_rrc_list data: %T", m.Data)
+		}
+		return &Message{Payload: &Message_RrcList{RrcList: &RrcList{Rrcs: l}}}, nil
+	case "pong":
+		return &Message{Payload: &Message_Pong{Pong: &Pong{}}}, nil
+	}
+	return nil, fmt.Errorf("unsupported type: %s", m.Type)
+}
+
+// ToRisLive converts a protobuf message back into the form produced by the
+// JSON decoder.
+func ToRisLive(p *Message) (*rislive.RisLiveMessage, error) {
+	switch pl := p.GetPayload().(type) {
+	case *Message_Update:
+		u, err := ToUpdate(pl.Update)
+		if err != nil {
+			return nil, err
+		}
+		return risMessage(&u.RisMessageCommon, "", u), nil
+	case *Message_Open:
+		o := ToOpen(pl.Open)
+		return risMessage(&o.RisMessageCommon, "", o), nil
+	case *Message_Notification:
+		n := ToNotification(pl.Notification)
+		return risMessage(&n.RisMessageCommon, "", n), nil
+	case *Message_Keepalive:
+		k := &rislive.RisMessageKeepalive{RisMessageCommon: toCommon(pl.Keepalive.GetCommon())}
+		return risMessage(&k.RisMessageCommon, "", k), nil
+	case *Message_PeerState:
+		s := ToPeerState(pl.PeerState)
+		return risMessage(&s.RisMessageCommon, s.State, s), nil
+	case *Message_Error:
+		return &rislive.RisLiveMessage{Type: "ris_error", Data: ToError(pl.Error)}, nil
+	case *Message_RrcList:
+		return &rislive.RisLiveMessage{Type: "ris_rrc_list", Data: rislive.RisRrcList(pl.RrcList.GetRrcs())}, nil
+	case *Message_Pong:
+		return &rislive.RisLiveMessage{Type: "pong"}, nil
+	}
+	return nil, fmt.Errorf("unsupported payload: %T", p.GetPayload())
+}
+
+func risMessage(c *rislive.RisMessageCommon, state string, data rislive.RisLiveMessageInterface) *rislive.RisLiveMessage {
+	return &rislive.RisLiveMessage{
+		Type:       "ris_message",
+		BgpMsgType: c.Type,
+		Timestamp:  c.Timestamp,
+		Peer:       c.Peer,
+		PeerASN:    c.PeerASN,
+		ID:         c.ID,
+		Host:       c.Host,
+		Raw:        c.Raw,
+		State:      state,
+		Data:       data,
+	}
+}
+
+func fromCommon(c *rislive.RisMessageCommon) *Common {
+	return &Common{
+		Type:      c.Type,
+		Timestamp: c.Timestamp,
+		Peer:      c.Peer,
+		PeerAsn:   c.PeerASN,
+		Id:        c.ID,
+		Host:      c.Host,
+		Raw:       c.Raw,
+	}
+}
+
+func toCommon(c *Common) rislive.RisMessageCommon {
+	return rislive.RisMessageCommon{
+		Type:      c.GetType(),
+		Timestamp: c.GetTimestamp(),
+		Peer:      c.GetPeer(),
+		PeerASN:   c.GetPeerAsn(),
+		ID:        c.GetId(),
+		Host:      c.GetHost(),
+		Raw:       c.GetRaw(),
+	}
+}
+
+// FromUpdate converts an UPDATE. It fails when the path holds elements
+// that are neither ASNs nor AS_SETs.
+func FromUpdate(u *rislive.RisMessageUpdate) (*Update, error) {
+	path, err := rislive.ParseASPath(u.Path)
+	if err != nil {
+		return nil, err
+	}
+	p := &Update{
+		Common:      fromCommon(&u.RisMessageCommon),
+		Path:        fromPath(path),
+		Communities: fromCommunities(u.Communities),
+		Origin:      u.Origin,
+		Med:         u.MED,
+		Withdrawals: u.Withdrawals,
+	}
+	for _, a := range u.Announcements {
+		p.Announcements = append(p.Announcements, &Announcement{NextHop: a.NextHop, Prefixes: a.Prefixes})
+	}
+	return p, nil
+}
+
+// ToUpdate converts an UPDATE back.
+func ToUpdate(p *Update) (*rislive.RisMessageUpdate, error) {
+	u := &rislive.RisMessageUpdate{
+		RisMessageCommon: toCommon(p.GetCommon()),
+		Origin:           p.GetOrigin(),
+		MED:              p.GetMed(),
+		Withdrawals:      p.GetWithdrawals(),
+	}
+	path, err := toPath(p.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range path {
+		var v interface{} = h.ASN
+		if h.IsSet() {
+			v = h.Set
+		}
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		u.Path = append(u.Path, raw)
+	}
+	if u.Communities, err = toCommunities(p.GetCommunities()); err != nil {
+		return nil, err
+	}
+	for _, a := range p.GetAnnouncements() {
+		u.Announcements = append(u.Announcements, rislive.Announcement{NextHop: a.GetNextHop(), Prefixes: a.GetPrefixes()})
+	}
+	return u, nil
+}
+
+func fromPath(path rislive.ASPath) []*PathElement {
+	var p []*PathElement
+	for _, h := range path {
+		if h.IsSet() {
+			p = append(p, &PathElement{Element: &PathElement_Set{Set: &AsSet{Asns: h.Set}}})
+		} else {
+			p = append(p, &PathElement{Element: &PathElement_Asn{Asn: h.ASN}})
+		}
+	}
+	return p
+}
+
+func toPath(p []*PathElement) (rislive.ASPath, error) {
+	var path rislive.ASPath
+	for _, e := range p {
+		switch el := e.GetElement().(type) {
+		case *PathElement_Asn:
+			path = append(path, rislive.PathHop{ASN: el.Asn})
+		case *PathElement_Set:
+			asns := el.Set.GetAsns()
+			if asns == nil {
+				asns = []uint32{}
+			}
+			path = append(path, rislive.PathHop{Set: asns})
+		default:
+			return nil, fmt.Errorf("empty path element")
+		}
+	}
+	return path, nil
+}
+
+func fromCommunities(communities [][]uint16) []*Community {
+	var p []*Community
+	for _, c := range communities {
+		values := make([]uint32, len(c))
+		for i, v := range c {
+			values[i] = uint32(v)
+		}
+		p = append(p, &Community{Values: values})
+	}
+	return p
+}
+
+func toCommunities(p []*Community) ([][]uint16, error) {
+	var communities [][]uint16
+	for _, c := range p {
+		values := make([]uint16, len(c.GetValues()))
+		for i, v := range c.GetValues() {
+			if v > 0xffff {
+				return nil, fmt.Errorf("community value out of range: %d", v)
+			}
+			values[i] = uint16(v)
+		}
+		communities = append(communities, values)
+	}
+	return communities, nil
+}
+
+// FromOpen converts an OPEN. Capabilities are carried as protobuf Values,
+// which hold everything the JSON decoder produces for them.
+func FromOpen(o *rislive.RisMessageOpen) (*Open, error) {
+	p := &Open{
+		Common:    fromCommon(&o.RisMessageCommon),
+		Direction: o.Direction,
+		RouterId:  o.RouterID,
+		Version:   int32(o.Version),
+		Asn:       o.ASN,
+		HoldTime:  int32(o.HoldTime),
+	}
+	if len(o.Capabilities) > 0 {
+		p.Capabilities = make(map[string]*structpb.Value, len(o.Capabilities))
+		for name, c := range o.Capabilities {
+			v, err := structpb.NewValue(c)
+			if err != nil {
+				return nil, fmt.Errorf("capability %s: %v", name, err)
+			}
+			p.Capabilities[name] = v
+		}
+	}
+	return p, nil
+}
+
+// ToOpen converts an OPEN back.
+func ToOpen(p *Open) *rislive.RisMessageOpen {
+	o := &rislive.RisMessageOpen{
+		RisMessageCommon: toCommon(p.GetCommon()),
+		Direction:        p.GetDirection(),
+		RouterID:         p.GetRouterId(),
+		Version:          int(p.GetVersion()),
+		ASN:              p.GetAsn(),
+		HoldTime:         int(p.GetHoldTime()),
+	}
+	if len(p.GetCapabilities()) > 0 {
+		o.Capabilities = make(map[string]rislive.CapabilityInterface, len(p.GetCapabilities()))
+		for name, v := range p.GetCapabilities() {
+			o.Capabilities[name] = v.AsInterface()
+		}
+	}
+	return o
+}
+
+// FromNotification converts a NOTIFICATION.
+func FromNotification(n *rislive.RisMessageNotification) *Notification {
+	return &Notification{
+		Common:  fromCommon(&n.RisMessageCommon),
+		Code:    uint32(n.Notification.Code),
+		Subcode: uint32(n.Notification.Subcode),
+		Data:    n.Notification.Data,
+	}
+}
+
+// ToNotification converts a NOTIFICATION back.
+func ToNotification(p *Notification) *rislive.RisMessageNotification {
+	n := &rislive.RisMessageNotification{RisMessageCommon: toCommon(p.GetCommon())}
+	n.Notification.Code = uint8(p.GetCode())
+	n.Notification.Subcode = uint8(p.GetSubcode())
+	n.Notification.Data = p.GetData()
+	return n
+}
+
+// FromPeerState converts a RIS_PEER_STATE.
+func FromPeerState(s *rislive.RisMessageRisPeerState) *PeerState {
+	return &PeerState{Common: fromCommon(&s.RisMessageCommon), State: s.State}
+}
+
+// ToPeerState converts a RIS_PEER_STATE back.
+func ToPeerState(p *PeerState) *rislive.RisMessageRisPeerState {
+	return &rislive.RisMessageRisPeerState{RisMessageCommon: toCommon(p.GetCommon()), State: p.GetState()}
+}
+
+// FromError converts a ris_error.
+func FromError(e *rislive.RisError) *RisError {
+	return &RisError{Message: e.Message, BufferSize: e.BufferSize, CommandType: e.CommandType}
+}
+
+// ToError converts a ris_error back.
+func ToError(p *RisError) *rislive.RisError {
+	return &rislive.RisError{Message: p.GetMessage(), BufferSize: p.GetBufferSize(), CommandType: p.GetCommandType()}
+}
+
+// FromRouteEvent converts a route event.
+func FromRouteEvent(ev *rislive.RouteEvent) *RouteEvent {
+	kind := RouteEvent_ANNOUNCEMENT
+	if ev.Kind == rislive.RouteWithdrawal {
+		kind = RouteEvent_WITHDRAWAL
+	}
+	return &RouteEvent{
+		Kind:        kind,
+		Prefix:      ev.Prefix,
+		NextHop:     ev.NextHop,
+		Path:        fromPath(ev.Path),
+		Communities: fromCommunities(ev.Communities),
+		Peer:        ev.Peer,
+		PeerAsn:     ev.PeerASN,
+		Host:        ev.Host,
+		Time:        timestamppb.New(ev.Time),
+	}
+}
+
+// ToRouteEvent converts a route event back.
+func ToRouteEvent(p *RouteEvent) (*rislive.RouteEvent, error) {
+	path, err := toPath(p.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	communities, err := toCommunities(p.GetCommunities())
+	if err != nil {
+		return nil, err
+	}
+	kind := rislive.RouteAnnouncement
+	if p.GetKind() == RouteEvent_WITHDRAWAL {
+		kind = rislive.RouteWithdrawal
+	}
+	return &rislive.RouteEvent{
+		Kind:        kind,
+		Prefix:      p.GetPrefix(),
+		NextHop:     p.GetNextHop(),
+		Path:        path,
+		Communities: communities,
+		Peer:        p.GetPeer(),
+		PeerASN:     p.GetPeerAsn(),
+		Host:        p.GetHost(),
+		Time:        p.GetTime().AsTime(),
+	}, nil
+}
+
+// ToFilter converts a FilterRequest into a subscription filter.
+func ToFilter(p *FilterRequest) *rislive.Filter {
+	f := rislive.NewFilter()
+	f.SetHost(p.GetHost())
+	f.SetType("UPDATE")
+	f.SetRequire(p.GetRequire())
+	f.SetPeer(p.GetPeer())
+	f.SetPath(p.GetPath())
+	f.SetPrefix(p.GetPrefix(), p.GetMoreSpecific(), p.GetLessSpecific())
+	return f
+}
+
+// FromFilter converts a subscription filter into a FilterRequest. The
+// message type and socket options have no counterpart and are dropped.
+func FromFilter(f *rislive.Filter) *FilterRequest {
+	return &FilterRequest{
+		Host:         f.Host,
+		Peer:         f.Peer,
+		Path:         f.Path,
+		Prefix:       f.Prefix,
+		MoreSpecific: f.MoreSpecific,
+		LessSpecific: f.LessSpecific,
+		Require:      f.Require,
+	}
+}
@@ -0,0 +1,90 @@
+package rislivepb
+
+import (
+	"encoding/json"
+	"testing"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		Description string
+		Msg         []byte
+	}{
+		{
+			Description: "update",
+			Msg: rislive.NewUpdate().Host("rrc13").Peer("195.208.208.147", 28917).
+				Raw("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF001304").Path(28917, 3257).PathSet(64512, 64513).
+				Community(3257, 4000).Origin("igp").MED(10).
+				Announce("195.208.208.147", "177.23.116.0/24", "177.23.117.0/24").
+				Announce("2001:7f8:20::147,fe80::1", "2a00:1450::/32").
+				Withdraw("10.0.0.0/8").MustJSON(),
+		},
+		{
+			Description: "open",
+			Msg:         []byte(`{"type":"ris_message","data":{"timestamp":1568279796.31,"peer":"192.0.2.1","peer_asn":"65530","id":"21-192-0-2-1-1","host":"rrc21","type":"OPEN","direction":"sent","version":4,"asn":65530,"hold_time":180,"router_id":"192.0.2.1","capabilities":{"1":{"name":"multiprotocol","families":["ipv4/unicast","ipv6/unicast"]},"65":{"name":"asn4","asn":65530}}}}`),
+		},
+		{
+			Description: "notification",
+			Msg:         rislive.NewNotification(6, 5).Host("rrc00").Peer("192.0.2.2", 64496).NotificationData("0605").MustJSON(),
+		},
+		{
+			Description: "keepalive",
+			Msg:         rislive.NewKeepalive().Host("rrc01").Peer("195.66.224.31", 32787).MustJSON(),
+		},
+		{
+			Description: "peer state",
+			Msg:         rislive.NewPeerState("down").Host("rrc01").Peer("195.66.224.31", 32787).MustJSON(),
+		},
+		{
+			Description: "ris_error",
+			Msg:         []byte(`{"type":"ris_error","data":{"message":"Buffer full","bufferSize":1000,"command_type":"ris_subscribe"}}`),
+		},
+		{
+			Description: "ris_rrc_list",
+			Msg:         []byte(`{"type":"ris_rrc_list","data":["rrc00","rrc01"]}`),
+		},
+		{
+			Description: "pong",
+			Msg:         []byte(`{"type":"pong"}`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.Description, func(t *testing.T) {
+			assert := assert.New(t)
+			var m rislive.RisLiveMessage
+			assert.NoError(json.Unmarshal(tt.Msg, &m))
+
+			p, err := FromRisLive(&m)
+			assert.NoError(err)
+			b, err := proto.Marshal(p)
+			assert.NoError(err)
+			var decoded Message
+			assert.NoError(proto.Unmarshal(b, &decoded))
+
+			back, err := ToRisLive(&decoded)
+			assert.NoError(err)
+			assert.Equal(m.BgpMsgType, back.BgpMsgType)
+			assert.Equal(m.State, back.State)
+			j, err := json.Marshal(back)
+			assert.NoError(err)
+			assert.JSONEq(string(tt.Msg), string(j))
+		})
+	}
+}
+
+func TestCommunityOutOfRange(t *testing.T) {
+	p := &Update{Common: &Common{Type: "UPDATE"}, Communities: []*Community{{Values: []uint32{65536, 1}}}}
+	_, err := ToUpdate(p)
+	assert.Error(t, err)
+}
+
+func TestUnsupported(t *testing.T) {
+	_, err := FromRisLive(rislive.NewRisPing())
+	assert.Error(t, err)
+	_, err = ToRisLive(&Message{})
+	assert.Error(t, err)
+}
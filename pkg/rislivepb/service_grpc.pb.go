@@ -0,0 +1,171 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: service.proto
+
+package rislivepb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	RisLive_Subscribe_FullMethodName      = "/rislive.v1.RisLive/Subscribe"
+	RisLive_ListCollectors_FullMethodName = "/rislive.v1.RisLive/ListCollectors"
+)
+
+// RisLiveClient is the client API for RisLive service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// RisLive streams decoded RIS Live data.
+type RisLiveClient interface {
+	// Subscribe streams the route events of the UPDATEs matching the filter.
+	Subscribe(ctx context.Context, in *FilterRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RouteEvent], error)
+	// ListCollectors returns the collectors known to RIS Live.
+	ListCollectors(ctx context.Context, in *ListCollectorsRequest, opts ...grpc.CallOption) (*ListCollectorsResponse, error)
+}
+
+type risLiveClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRisLiveClient(cc grpc.ClientConnInterface) RisLiveClient {
+	return &risLiveClient{cc}
+}
+
+func (c *risLiveClient) Subscribe(ctx context.Context, in *FilterRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RouteEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &RisLive_ServiceDesc.Streams[0], RisLive_Subscribe_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[FilterRequest, RouteEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RisLive_SubscribeClient = grpc.ServerStreamingClient[RouteEvent]
+
+func (c *risLiveClient) ListCollectors(ctx context.Context, in *ListCollectorsRequest, opts ...grpc.CallOption) (*ListCollectorsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListCollectorsResponse)
+	err := c.cc.Invoke(ctx, RisLive_ListCollectors_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RisLiveServer is the server API for RisLive service.
+// All implementations must embed UnimplementedRisLiveServer
+// for forward compatibility.
+//
+// RisLive streams decoded RIS Live data.
+type RisLiveServer interface {
+	// Subscribe streams the route events of the UPDATEs matching the filter.
+	Subscribe(*FilterRequest, grpc.ServerStreamingServer[RouteEvent]) error
+	// ListCollectors returns the collectors known to RIS Live.
+	ListCollectors(context.Context, *ListCollectorsRequest) (*ListCollectorsResponse, error)
+	mustEmbedUnimplementedRisLiveServer()
+}
+
+// UnimplementedRisLiveServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRisLiveServer struct{}
+
+func (UnimplementedRisLiveServer) Subscribe(*FilterRequest, grpc.ServerStreamingServer[RouteEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (UnimplementedRisLiveServer) ListCollectors(context.Context, *ListCollectorsRequest) (*ListCollectorsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCollectors not implemented")
+}
+func (UnimplementedRisLiveServer) mustEmbedUnimplementedRisLiveServer() {}
+func (UnimplementedRisLiveServer) testEmbeddedByValue()                 {}
+
+// UnsafeRisLiveServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RisLiveServer will
+// result in compilation errors.
+type UnsafeRisLiveServer interface {
+	mustEmbedUnimplementedRisLiveServer()
+}
+
+func RegisterRisLiveServer(s grpc.ServiceRegistrar, srv RisLiveServer) {
+	// If the following call pancis, it indicates UnimplementedRisLiveServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&RisLive_ServiceDesc, srv)
+}
+
+func _RisLive_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(FilterRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RisLiveServer).Subscribe(m, &grpc.GenericServerStream[FilterRequest, RouteEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RisLive_SubscribeServer = grpc.ServerStreamingServer[RouteEvent]
+
+func _RisLive_ListCollectors_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCollectorsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RisLiveServer).ListCollectors(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RisLive_ListCollectors_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RisLiveServer).ListCollectors(ctx, req.(*ListCollectorsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// RisLive_ServiceDesc is the grpc.ServiceDesc for RisLive service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RisLive_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "rislive.v1.RisLive",
+	HandlerType: (*RisLiveServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListCollectors",
+			Handler:    _RisLive_ListCollectors_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       _RisLive_Subscribe_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "service.proto",
+}
@@ -0,0 +1,215 @@
+// Package rislivetest provides an in-process RIS Live server for testing
+// consumers without connecting to ris-live.ripe.net.
+//
+// The server speaks the WebSocket protocol on /v1/ws/ and streams JSON
+// lines on /v1/stream/. Messages are supplied by the test with Publish or
+// Replay and delivered to the clients whose subscriptions match them.
+package rislivetest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/a16/go-rislive/internal/fanout"
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/a16/go-rislive/pkg/replay"
+	"github.com/gorilla/websocket"
+)
+
+// DefaultMaxBuffer is the number of messages queued for a client before it
+// is disconnected as a slow consumer.
+const DefaultMaxBuffer = 1024
+
+type Server struct {
+	*httptest.Server
+
+	hub      *fanout.Hub
+	upgrader websocket.Upgrader
+
+	mu        sync.Mutex
+	maxBuffer int
+}
+
+// NewServer starts a server. It must be closed with Close.
+func NewServer() *Server {
+	s := &Server{
+		hub:       fanout.NewHub(),
+		maxBuffer: DefaultMaxBuffer,
+	}
+	s.hub.SetRrcList(rislive.RisRrcList{"rrc00", "rrc01"})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/ws/", s.serveWebSocket)
+	mux.HandleFunc("/v1/stream/", s.serveStream)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// WebSocketURL returns the URL of the WebSocket endpoint.
+func (s *Server) WebSocketURL() string {
+	return "ws" + strings.TrimPrefix(s.URL, "http") + "/v1/ws/"
+}
+
+// StreamURL returns the URL of the HTTP stream endpoint.
+func (s *Server) StreamURL() string {
+	return s.URL + "/v1/stream/"
+}
+
+// SetRrcList sets the collectors returned for request_rrc_list.
+func (s *Server) SetRrcList(rrcs ...string) {
+	s.hub.SetRrcList(rislive.RisRrcList(rrcs))
+}
+
+// SetMaxBuffer sets the queue length of clients connecting afterwards.
+func (s *Server) SetMaxBuffer(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxBuffer = n
+}
+
+// Close disconnects all clients and shuts the server down.
+func (s *Server) Close() {
+	s.DisconnectAll()
+	s.Server.Close()
+}
+
+// Clients returns the number of connected clients.
+func (s *Server) Clients() int {
+	return s.hub.Len()
+}
+
+// WaitForSubscribers waits until n clients have at least one subscription.
+func (s *Server) WaitForSubscribers(n int, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		count := 0
+		for _, c := range s.hub.Subscribers() {
+			if len(c.Subscriptions()) > 0 {
+				count++
+			}
+		}
+		if count >= n {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("rislivetest: %d of %d subscribers after %v", count, n, timeout)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// Publish delivers frames, each a JSON encoded RIS Live message, to the
+// clients whose subscriptions match. Frames other than ris_message are sent
+// to every client.
+func (s *Server) Publish(frames ...[]byte) error {
+	for _, frame := range frames {
+		var m rislive.RisLiveMessage
+		if err := json.Unmarshal(frame, &m); err != nil {
+			return err
+		}
+		if err := s.hub.Publish(frame, &m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PublishMessages is Publish for decoded or built messages.
+func (s *Server) PublishMessages(msgs ...*rislive.RisLiveMessage) error {
+	for _, m := range msgs {
+		frame, err := json.Marshal(m)
+		if err != nil {
+			return err
+		}
+		if err := s.Publish(frame); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Replay publishes the frames of a recording, paced according to speed as
+// in replay.Replayer.
+func (s *Server) Replay(ctx context.Context, r *replay.Reader, speed float64) error {
+	p := &replay.Replayer{Speed: speed}
+	return p.ReplayFrames(ctx, r, func(f *replay.Frame) error {
+		return s.Publish(f.Data)
+	})
+}
+
+// InjectError sends a ris_error to every client.
+func (s *Server) InjectError(message string) {
+	s.hub.Broadcast(fanout.MustMarshal(&rislive.RisLiveMessage{Type: "ris_error", Data: &rislive.RisError{Message: message}}))
+}
+
+// InjectSlowConsumer disconnects every client the way RIS Live drops
+// clients that fall behind.
+func (s *Server) InjectSlowConsumer() {
+	for _, c := range s.hub.Subscribers() {
+		c.CloseSlow()
+	}
+}
+
+// DisconnectAll closes every client connection without notice.
+func (s *Server) DisconnectAll() {
+	s.hub.CloseAll()
+}
+
+func (s *Server) addClient(filters ...*rislive.Filter) *fanout.Subscriber {
+	s.mu.Lock()
+	size := s.maxBuffer
+	s.mu.Unlock()
+	return s.hub.Add(size, filters...)
+}
+
+func (s *Server) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	c := s.addClient()
+	defer s.hub.Remove(c)
+	c.ServeWebSocket(conn, 0)
+	conn.Close()
+}
+
+func (s *Server) serveStream(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if format := q.Get("format"); format != "" && format != "json" {
+		http.Error(w, "unsupported format", http.StatusBadRequest)
+		return
+	}
+	f, err := rislive.FilterFromValues(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	flusher, _ := w.(http.Flusher)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if flusher != nil {
+		flusher.Flush()
+	}
+	c := s.addClient(f)
+	defer s.hub.Remove(c)
+	c.CloseOnDone(r.Context())
+	c.WriteLoop(func(frame []byte) error {
+		// The frame is shared with other clients and not appended to.
+		if _, err := w.Write(frame); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+}
@@ -0,0 +1,205 @@
+package rislivetest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/a16/go-rislive/pkg/replay"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	update = rislive.NewUpdate().Host("rrc13").Peer("195.208.208.147", 28917).
+		Raw("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF001304").Path(28917, 3257).
+		Announce("195.208.208.147", "177.23.116.0/24").MustJSON()
+	keepalive = rislive.NewKeepalive().Host("rrc01").Peer("195.66.224.31", 32787).MustJSON()
+)
+
+func dial(t *testing.T, s *Server) *websocket.Conn {
+	conn, _, err := websocket.DefaultDialer.Dial(s.WebSocketURL(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return conn
+}
+
+func read(t *testing.T, conn *websocket.Conn) *rislive.RisLiveMessage {
+	var m rislive.RisLiveMessage
+	_, p, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(p, &m); err != nil {
+		t.Fatal(err)
+	}
+	return &m
+}
+
+func decode(t *testing.T, frame []byte) *rislive.RisLiveMessage {
+	var m rislive.RisLiveMessage
+	if err := json.Unmarshal(frame, &m); err != nil {
+		t.Fatal(err)
+	}
+	return &m
+}
+
+func TestWebSocketCommands(t *testing.T) {
+	assert := assert.New(t)
+	s := NewServer()
+	defer s.Close()
+	s.SetRrcList("rrc00", "rrc13")
+
+	conn := dial(t, s)
+	defer conn.Close()
+
+	assert.NoError(conn.WriteJSON(rislive.NewRisRequestRrcList()))
+	rrcs, ok := read(t, conn).AsRrcList()
+	assert.True(ok)
+	assert.Equal(rislive.RisRrcList{"rrc00", "rrc13"}, rrcs)
+
+	assert.NoError(conn.WriteJSON(rislive.NewRisPing()))
+	assert.Equal("pong", read(t, conn).Type)
+
+	assert.NoError(conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"wrong"}`)))
+	e, ok := read(t, conn).AsError()
+	assert.True(ok)
+	assert.Equal("wrong", e.CommandType)
+
+	f := rislive.NewFilter()
+	f.SetPath("x")
+	assert.NoError(conn.WriteJSON(rislive.NewRisSubscribe(f)))
+	_, ok = read(t, conn).AsError()
+	assert.True(ok)
+}
+
+func TestSubscribeAndPublish(t *testing.T) {
+	assert := assert.New(t)
+	s := NewServer()
+	defer s.Close()
+
+	all := dial(t, s)
+	defer all.Close()
+	assert.NoError(all.WriteJSON(rislive.NewRisSubscribe(rislive.NewFilter())))
+
+	updates := dial(t, s)
+	defer updates.Close()
+	f := rislive.NewFilter()
+	f.SetType("UPDATE")
+	f.SetSocketOptions(true)
+	assert.NoError(updates.WriteJSON(rislive.NewRisSubscribe(f)))
+	assert.NoError(s.WaitForSubscribers(2, 5*time.Second))
+
+	assert.NoError(s.Publish(keepalive))
+	assert.NoError(s.PublishMessages(decode(t, update)))
+
+	m := read(t, all)
+	assert.Equal("KEEPALIVE", m.BgpMsgType)
+	m = read(t, all)
+	assert.Equal("UPDATE", m.BgpMsgType)
+	assert.Equal("", m.Raw)
+
+	m = read(t, updates)
+	assert.Equal("UPDATE", m.BgpMsgType)
+	assert.Equal("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF001304", m.Raw)
+
+	assert.NoError(updates.WriteJSON(rislive.NewRisUnsubscribe(f)))
+	assert.NoError(updates.WriteJSON(rislive.NewRisPing()))
+	assert.Equal("pong", read(t, updates).Type)
+	assert.NoError(s.Publish(update))
+	assert.NoError(updates.WriteJSON(rislive.NewRisPing()))
+	assert.Equal("pong", read(t, updates).Type)
+}
+
+func TestInjections(t *testing.T) {
+	assert := assert.New(t)
+	s := NewServer()
+	defer s.Close()
+
+	conn := dial(t, s)
+	defer conn.Close()
+	assert.NoError(conn.WriteJSON(rislive.NewRisSubscribe(rislive.NewFilter())))
+	assert.NoError(s.WaitForSubscribers(1, 5*time.Second))
+
+	s.InjectError("something went wrong")
+	e, ok := read(t, conn).AsError()
+	assert.True(ok)
+	assert.Equal("something went wrong", e.Message)
+
+	s.InjectSlowConsumer()
+	e, ok = read(t, conn).AsError()
+	assert.True(ok)
+	assert.Equal(uint64(DefaultMaxBuffer), e.BufferSize)
+	_, _, err := conn.ReadMessage()
+	assert.Error(err)
+
+	conn = dial(t, s)
+	defer conn.Close()
+	assert.NoError(conn.WriteJSON(rislive.NewRisPing()))
+	assert.Equal("pong", read(t, conn).Type)
+	s.DisconnectAll()
+	_, _, err = conn.ReadMessage()
+	assert.Error(err)
+}
+
+func TestSlowConsumerOverflow(t *testing.T) {
+	assert := assert.New(t)
+	s := NewServer()
+	defer s.Close()
+	s.SetMaxBuffer(1)
+
+	conn := dial(t, s)
+	defer conn.Close()
+	assert.NoError(conn.WriteJSON(rislive.NewRisSubscribe(rislive.NewFilter())))
+	assert.NoError(s.WaitForSubscribers(1, 5*time.Second))
+
+	// the client never reads, so the queue eventually overflows
+	for i := 0; i < 100000 && s.Clients() > 0; i++ {
+		assert.NoError(s.Publish(update))
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for s.Clients() > 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	assert.Equal(0, s.Clients())
+}
+
+func TestStream(t *testing.T) {
+	assert := assert.New(t)
+	s := NewServer()
+	defer s.Close()
+
+	resp, err := http.Get(s.StreamURL() + "?format=json&host=rrc13")
+	assert.NoError(err)
+	defer resp.Body.Close()
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	assert.NoError(s.WaitForSubscribers(1, 5*time.Second))
+
+	var buf bytes.Buffer
+	rec := replay.NewRecorder(&buf)
+	assert.NoError(rec.Record(time.Now(), keepalive))
+	assert.NoError(rec.Record(time.Now(), update))
+	assert.NoError(rec.Close())
+	r, err := replay.NewReader(&buf)
+	assert.NoError(err)
+	assert.NoError(s.Replay(context.Background(), r, 0))
+
+	scanner := bufio.NewScanner(resp.Body)
+	assert.True(scanner.Scan())
+	var m rislive.RisLiveMessage
+	assert.NoError(json.Unmarshal(scanner.Bytes(), &m))
+	assert.Equal("UPDATE", m.BgpMsgType)
+	assert.Equal("rrc13", m.Host)
+
+	resp, err = http.Get(s.StreamURL() + "?prefix=bad")
+	assert.NoError(err)
+	resp.Body.Close()
+	assert.Equal(http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,49 @@
+package rislivetest
+
+import (
+	"net"
+	"time"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/a16/go-rislive/pkg/rib"
+)
+
+// Update returns the UPDATE built by b, for consumers that take UPDATEs
+// directly. It panics if b builds another message type.
+func Update(b *rislive.Builder) *rislive.RisMessageUpdate {
+	u, ok := b.MustMessage().AsUpdate()
+	if !ok {
+		panic("rislivetest: builder does not build an UPDATE")
+	}
+	return u
+}
+
+// Announce returns an UPDATE from peer at t announcing prefixes with path.
+// The peer ASN is the first ASN of path.
+func Announce(peer rib.PeerKey, t time.Time, path []uint32, prefixes ...string) *rislive.RisMessageUpdate {
+	asn := uint32(64496)
+	if len(path) > 0 {
+		asn = path[0]
+	}
+	return Update(rislive.NewUpdate().Host(peer.Host).Peer(peer.Peer, asn).Time(t).
+		Path(path...).Announce(peer.Peer, prefixes...))
+}
+
+// Withdraw returns an UPDATE from peer at t withdrawing prefixes.
+func Withdraw(peer rib.PeerKey, t time.Time, prefixes ...string) *rislive.RisMessageUpdate {
+	return Update(rislive.NewUpdate().Host(peer.Host).Peer(peer.Peer, 64496).Time(t).Withdraw(prefixes...))
+}
+
+// PeerState returns the RIS_PEER_STATE message of peer at t.
+func PeerState(peer rib.PeerKey, t time.Time, state string) *rislive.RisLiveMessage {
+	return rislive.NewPeerState(state).Host(peer.Host).Peer(peer.Peer, 64496).Time(t).MustMessage()
+}
+
+// MustPrefix parses a prefix in CIDR notation and panics if it is invalid.
+func MustPrefix(s string) *net.IPNet {
+	_, p, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
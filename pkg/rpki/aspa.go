@@ -0,0 +1,258 @@
+package rpki
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+)
+
+// ASPA is the payload of an ASPA object: the set of provider ASNs
+// authorized by a customer AS.
+type ASPA struct {
+	Customer  uint32
+	Providers []uint32
+}
+
+// PathState is the result of ASPA path verification.
+type PathState int
+
+const (
+	PathUnknown PathState = iota
+	PathValid
+	PathInvalid
+)
+
+func (s PathState) String() string {
+	switch s {
+	case PathUnknown:
+		return "unknown"
+	case PathValid:
+		return "valid"
+	case PathInvalid:
+		return "invalid"
+	}
+	return "unknown"
+}
+
+// Direction tells which verification procedure applies to a path, from
+// the relationship with the neighbor the route was received from.
+type Direction int
+
+const (
+	// Upstream is for routes received from a customer or a lateral peer,
+	// whose paths must only go up from the origin.
+	Upstream Direction = iota
+	// Downstream is for routes received from a provider, whose paths may
+	// go up and then down.
+	Downstream
+)
+
+func (d Direction) String() string {
+	if d == Downstream {
+		return "downstream"
+	}
+	return "upstream"
+}
+
+// PathResult is the result of verifying an AS path.
+type PathResult struct {
+	State PathState
+	// Customer and Provider are the failing hop of an Invalid path: the
+	// ASPA of Customer does not authorize Provider. Both are zero when the
+	// path is Invalid because it contains an AS_SET. For a Downstream
+	// path they are the failing hop of the up-ramp.
+	Customer, Provider uint32
+	// DownCustomer and DownProvider are the failing hop of the down-ramp
+	// of an Invalid Downstream path.
+	DownCustomer, DownProvider uint32
+}
+
+func (r PathResult) String() string {
+	switch {
+	case r.State != PathInvalid || r.Customer == 0:
+		return r.State.String()
+	case r.DownCustomer != 0:
+		return fmt.Sprintf("%s (AS%d -> AS%d, AS%d -> AS%d)", r.State, r.Customer, r.Provider, r.DownCustomer, r.DownProvider)
+	}
+	return fmt.Sprintf("%s (AS%d -> AS%d)", r.State, r.Customer, r.Provider)
+}
+
+// ASPAVerifier verifies AS paths against ASPAs.
+type ASPAVerifier interface {
+	VerifyPath(path rislive.ASPath, dir Direction) PathResult
+}
+
+// ASPASet is a set of ASPAs indexed by customer. It is safe for concurrent
+// use.
+type ASPASet struct {
+	mu        sync.RWMutex
+	providers map[uint32][]uint32
+}
+
+// NewASPASet returns a set of aspas. The providers of ASPAs of the same
+// customer are merged.
+func NewASPASet(aspas []ASPA) *ASPASet {
+	s := &ASPASet{providers: map[uint32][]uint32{}}
+	for _, a := range aspas {
+		s.providers[a.Customer] = sortProviders(append(s.providers[a.Customer], a.Providers...))
+	}
+	return s
+}
+
+// sortProviders sorts list in place and removes duplicates.
+func sortProviders(list []uint32) []uint32 {
+	sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+	dedup := list[:0]
+	for i, asn := range list {
+		if i == 0 || asn != list[i-1] {
+			dedup = append(dedup, asn)
+		}
+	}
+	return dedup
+}
+
+// Apply removes the ASPAs of the withdrawn customers and installs the
+// announced ASPAs, each replacing the previous ASPA of its customer, as an
+// RTR serial update does. Verifications never see the set halfway through
+// the change.
+func (s *ASPASet) Apply(announced []ASPA, withdrawn []uint32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, customer := range withdrawn {
+		delete(s.providers, customer)
+	}
+	for _, a := range announced {
+		s.providers[a.Customer] = sortProviders(append([]uint32(nil), a.Providers...))
+	}
+}
+
+// Len returns the number of customers with an ASPA.
+func (s *ASPASet) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.providers)
+}
+
+// Providers returns the providers authorized by customer, and false when
+// it has no ASPA.
+func (s *ASPASet) Providers(customer uint32) ([]uint32, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	p, ok := s.providers[customer]
+	return p, ok
+}
+
+// ASPAs returns the ASPAs ordered by customer.
+func (s *ASPASet) ASPAs() []ASPA {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	aspas := make([]ASPA, 0, len(s.providers))
+	for customer, providers := range s.providers {
+		aspas = append(aspas, ASPA{Customer: customer, Providers: providers})
+	}
+	sort.Slice(aspas, func(i, j int) bool { return aspas[i].Customer < aspas[j].Customer })
+	return aspas
+}
+
+type hopCheck int
+
+const (
+	noAttestation hopCheck = iota
+	providerPlus
+	notProviderPlus
+)
+
+// hop tells whether provider is authorized by the ASPA of customer.
+func (s *ASPASet) hop(customer, provider uint32) hopCheck {
+	providers, ok := s.providers[customer]
+	if !ok {
+		return noAttestation
+	}
+	i := sort.Search(len(providers), func(i int) bool { return providers[i] >= provider })
+	if i < len(providers) && providers[i] == provider {
+		return providerPlus
+	}
+	return notProviderPlus
+}
+
+// VerifyPath implements ASPAVerifier with the upstream and downstream
+// procedures of draft-ietf-sidrops-aspa-verification. Prepends are
+// collapsed and paths with AS_SETs are Invalid. An empty path is Unknown.
+func (s *ASPASet) VerifyPath(path rislive.ASPath, dir Direction) PathResult {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	path = path.Dedup()
+	if len(path) == 0 {
+		return PathResult{State: PathUnknown}
+	}
+	// as holds the path from the origin towards the neighbor.
+	n := len(path)
+	as := make([]uint32, n)
+	for i, h := range path {
+		if h.IsSet() {
+			return PathResult{State: PathInvalid}
+		}
+		as[n-1-i] = h.ASN
+	}
+
+	// The up-ramp goes from the origin through customer to provider hops,
+	// the down-ramp from the neighbor. Their max lengths only cross
+	// attested hops, their min lengths stop at the first unauthorized hop.
+	ramp := func(hop func(k int) hopCheck, ok func(hopCheck) bool) int {
+		k := 1
+		for k < n && ok(hop(k)) {
+			k++
+		}
+		return k
+	}
+	attested := func(c hopCheck) bool { return c == providerPlus }
+	authorized := func(c hopCheck) bool { return c != notProviderPlus }
+	up := func(k int) hopCheck { return s.hop(as[k-1], as[k]) }
+	down := func(k int) hopCheck { return s.hop(as[n-k], as[n-k-1]) }
+	maxUp, minUp := ramp(up, attested), ramp(up, authorized)
+	invalid := PathResult{State: PathInvalid}
+	if minUp < n {
+		invalid.Customer, invalid.Provider = as[minUp-1], as[minUp]
+	}
+
+	if dir == Upstream {
+		switch {
+		case maxUp == n:
+			return PathResult{State: PathValid}
+		case minUp < n:
+			return invalid
+		}
+		return PathResult{State: PathUnknown}
+	}
+	maxDown, minDown := ramp(down, attested), ramp(down, authorized)
+	switch {
+	case maxUp+maxDown >= n:
+		return PathResult{State: PathValid}
+	case minUp+minDown < n:
+		// Both ramps stop short of n, so both have a failing hop.
+		invalid.DownCustomer, invalid.DownProvider = as[n-minDown], as[n-minDown-1]
+		return invalid
+	}
+	return PathResult{State: PathUnknown}
+}
+
+// PathAnnotation is the ASPA verification result of an announced route.
+type PathAnnotation struct {
+	rislive.RouteEvent
+	PathResult
+}
+
+// VerifyUpdate verifies the path of every announcement of an UPDATE.
+func VerifyUpdate(v ASPAVerifier, u *rislive.RisMessageUpdate, dir Direction) []PathAnnotation {
+	var as []PathAnnotation
+	u.EachRouteEvent(func(ev rislive.RouteEvent) bool {
+		if ev.Kind != rislive.RouteAnnouncement {
+			return true
+		}
+		as = append(as, PathAnnotation{RouteEvent: ev, PathResult: v.VerifyPath(ev.Path, dir)})
+		return true
+	})
+	return as
+}
@@ -0,0 +1,103 @@
+package rpki
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/stretchr/testify/assert"
+)
+
+func mustPath(s string) rislive.ASPath {
+	var p rislive.ASPath
+	if err := p.UnmarshalJSON([]byte(s)); err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func loadASPAs(t *testing.T, name string) *ASPASet {
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	_, aspas, err := ReadExport(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewASPASet(aspas)
+}
+
+func TestReadExportASPAs(t *testing.T) {
+	for _, name := range []string{"rpki-client.json", "routinator.json"} {
+		s := loadASPAs(t, name)
+		assert.Equal(t, []ASPA{
+			{Customer: 64500, Providers: []uint32{64510, 64511}},
+			{Customer: 64510, Providers: []uint32{64520}},
+			{Customer: 64520, Providers: []uint32{0}},
+			{Customer: 64530, Providers: []uint32{64520}},
+		}, s.ASPAs(), name)
+	}
+	_, _, err := ReadExport(strings.NewReader(`{"aspas": [{"providers": [1]}]}`))
+	assert.Error(t, err)
+	_, _, err = ReadExport(strings.NewReader(`{"aspas": [{"customer": 1, "providers": ["ASx"]}]}`))
+	assert.Error(t, err)
+}
+
+func TestVerifyPath(t *testing.T) {
+	s := loadASPAs(t, "rpki-client.json")
+	tests := []struct {
+		Path      string
+		Direction Direction
+		Result    PathResult
+	}{
+		{`[64510, 64500]`, Upstream, PathResult{State: PathValid}},
+		{`[64520, 64510, 64500]`, Upstream, PathResult{State: PathValid}},
+		{`[64520, 64520, 64510, 64500, 64500]`, Upstream, PathResult{State: PathValid}},
+		{`[64530, 64500]`, Upstream, PathResult{State: PathInvalid, Customer: 64500, Provider: 64530}},
+		{`[64599, 64510, 64500]`, Upstream, PathResult{State: PathInvalid, Customer: 64510, Provider: 64599}},
+		{`[64599, 64598]`, Upstream, PathResult{State: PathUnknown}},
+		{`[64500]`, Upstream, PathResult{State: PathValid}},
+		{`[64510, [64500, 64501]]`, Upstream, PathResult{State: PathInvalid}},
+		{`[]`, Upstream, PathResult{State: PathUnknown}},
+		{`[64530, 64520, 64510, 64500]`, Upstream, PathResult{State: PathInvalid, Customer: 64520, Provider: 64530}},
+		{`[64530, 64520, 64510, 64500]`, Downstream, PathResult{State: PathValid}},
+		{`[64530, 64500]`, Downstream, PathResult{State: PathValid}},
+		{`[64530, 64520, 64599, 64510, 64500]`, Downstream, PathResult{PathInvalid, 64510, 64599, 64520, 64599}},
+		// The up-ramp may turn down at 64520, but 64530 does not authorize
+		// 64599 as its provider.
+		{`[64530, 64599, 64520, 64510, 64500]`, Downstream, PathResult{PathInvalid, 64520, 64599, 64530, 64599}},
+		{`[64530, 64520, 64598, 64597]`, Downstream, PathResult{State: PathUnknown}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.Result, s.VerifyPath(mustPath(tt.Path), tt.Direction), "%s %s", tt.Path, tt.Direction)
+	}
+	assert.Equal(t, "invalid (AS64520 -> AS64599, AS64530 -> AS64599)",
+		s.VerifyPath(mustPath(`[64530, 64599, 64520, 64510, 64500]`), Downstream).String())
+}
+
+func TestASPASetApply(t *testing.T) {
+	assert := assert.New(t)
+	s := NewASPASet([]ASPA{
+		{Customer: 64500, Providers: []uint32{64510}},
+		{Customer: 64501, Providers: []uint32{64510}},
+	})
+	s.Apply([]ASPA{{Customer: 64500, Providers: []uint32{64512, 64511, 64512}}}, []uint32{64501, 64502})
+	assert.Equal([]ASPA{{Customer: 64500, Providers: []uint32{64511, 64512}}}, s.ASPAs())
+}
+
+func TestVerifyUpdate(t *testing.T) {
+	assert := assert.New(t)
+	s := loadASPAs(t, "routinator.json")
+	m := rislive.NewUpdate().Host("rrc00").Peer("192.0.2.1", 64530).Path(64530, 64500).
+		Announce("192.0.2.1", "198.51.100.0/22").Withdraw("198.51.101.0/24").MustMessage()
+	u, _ := m.AsUpdate()
+	as := VerifyUpdate(s, u, Upstream)
+	if assert.Len(as, 1) {
+		assert.Equal("198.51.100.0/22", as[0].Prefix)
+		assert.Equal("invalid (AS64500 -> AS64530)", as[0].PathResult.String())
+	}
+}
@@ -0,0 +1,116 @@
+package rpki
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+)
+
+// FileValidator validates against the VRPs and ASPAs of a JSON export on
+// disk and can reload the file when it changes. It is safe for concurrent
+// use.
+type FileValidator struct {
+	path string
+
+	mu      sync.RWMutex
+	set     *Set
+	aspas   *ASPASet
+	modTime time.Time
+	size    int64
+}
+
+// NewFileValidator loads the VRPs of path.
+func NewFileValidator(path string) (*FileValidator, error) {
+	v := &FileValidator{path: path}
+	if _, err := v.Reload(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Validate implements Validator.
+func (v *FileValidator) Validate(prefix *net.IPNet, origin uint32) Result {
+	return v.Set().Validate(prefix, origin)
+}
+
+// Set returns the VRPs currently loaded.
+func (v *FileValidator) Set() *Set {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.set
+}
+
+// VerifyPath implements ASPAVerifier.
+func (v *FileValidator) VerifyPath(path rislive.ASPath, dir Direction) PathResult {
+	return v.ASPASet().VerifyPath(path, dir)
+}
+
+// ASPASet returns the ASPAs currently loaded.
+func (v *FileValidator) ASPASet() *ASPASet {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.aspas
+}
+
+// Reload loads the file again if its modification time or size changed,
+// reporting whether it did. On failure the previous VRPs are kept.
+func (v *FileValidator) Reload() (bool, error) {
+	fi, err := os.Stat(v.path)
+	if err != nil {
+		return false, err
+	}
+	v.mu.RLock()
+	unchanged := v.set != nil && fi.ModTime().Equal(v.modTime) && fi.Size() == v.size
+	v.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+	f, err := os.Open(v.path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	vrps, aspas, err := ReadExport(f)
+	if err != nil {
+		return false, fmt.Errorf("%s: %v", v.path, err)
+	}
+	v.mu.Lock()
+	v.set, v.aspas, v.modTime, v.size = NewSet(vrps), NewASPASet(aspas), fi.ModTime(), fi.Size()
+	v.mu.Unlock()
+	return true, nil
+}
+
+// Watch checks the file for changes at every interval in the background
+// until the returned function is called. Failures are passed to errFn when
+// it is not nil.
+func (v *FileValidator) Watch(interval time.Duration, errFn func(error)) (stop func()) {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-t.C:
+				if _, err := v.Reload(); err != nil && errFn != nil {
+					errFn(err)
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+		})
+	}
+}
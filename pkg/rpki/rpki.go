@@ -0,0 +1,224 @@
+// Package rpki implements Route Origin Validation (RFC 6811) of announced
+// routes against a set of Validated ROA Payloads, and ASPA verification of
+// their AS paths.
+package rpki
+
+import (
+	"fmt"
+	"net"
+	"sync"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/a16/go-rislive/pkg/prefixtree"
+)
+
+type State int
+
+const (
+	NotFound State = iota
+	Valid
+	Invalid
+)
+
+func (s State) String() string {
+	switch s {
+	case NotFound:
+		return "not-found"
+	case Valid:
+		return "valid"
+	case Invalid:
+		return "invalid"
+	}
+	return "unknown"
+}
+
+// Reason tells why a route is Invalid.
+type Reason int
+
+const (
+	ReasonNone Reason = iota
+	// ReasonOrigin is set when no covering VRP has the origin of the route.
+	ReasonOrigin
+	// ReasonMaxLength is set when a covering VRP has the origin of the route
+	// but the prefix is longer than its maxLength.
+	ReasonMaxLength
+)
+
+func (r Reason) String() string {
+	switch r {
+	case ReasonNone:
+		return "none"
+	case ReasonOrigin:
+		return "origin"
+	case ReasonMaxLength:
+		return "max-length"
+	}
+	return "unknown"
+}
+
+// VRP is a Validated ROA Payload.
+type VRP struct {
+	Prefix    *net.IPNet
+	MaxLength int
+	ASN       uint32
+}
+
+func (v VRP) String() string {
+	return fmt.Sprintf("%s-%d AS%d", v.Prefix, v.MaxLength, v.ASN)
+}
+
+type Result struct {
+	State  State
+	Reason Reason
+	// Covering holds the VRPs covering the prefix.
+	Covering []VRP
+}
+
+// Validator validates the origin of a route. Origin is zero when the path
+// ends in an AS_SET, which never matches a VRP.
+type Validator interface {
+	Validate(prefix *net.IPNet, origin uint32) Result
+}
+
+// Set is a set of VRPs indexed by prefix. It is safe for concurrent use.
+type Set struct {
+	mu    sync.RWMutex
+	tree  *prefixtree.Tree
+	count int
+}
+
+// NewSet returns a set of vrps. Duplicates are kept once.
+func NewSet(vrps []VRP) *Set {
+	s := &Set{tree: prefixtree.New()}
+	for _, v := range vrps {
+		s.add(v)
+	}
+	return s
+}
+
+func (s *Set) add(v VRP) {
+	var list []VRP
+	if l, ok := s.tree.Get(v.Prefix); ok {
+		list = l.([]VRP)
+	}
+	for _, e := range list {
+		if e.ASN == v.ASN && e.MaxLength == v.MaxLength {
+			return
+		}
+	}
+	s.tree.Insert(v.Prefix, append(list[:len(list):len(list)], v))
+	s.count++
+}
+
+func (s *Set) remove(v VRP) {
+	l, ok := s.tree.Get(v.Prefix)
+	if !ok {
+		return
+	}
+	list := l.([]VRP)
+	for i, e := range list {
+		if e.ASN != v.ASN || e.MaxLength != v.MaxLength {
+			continue
+		}
+		if rest := append(list[:i:i], list[i+1:]...); len(rest) > 0 {
+			s.tree.Insert(v.Prefix, rest)
+		} else {
+			s.tree.Delete(v.Prefix)
+		}
+		s.count--
+		return
+	}
+}
+
+// Apply removes withdrawn and adds announced, as an RTR serial update does.
+// Validations never see the set halfway through the change.
+func (s *Set) Apply(announced, withdrawn []VRP) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, v := range withdrawn {
+		s.remove(v)
+	}
+	for _, v := range announced {
+		s.add(v)
+	}
+}
+
+// Len returns the number of VRPs.
+func (s *Set) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.count
+}
+
+// Walk calls fn for every VRP until it returns false. fn must not change
+// the set.
+func (s *Set) Walk(fn func(VRP) bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.tree.Walk(func(_ *net.IPNet, v interface{}) bool {
+		for _, vrp := range v.([]VRP) {
+			if !fn(vrp) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Validate implements Validator.
+func (s *Set) Validate(prefix *net.IPNet, origin uint32) Result {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var r Result
+	ones, _ := prefix.Mask.Size()
+	sameOrigin := false
+	s.tree.Covering(prefix, func(_ *net.IPNet, v interface{}) bool {
+		for _, vrp := range v.([]VRP) {
+			r.Covering = append(r.Covering, vrp)
+			if vrp.ASN == 0 || vrp.ASN != origin {
+				continue
+			}
+			if ones <= vrp.MaxLength {
+				r.State = Valid
+			}
+			sameOrigin = true
+		}
+		return true
+	})
+	switch {
+	case r.State == Valid:
+	case len(r.Covering) == 0:
+		r.State = NotFound
+	case sameOrigin:
+		r.State, r.Reason = Invalid, ReasonMaxLength
+	default:
+		r.State, r.Reason = Invalid, ReasonOrigin
+	}
+	return r
+}
+
+// Annotation is the validation result of an announced route.
+type Annotation struct {
+	rislive.RouteEvent
+	Origin uint32
+	Result
+}
+
+// ValidateUpdate validates every announcement of an UPDATE. Prefixes that
+// cannot be parsed are skipped.
+func ValidateUpdate(v Validator, u *rislive.RisMessageUpdate) []Annotation {
+	var as []Annotation
+	u.EachRouteEvent(func(ev rislive.RouteEvent) bool {
+		if ev.Kind != rislive.RouteAnnouncement {
+			return true
+		}
+		_, prefix, err := net.ParseCIDR(ev.Prefix)
+		if err != nil {
+			return true
+		}
+		origin, _ := ev.Path.Origin()
+		as = append(as, Annotation{RouteEvent: ev, Origin: origin, Result: v.Validate(prefix, origin)})
+		return true
+	})
+	return as
+}
@@ -0,0 +1,165 @@
+package rpki
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/a16/go-rislive/pkg/rislivetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadFile(t *testing.T) {
+	for _, name := range []string{"rpki-client.json", "routinator.json"} {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			s, err := LoadFile(filepath.Join("testdata", name))
+			assert.NoError(err)
+			assert.Equal(4, s.Len())
+			var vrps []string
+			s.Walk(func(v VRP) bool {
+				vrps = append(vrps, v.String())
+				return true
+			})
+			assert.Equal([]string{
+				"198.51.100.0/22-23 AS64500",
+				"198.51.100.0/24-24 AS64501",
+				"203.0.113.0/24-32 AS0",
+				"2001:db8::/32-48 AS64500",
+			}, vrps)
+		})
+	}
+}
+
+func TestReadVRPsErrors(t *testing.T) {
+	for _, doc := range []string{
+		`{"roas": [{"asn": "ASx", "prefix": "10.0.0.0/8", "maxLength": 8}]}`,
+		`{"roas": [{"asn": 1, "prefix": "10.0.0.0", "maxLength": 8}]}`,
+		`{"roas": [{"asn": 1, "prefix": "10.0.0.0/8", "maxLength": 7}]}`,
+		`{"roas": [{"asn": 1, "prefix": "10.0.0.0/8", "maxLength": 33}]}`,
+		`not json`,
+	} {
+		_, err := ReadVRPs(strings.NewReader(doc))
+		assert.Error(t, err, doc)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	s, err := LoadFile("testdata/rpki-client.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		Prefix   string
+		Origin   uint32
+		State    State
+		Reason   Reason
+		Covering int
+	}{
+		{"198.51.100.0/22", 64500, Valid, ReasonNone, 1},
+		{"198.51.100.0/23", 64500, Valid, ReasonNone, 1},
+		{"198.51.100.0/24", 64500, Invalid, ReasonMaxLength, 2},
+		{"198.51.100.0/24", 64501, Valid, ReasonNone, 2},
+		{"198.51.100.0/22", 64666, Invalid, ReasonOrigin, 1},
+		{"198.51.100.0/24", 0, Invalid, ReasonOrigin, 2},
+		{"198.51.96.0/20", 64500, NotFound, ReasonNone, 0},
+		{"203.0.113.0/25", 0, Invalid, ReasonOrigin, 1},
+		{"2001:db8:1::/48", 64500, Valid, ReasonNone, 1},
+		{"2001:db8:1::/64", 64500, Invalid, ReasonMaxLength, 1},
+	}
+	for _, tt := range tests {
+		r := s.Validate(rislivetest.MustPrefix(tt.Prefix), tt.Origin)
+		assert.Equal(t, tt.State, r.State, "%s AS%d", tt.Prefix, tt.Origin)
+		assert.Equal(t, tt.Reason, r.Reason, "%s AS%d", tt.Prefix, tt.Origin)
+		assert.Len(t, r.Covering, tt.Covering, "%s AS%d", tt.Prefix, tt.Origin)
+	}
+}
+
+func TestSetApply(t *testing.T) {
+	assert := assert.New(t)
+	a := VRP{Prefix: rislivetest.MustPrefix("198.51.100.0/22"), MaxLength: 24, ASN: 64500}
+	b := VRP{Prefix: rislivetest.MustPrefix("198.51.100.0/22"), MaxLength: 22, ASN: 64501}
+	c := VRP{Prefix: rislivetest.MustPrefix("2001:db8::/32"), MaxLength: 48, ASN: 64500}
+	s := NewSet([]VRP{a, b})
+
+	s.Apply([]VRP{c, c}, []VRP{a, {Prefix: rislivetest.MustPrefix("203.0.113.0/24"), MaxLength: 24, ASN: 64500}})
+	assert.Equal(2, s.Len())
+	assert.Equal(Invalid, s.Validate(rislivetest.MustPrefix("198.51.100.0/24"), 64500).State)
+	assert.Equal(Valid, s.Validate(rislivetest.MustPrefix("2001:db8::/48"), 64500).State)
+
+	s.Apply(nil, []VRP{b})
+	assert.Equal(1, s.Len())
+	assert.Equal(NotFound, s.Validate(rislivetest.MustPrefix("198.51.100.0/22"), 64501).State)
+}
+
+func TestValidateUpdate(t *testing.T) {
+	assert := assert.New(t)
+	s, err := LoadFile("testdata/routinator.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := rislive.NewUpdate().Host("rrc00").Peer("192.0.2.1", 64496).Path(64496, 64500).
+		Announce("192.0.2.1", "198.51.100.0/22", "198.51.100.0/24", "192.0.2.0/24").
+		Withdraw("198.51.101.0/24").MustMessage()
+	u, _ := m.AsUpdate()
+	as := ValidateUpdate(s, u)
+	if assert.Len(as, 3) {
+		assert.Equal("198.51.100.0/22", as[0].Prefix)
+		assert.Equal(uint32(64500), as[0].Origin)
+		assert.Equal(Valid, as[0].State)
+		assert.Equal(Invalid, as[1].State)
+		assert.Equal(ReasonMaxLength, as[1].Reason)
+		assert.Equal(NotFound, as[2].State)
+	}
+}
+
+func TestFileValidator(t *testing.T) {
+	assert := assert.New(t)
+	dir, err := ioutil.TempDir("", "rpki")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "vrps.json")
+	write := func(doc string, mtime time.Time) {
+		assert.NoError(ioutil.WriteFile(path, []byte(doc), 0644))
+		assert.NoError(os.Chtimes(path, mtime, mtime))
+	}
+	t0 := time.Now().Add(-time.Hour)
+	write(`{"roas": [{"asn": 64500, "prefix": "198.51.100.0/24", "maxLength": 24}]}`, t0)
+
+	v, err := NewFileValidator(path)
+	assert.NoError(err)
+	assert.Equal(Invalid, v.Validate(rislivetest.MustPrefix("198.51.100.0/24"), 64501).State)
+	assert.Equal(PathUnknown, v.VerifyPath(rislive.ASPath{{ASN: 64510}, {ASN: 64501}}, Upstream).State)
+	reloaded, err := v.Reload()
+	assert.NoError(err)
+	assert.False(reloaded)
+
+	errs := make(chan error, 10)
+	stop := v.Watch(10*time.Millisecond, func(err error) { errs <- err })
+	defer stop()
+
+	write(`{"roas": [{"asn": 64501, "prefix": "198.51.100.0/24", "maxLength": 24}],
+		"aspas": [{"customer_asid": 64501, "providers": [64510]}]}`, t0.Add(time.Minute))
+	for i := 0; i < 500 && v.Validate(rislivetest.MustPrefix("198.51.100.0/24"), 64501).State != Valid; i++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+	assert.Equal(Valid, v.Validate(rislivetest.MustPrefix("198.51.100.0/24"), 64501).State)
+	assert.Equal(PathValid, v.VerifyPath(rislive.ASPath{{ASN: 64510}, {ASN: 64501}}, Upstream).State)
+
+	// A broken file keeps the previous VRPs.
+	write(`{"roas": [`, t0.Add(2*time.Minute))
+	select {
+	case err := <-errs:
+		assert.Error(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reload error")
+	}
+	assert.Equal(Valid, v.Validate(rislivetest.MustPrefix("198.51.100.0/24"), 64501).State)
+	stop()
+}
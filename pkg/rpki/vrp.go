@@ -0,0 +1,109 @@
+package rpki
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// asn decodes an ASN given either as a number or as a string such as
+// "AS64496", which rpki-client and Routinator use respectively.
+type asn uint32
+
+func (a *asn) UnmarshalJSON(buf []byte) error {
+	s := strings.Trim(string(buf), `"`)
+	if len(s) > 2 && strings.EqualFold(s[:2], "AS") {
+		s = s[2:]
+	}
+	n, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid asn: %s", string(buf))
+	}
+	*a = asn(n)
+	return nil
+}
+
+type jsonROA struct {
+	Prefix    string `json:"prefix"`
+	MaxLength int    `json:"maxLength"`
+	ASN       asn    `json:"asn"`
+}
+
+// jsonASPA is an ASPA of rpki-client, which names the customer
+// customer_asid, or of Routinator, which names it customer.
+type jsonASPA struct {
+	CustomerASID *asn  `json:"customer_asid"`
+	Customer     *asn  `json:"customer"`
+	Providers    []asn `json:"providers"`
+}
+
+type jsonVRPs struct {
+	ROAs  []jsonROA  `json:"roas"`
+	ASPAs []jsonASPA `json:"aspas"`
+}
+
+// ReadVRPs reads the JSON export of rpki-client or Routinator, an object
+// with a "roas" list of prefix, maxLength and asn.
+func ReadVRPs(r io.Reader) ([]VRP, error) {
+	vrps, _, err := ReadExport(r)
+	return vrps, err
+}
+
+// ReadExport reads the VRPs and the ASPAs of a JSON export. ASPAs are
+// read from an optional "aspas" list of customer and providers.
+func ReadExport(r io.Reader) ([]VRP, []ASPA, error) {
+	var doc jsonVRPs
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, nil, err
+	}
+	vrps := make([]VRP, 0, len(doc.ROAs))
+	for _, roa := range doc.ROAs {
+		_, p, err := net.ParseCIDR(roa.Prefix)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid prefix: %s", roa.Prefix)
+		}
+		ones, bits := p.Mask.Size()
+		maxLength := roa.MaxLength
+		if maxLength == 0 {
+			maxLength = ones
+		}
+		if maxLength < ones || maxLength > bits {
+			return nil, nil, fmt.Errorf("invalid maxLength %d for %s", roa.MaxLength, roa.Prefix)
+		}
+		vrps = append(vrps, VRP{Prefix: p, MaxLength: maxLength, ASN: uint32(roa.ASN)})
+	}
+	aspas := make([]ASPA, 0, len(doc.ASPAs))
+	for _, a := range doc.ASPAs {
+		customer := a.CustomerASID
+		if customer == nil {
+			customer = a.Customer
+		}
+		if customer == nil {
+			return nil, nil, fmt.Errorf("aspa without customer")
+		}
+		providers := make([]uint32, len(a.Providers))
+		for i, p := range a.Providers {
+			providers[i] = uint32(p)
+		}
+		aspas = append(aspas, ASPA{Customer: uint32(*customer), Providers: providers})
+	}
+	return vrps, aspas, nil
+}
+
+// LoadFile reads the VRPs of a JSON export into a Set.
+func LoadFile(path string) (*Set, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	vrps, err := ReadVRPs(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return NewSet(vrps), nil
+}
@@ -0,0 +1,369 @@
+package rtr
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/a16/go-rislive/pkg/rpki"
+)
+
+// Default timing parameters of RFC 8210, used until the cache sends its
+// own in End of Data.
+const (
+	DefaultRefresh = time.Hour
+	DefaultRetry   = 10 * time.Minute
+	DefaultExpire  = 2 * time.Hour
+)
+
+// errDowngrade ends a session that is to be retried with a lower version.
+var errDowngrade = errors.New("rtr: protocol version downgrade")
+
+type vrpKey struct {
+	prefix    string
+	maxLength int
+	asn       uint32
+}
+
+// Client maintains the VRPs and ASPAs of an RTR cache. It implements
+// rpki.Validator and rpki.ASPAVerifier and is safe for concurrent use.
+type Client struct {
+	Addr string
+	// Dial opens the transport to Addr; plain TCP when nil.
+	Dial func(ctx context.Context, addr string) (net.Conn, error)
+	// Version is the highest protocol version to use. Lower versions are
+	// negotiated with caches that do not support it.
+	Version uint8
+	// OnUpdate, if set, is called after the data of each End of Data has
+	// been applied.
+	OnUpdate func()
+
+	mu       sync.RWMutex
+	version  uint8
+	synced   bool
+	session  uint16
+	serial   uint32
+	refresh  time.Duration
+	retry    time.Duration
+	expire   time.Duration
+	lastSync time.Time
+	set      *rpki.Set
+	aspaSet  *rpki.ASPASet
+	ready    chan struct{}
+}
+
+func NewClient(addr string) *Client {
+	return &Client{
+		Addr:    addr,
+		Version: Version2,
+		version: Version2,
+		refresh: DefaultRefresh,
+		retry:   DefaultRetry,
+		expire:  DefaultExpire,
+		set:     rpki.NewSet(nil),
+		aspaSet: rpki.NewASPASet(nil),
+		ready:   make(chan struct{}),
+	}
+}
+
+// Ready is closed once the first End of Data has been applied.
+func (c *Client) Ready() <-chan struct{} {
+	return c.ready
+}
+
+// Validate implements rpki.Validator. Before the first synchronization
+// every route is NotFound.
+func (c *Client) Validate(prefix *net.IPNet, origin uint32) rpki.Result {
+	return c.Set().Validate(prefix, origin)
+}
+
+// Set returns the current VRPs. Serial updates change the returned set in
+// place, while a Cache Reset replaces it.
+func (c *Client) Set() *rpki.Set {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.set
+}
+
+// VerifyPath implements rpki.ASPAVerifier. Before the first
+// synchronization, or with caches older than version 2, every path is
+// Unknown.
+func (c *Client) VerifyPath(path rislive.ASPath, dir rpki.Direction) rpki.PathResult {
+	return c.ASPASet().VerifyPath(path, dir)
+}
+
+// ASPASet returns the current ASPAs, changed or replaced as Set is.
+func (c *Client) ASPASet() *rpki.ASPASet {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.aspaSet
+}
+
+// ASPAs returns the current ASPAs ordered by customer.
+func (c *Client) ASPAs() []rpki.ASPA {
+	return c.ASPASet().ASPAs()
+}
+
+// Serial returns the session ID and serial number of the data, and false
+// before the first synchronization.
+func (c *Client) Serial() (uint16, uint32, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.session, c.serial, c.synced
+}
+
+// ProtocolVersion returns the version negotiated with the cache.
+func (c *Client) ProtocolVersion() uint8 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.version
+}
+
+// Run keeps a session with the cache until ctx is done, reconnecting after
+// the retry interval on failures. Data older than the expire interval is
+// discarded while the cache is unreachable.
+func (c *Client) Run(ctx context.Context) error {
+	c.mu.Lock()
+	if c.Version < c.version {
+		c.version = c.Version
+	}
+	c.mu.Unlock()
+	for {
+		err := c.runOnce(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err == errDowngrade {
+			continue
+		}
+		c.mu.Lock()
+		if c.synced && time.Since(c.lastSync) > c.expire {
+			c.flush()
+		}
+		retry := c.retry
+		c.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retry):
+		}
+	}
+}
+
+// flush discards all data.
+func (c *Client) flush() {
+	c.synced = false
+	c.set = rpki.NewSet(nil)
+	c.aspaSet = rpki.NewASPASet(nil)
+}
+
+func (c *Client) dial(ctx context.Context) (net.Conn, error) {
+	if c.Dial != nil {
+		return c.Dial(ctx, c.Addr)
+	}
+	var d net.Dialer
+	return d.DialContext(ctx, "tcp", c.Addr)
+}
+
+// response collects the payload PDUs between Cache Response and End of
+// Data, which are applied together.
+type response struct {
+	reset bool
+	vrps  []*pdu
+	aspas []*pdu
+}
+
+func (c *Client) runOnce(ctx context.Context) error {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-stop:
+		}
+	}()
+
+	pdus := make(chan *pdu)
+	errs := make(chan error, 1)
+	go func() {
+		for {
+			p, err := readPDU(conn)
+			if err != nil {
+				errs <- err
+				return
+			}
+			select {
+			case pdus <- p:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	c.mu.RLock()
+	version, synced, session, serial, refresh := c.version, c.synced, c.session, c.serial, c.refresh
+	c.mu.RUnlock()
+
+	send := func(p *pdu) error {
+		p.version = version
+		_, err := conn.Write(p.marshal())
+		return err
+	}
+	var resp *response
+	query := func() error {
+		if synced {
+			resp = &response{}
+			return send(&pdu{typ: typeSerialQuery, session: session, serial: serial})
+		}
+		resp = &response{reset: true}
+		return send(&pdu{typ: typeResetQuery})
+	}
+	if err := query(); err != nil {
+		return err
+	}
+	timer := time.NewTimer(refresh)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-errs:
+			return err
+		case <-timer.C:
+			if resp == nil {
+				if err := query(); err != nil {
+					return err
+				}
+			}
+			timer.Reset(refresh)
+		case p := <-pdus:
+			if p.version != version {
+				if p.version < version && !synced {
+					c.mu.Lock()
+					c.version = p.version
+					c.mu.Unlock()
+					return errDowngrade
+				}
+				send(errorPDU(version, ErrUnexpectedVersion, p.marshal(), "unexpected protocol version"))
+				return errors.New("rtr: unexpected protocol version")
+			}
+			switch p.typ {
+			case typeSerialNotify:
+				if resp == nil {
+					if err := query(); err != nil {
+						return err
+					}
+				}
+			case typeCacheResponse:
+				if resp == nil {
+					return errors.New("rtr: unexpected Cache Response")
+				}
+				if synced && !resp.reset && p.session != session {
+					// The data of the old session is replaced by a reset
+					// on the next connection.
+					c.mu.Lock()
+					c.synced = false
+					c.mu.Unlock()
+					return errors.New("rtr: session ID changed")
+				}
+			case typeIPv4Prefix, typeIPv6Prefix:
+				if resp != nil {
+					resp.vrps = append(resp.vrps, p)
+				}
+			case typeASPA:
+				if resp != nil {
+					resp.aspas = append(resp.aspas, p)
+				}
+			case typeRouterKey:
+			case typeCacheReset:
+				synced = false
+				if err := query(); err != nil {
+					return err
+				}
+			case typeEndOfData:
+				if resp == nil {
+					return errors.New("rtr: unexpected End of Data")
+				}
+				c.apply(resp, p)
+				resp = nil
+				synced, session, serial = true, p.session, p.serial
+				c.mu.RLock()
+				refresh = c.refresh
+				c.mu.RUnlock()
+				timer.Reset(refresh)
+				if c.OnUpdate != nil {
+					c.OnUpdate()
+				}
+			case typeErrorReport:
+				if p.session == ErrUnsupportedVersion && version > Version0 && !synced {
+					c.mu.Lock()
+					c.version = version - 1
+					c.mu.Unlock()
+					return errDowngrade
+				}
+				return &ErrorReport{Code: p.session, Text: p.errText}
+			default:
+				send(errorPDU(version, ErrUnsupportedPDUType, p.marshal(), "unsupported PDU type"))
+				return errors.New("rtr: unsupported PDU type")
+			}
+		}
+	}
+}
+
+// apply installs the data of a response ended by eod. The data of a reset
+// replaces the sets, while that of a serial query changes them in place.
+// Either way the work is done before taking c.mu.
+func (c *Client) apply(resp *response, eod *pdu) {
+	var announced, withdrawn []rpki.VRP
+	for _, p := range resp.vrps {
+		if p.announce() {
+			announced = append(announced, p.vrp)
+		} else {
+			withdrawn = append(withdrawn, p.vrp)
+		}
+	}
+	var aspas []rpki.ASPA
+	var customers []uint32
+	for _, p := range resp.aspas {
+		if p.announce() {
+			aspas = append(aspas, p.aspa)
+		} else {
+			customers = append(customers, p.aspa.Customer)
+		}
+	}
+	c.mu.RLock()
+	set, aspaSet := c.set, c.aspaSet
+	c.mu.RUnlock()
+	if resp.reset {
+		set, aspaSet = rpki.NewSet(announced), rpki.NewASPASet(aspas)
+	} else {
+		set.Apply(announced, withdrawn)
+		aspaSet.Apply(aspas, customers)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set, c.aspaSet = set, aspaSet
+	c.synced, c.session, c.serial = true, eod.session, eod.serial
+	c.lastSync = time.Now()
+	if eod.version > Version0 && eod.refresh > 0 && eod.retry > 0 && eod.expire > 0 {
+		c.refresh = time.Duration(eod.refresh) * time.Second
+		c.retry = time.Duration(eod.retry) * time.Second
+		c.expire = time.Duration(eod.expire) * time.Second
+	}
+	select {
+	case <-c.ready:
+	default:
+		close(c.ready)
+	}
+}
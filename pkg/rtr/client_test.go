@@ -0,0 +1,149 @@
+package rtr
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/a16/go-rislive/pkg/rislivetest"
+	"github.com/a16/go-rislive/pkg/rpki"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testVRPs = []rpki.VRP{
+		{Prefix: rislivetest.MustPrefix("198.51.100.0/22"), MaxLength: 23, ASN: 64500},
+		{Prefix: rislivetest.MustPrefix("2001:db8::/32"), MaxLength: 48, ASN: 64500},
+	}
+	testASPAs = []rpki.ASPA{
+		{Customer: 64500, Providers: []uint32{64511, 64510}},
+		{Customer: 64501, Providers: []uint32{64510}},
+	}
+)
+
+func startServer(t *testing.T, s *Server) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Serve(l)
+	return l.Addr().String()
+}
+
+func startClient(c *Client) (updates chan struct{}, stop func()) {
+	updates = make(chan struct{}, 10)
+	c.OnUpdate = func() { updates <- struct{}{} }
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		c.Run(ctx)
+		close(done)
+	}()
+	return updates, func() {
+		cancel()
+		<-done
+	}
+}
+
+func waitUpdate(t *testing.T, updates chan struct{}) {
+	select {
+	case <-updates:
+	case <-time.After(2 * time.Second):
+		t.Fatal("no update")
+	}
+}
+
+func TestClientSync(t *testing.T) {
+	assert := assert.New(t)
+	s := NewServer(7)
+	defer s.Close()
+	s.Update(testVRPs, testASPAs)
+	c := NewClient(startServer(t, s))
+	updates, stop := startClient(c)
+	defer stop()
+
+	waitUpdate(t, updates)
+	<-c.Ready()
+	assert.Equal(Version2, c.ProtocolVersion())
+	session, serial, ok := c.Serial()
+	assert.Equal([]interface{}{uint16(7), uint32(1), true}, []interface{}{session, serial, ok})
+	assert.Equal(2, c.Set().Len())
+	assert.Equal(rpki.Valid, c.Validate(rislivetest.MustPrefix("198.51.100.0/23"), 64500).State)
+	assert.Equal(rpki.Invalid, c.Validate(rislivetest.MustPrefix("198.51.100.0/24"), 64500).State)
+	assert.Equal([]rpki.ASPA{
+		{Customer: 64500, Providers: []uint32{64510, 64511}},
+		{Customer: 64501, Providers: []uint32{64510}},
+	}, c.ASPAs())
+	assert.Equal(rpki.PathResult{State: rpki.PathInvalid, Customer: 64500, Provider: 64520},
+		c.VerifyPath(rislive.ASPath{{ASN: 64520}, {ASN: 64500}}, rpki.Upstream))
+
+	// Serial Notify triggers an incremental update, applied to the same
+	// sets.
+	set, aspaSet := c.Set(), c.ASPASet()
+	s.Update(append(testVRPs[:1:1], rpki.VRP{Prefix: rislivetest.MustPrefix("203.0.113.0/24"), MaxLength: 24, ASN: 64502}),
+		[]rpki.ASPA{{Customer: 64500, Providers: []uint32{64512}}})
+	waitUpdate(t, updates)
+	_, serial, _ = c.Serial()
+	assert.Equal(uint32(2), serial)
+	assert.Equal(rpki.NotFound, c.Validate(rislivetest.MustPrefix("2001:db8::/32"), 64500).State)
+	assert.Equal(rpki.Valid, c.Validate(rislivetest.MustPrefix("203.0.113.0/24"), 64502).State)
+	assert.Equal([]rpki.ASPA{{Customer: 64500, Providers: []uint32{64512}}}, c.ASPAs())
+	assert.True(set == c.Set() && aspaSet == c.ASPASet())
+	assert.Equal(2, set.Len())
+}
+
+func TestClientDowngrade(t *testing.T) {
+	assert := assert.New(t)
+	s := NewServer(7)
+	s.MaxVersion = Version1
+	defer s.Close()
+	s.Update(testVRPs, testASPAs)
+	c := NewClient(startServer(t, s))
+	updates, stop := startClient(c)
+	defer stop()
+
+	waitUpdate(t, updates)
+	assert.Equal(Version1, c.ProtocolVersion())
+	assert.Equal(2, c.Set().Len())
+	assert.Empty(c.ASPAs())
+}
+
+func TestServerQueries(t *testing.T) {
+	assert := assert.New(t)
+	s := NewServer(7)
+	defer s.Close()
+	conn, err := net.Dial("tcp", startServer(t, s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	query := func(q *pdu) []uint8 {
+		q.version = Version1
+		conn.Write(q.marshal())
+		var types []uint8
+		for {
+			p, err := readPDU(conn)
+			if !assert.NoError(err) {
+				return types
+			}
+			types = append(types, p.typ)
+			if p.typ != typeCacheResponse && p.typ != typeIPv4Prefix && p.typ != typeIPv6Prefix {
+				return types
+			}
+		}
+	}
+
+	assert.Equal([]uint8{typeErrorReport}, query(&pdu{typ: typeResetQuery}))
+	s.Update(testVRPs, testASPAs)
+	if p, err := readPDU(conn); assert.NoError(err) {
+		assert.Equal(typeSerialNotify, p.typ)
+		assert.Equal(uint32(1), p.serial)
+	}
+	assert.Equal([]uint8{typeCacheResponse, typeIPv4Prefix, typeIPv6Prefix, typeEndOfData}, query(&pdu{typ: typeResetQuery}))
+	assert.Equal([]uint8{typeCacheResponse, typeEndOfData}, query(&pdu{typ: typeSerialQuery, session: 7, serial: 1}))
+	assert.Equal([]uint8{typeCacheReset}, query(&pdu{typ: typeSerialQuery, session: 8, serial: 1}))
+	assert.Equal([]uint8{typeCacheReset}, query(&pdu{typ: typeSerialQuery, session: 7, serial: 5}))
+}
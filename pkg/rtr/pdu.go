@@ -0,0 +1,237 @@
+// Package rtr implements the RPKI to Router protocol (RFC 8210), with the
+// ASPA PDU of version 2, as a client maintaining a VRP set for validation
+// and as a cache server.
+package rtr
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/a16/go-rislive/pkg/rpki"
+)
+
+// Protocol versions. Version 2 adds the ASPA PDU.
+const (
+	Version0 uint8 = iota
+	Version1
+	Version2
+)
+
+// PDU types.
+const (
+	typeSerialNotify  uint8 = 0
+	typeSerialQuery   uint8 = 1
+	typeResetQuery    uint8 = 2
+	typeCacheResponse uint8 = 3
+	typeIPv4Prefix    uint8 = 4
+	typeIPv6Prefix    uint8 = 6
+	typeEndOfData     uint8 = 7
+	typeCacheReset    uint8 = 8
+	typeRouterKey     uint8 = 9
+	typeErrorReport   uint8 = 10
+	typeASPA          uint8 = 11
+)
+
+// Error Report codes.
+const (
+	ErrCorruptData           uint16 = 0
+	ErrInternal              uint16 = 1
+	ErrNoData                uint16 = 2
+	ErrInvalidRequest        uint16 = 3
+	ErrUnsupportedVersion    uint16 = 4
+	ErrUnsupportedPDUType    uint16 = 5
+	ErrWithdrawalUnknown     uint16 = 6
+	ErrDuplicateAnnouncement uint16 = 7
+	ErrUnexpectedVersion     uint16 = 8
+	ErrASPAProviderListError uint16 = 9
+	ErrTransportFailure      uint16 = 10
+	ErrOrderingError         uint16 = 11
+)
+
+const (
+	headerLen = 8
+	maxPDULen = 1 << 20
+	// flagAnnounce is set in the flags of prefix and ASPA PDUs for
+	// announcements and cleared for withdrawals.
+	flagAnnounce uint8 = 1
+)
+
+// ErrorReport is an Error Report PDU received from the peer.
+type ErrorReport struct {
+	Code uint16
+	Text string
+}
+
+func (e *ErrorReport) Error() string {
+	return fmt.Sprintf("rtr: error report %d: %s", e.Code, e.Text)
+}
+
+// pdu holds any PDU. The second 16-bit field of the header is the session
+// ID, the error code, or flags and zero, depending on the type.
+type pdu struct {
+	version uint8
+	typ     uint8
+	session uint16
+
+	serial                 uint32
+	refresh, retry, expire uint32
+	flags                  uint8
+	vrp                    rpki.VRP
+	aspa                   rpki.ASPA
+	errPDU                 []byte
+	errText                string
+}
+
+func (p *pdu) announce() bool {
+	return p.flags&flagAnnounce != 0
+}
+
+func (p *pdu) marshal() []byte {
+	b := make([]byte, headerLen, 32)
+	b[0], b[1] = p.version, p.typ
+	binary.BigEndian.PutUint16(b[2:], p.session)
+	switch p.typ {
+	case typeSerialNotify, typeSerialQuery:
+		b = appendUint32(b, p.serial)
+	case typeEndOfData:
+		b = appendUint32(b, p.serial)
+		if p.version > Version0 {
+			b = appendUint32(b, p.refresh)
+			b = appendUint32(b, p.retry)
+			b = appendUint32(b, p.expire)
+		}
+	case typeIPv4Prefix, typeIPv6Prefix:
+		ones, _ := p.vrp.Prefix.Mask.Size()
+		b = append(b, p.flags, byte(ones), byte(p.vrp.MaxLength), 0)
+		if p.typ == typeIPv4Prefix {
+			b = append(b, p.vrp.Prefix.IP.To4()...)
+		} else {
+			b = append(b, p.vrp.Prefix.IP.To16()...)
+		}
+		b = appendUint32(b, p.vrp.ASN)
+	case typeASPA:
+		b[2], b[3] = p.flags, 0
+		b = appendUint32(b, p.aspa.Customer)
+		for _, asn := range p.aspa.Providers {
+			b = appendUint32(b, asn)
+		}
+	case typeErrorReport:
+		b = appendUint32(b, uint32(len(p.errPDU)))
+		b = append(b, p.errPDU...)
+		b = appendUint32(b, uint32(len(p.errText)))
+		b = append(b, p.errText...)
+	}
+	binary.BigEndian.PutUint32(b[4:], uint32(len(b)))
+	return b
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func readPDU(r io.Reader) (*pdu, error) {
+	head := make([]byte, headerLen)
+	if _, err := io.ReadFull(r, head); err != nil {
+		return nil, err
+	}
+	p := &pdu{
+		version: head[0],
+		typ:     head[1],
+		session: binary.BigEndian.Uint16(head[2:]),
+	}
+	length := binary.BigEndian.Uint32(head[4:])
+	if length < headerLen || length > maxPDULen {
+		return nil, fmt.Errorf("rtr: invalid PDU length %d", length)
+	}
+	body := make([]byte, length-headerLen)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	if err := p.unmarshal(body); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *pdu) unmarshal(b []byte) error {
+	short := func(n int) error {
+		if len(b) < n {
+			return fmt.Errorf("rtr: short PDU type %d", p.typ)
+		}
+		return nil
+	}
+	switch p.typ {
+	case typeSerialNotify, typeSerialQuery:
+		if err := short(4); err != nil {
+			return err
+		}
+		p.serial = binary.BigEndian.Uint32(b)
+	case typeEndOfData:
+		if err := short(4); err != nil {
+			return err
+		}
+		p.serial = binary.BigEndian.Uint32(b)
+		if p.version > Version0 {
+			if err := short(16); err != nil {
+				return err
+			}
+			p.refresh = binary.BigEndian.Uint32(b[4:])
+			p.retry = binary.BigEndian.Uint32(b[8:])
+			p.expire = binary.BigEndian.Uint32(b[12:])
+		}
+	case typeIPv4Prefix, typeIPv6Prefix:
+		ipLen := net.IPv4len
+		if p.typ == typeIPv6Prefix {
+			ipLen = net.IPv6len
+		}
+		if err := short(4 + ipLen + 4); err != nil {
+			return err
+		}
+		p.flags = b[0]
+		ones, maxLen := int(b[1]), int(b[2])
+		if ones > ipLen*8 || maxLen < ones || maxLen > ipLen*8 {
+			return fmt.Errorf("rtr: invalid prefix length %d-%d", ones, maxLen)
+		}
+		ip := make(net.IP, ipLen)
+		copy(ip, b[4:4+ipLen])
+		mask := net.CIDRMask(ones, ipLen*8)
+		p.vrp = rpki.VRP{
+			Prefix:    &net.IPNet{IP: ip.Mask(mask), Mask: mask},
+			MaxLength: maxLen,
+			ASN:       binary.BigEndian.Uint32(b[4+ipLen:]),
+		}
+	case typeASPA:
+		if err := short(4); err != nil {
+			return err
+		}
+		if len(b)%4 != 0 {
+			return fmt.Errorf("rtr: invalid ASPA PDU length %d", len(b)+headerLen)
+		}
+		p.flags = uint8(p.session >> 8)
+		p.aspa.Customer = binary.BigEndian.Uint32(b)
+		for i := 4; i < len(b); i += 4 {
+			p.aspa.Providers = append(p.aspa.Providers, binary.BigEndian.Uint32(b[i:]))
+		}
+	case typeErrorReport:
+		if err := short(4); err != nil {
+			return err
+		}
+		n := int(binary.BigEndian.Uint32(b))
+		if err := short(4 + n + 4); err != nil {
+			return err
+		}
+		p.errPDU = b[4 : 4+n]
+		m := int(binary.BigEndian.Uint32(b[4+n:]))
+		if err := short(4 + n + 4 + m); err != nil {
+			return err
+		}
+		p.errText = string(b[8+n : 8+n+m])
+	}
+	return nil
+}
+
+func errorPDU(version uint8, code uint16, offending []byte, text string) *pdu {
+	return &pdu{version: version, typ: typeErrorReport, session: code, errPDU: offending, errText: text}
+}
@@ -0,0 +1,44 @@
+package rtr
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/a16/go-rislive/pkg/rislivetest"
+	"github.com/a16/go-rislive/pkg/rpki"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPDURoundTrip(t *testing.T) {
+	for _, p := range []*pdu{
+		{version: Version1, typ: typeSerialNotify, session: 7, serial: 42},
+		{version: Version1, typ: typeSerialQuery, session: 7, serial: 42},
+		{version: Version1, typ: typeResetQuery},
+		{version: Version1, typ: typeCacheResponse, session: 7},
+		{version: Version1, typ: typeIPv4Prefix, flags: flagAnnounce, vrp: rpki.VRP{Prefix: rislivetest.MustPrefix("198.51.100.0/22"), MaxLength: 24, ASN: 64500}},
+		{version: Version1, typ: typeIPv6Prefix, vrp: rpki.VRP{Prefix: rislivetest.MustPrefix("2001:db8::/32"), MaxLength: 48, ASN: 64500}},
+		{version: Version0, typ: typeEndOfData, session: 7, serial: 42},
+		{version: Version1, typ: typeEndOfData, session: 7, serial: 42, refresh: 3600, retry: 600, expire: 7200},
+		{version: Version1, typ: typeCacheReset},
+		{version: Version2, typ: typeASPA, session: uint16(flagAnnounce) << 8, flags: flagAnnounce, aspa: rpki.ASPA{Customer: 64500, Providers: []uint32{64510, 64511}}},
+		{version: Version2, typ: typeASPA, aspa: rpki.ASPA{Customer: 64500}},
+		{version: Version1, typ: typeErrorReport, session: ErrNoData, errPDU: []byte{1, 2, 0, 0, 0, 0, 0, 8}, errText: "no data"},
+	} {
+		got, err := readPDU(bytes.NewReader(p.marshal()))
+		if assert.NoError(t, err) {
+			assert.Equal(t, p, got)
+		}
+	}
+}
+
+func TestReadPDUErrors(t *testing.T) {
+	for _, b := range [][]byte{
+		{1, 0, 0, 0, 0, 0, 0, 4},
+		{1, 4, 0, 0, 0, 0, 0, 12, 1, 24, 24, 0},
+		{1, 4, 0, 0, 0, 0, 0, 20, 1, 24, 16, 0, 198, 51, 100, 0, 0, 0, 0, 1},
+		{2, 11, 1, 0, 0, 0, 0, 14, 0, 0, 251, 244, 0, 0},
+	} {
+		_, err := readPDU(bytes.NewReader(b))
+		assert.Error(t, err, "%v", b)
+	}
+}
@@ -0,0 +1,320 @@
+package rtr
+
+import (
+	"net"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/a16/go-rislive/pkg/rpki"
+)
+
+// maxDiffs is the number of serials for which incremental updates are kept.
+const maxDiffs = 64
+
+// Server is an RTR cache serving the VRPs and ASPAs given to Update.
+// Clients are sent incremental updates for the serials kept in memory and
+// a Cache Reset otherwise.
+type Server struct {
+	// MaxVersion is the highest protocol version accepted from clients.
+	MaxVersion uint8
+	// Refresh, Retry and Expire are the intervals in seconds sent in End
+	// of Data.
+	Refresh, Retry, Expire uint32
+
+	mu      sync.Mutex
+	session uint16
+	serial  uint32
+	hasData bool
+	vrps    map[vrpKey]rpki.VRP
+	aspas   map[uint32][]uint32
+	// diffs holds the changes leading to each serial.
+	diffs     map[uint32][]*pdu
+	conns     map[*serverConn]struct{}
+	listeners map[net.Listener]struct{}
+}
+
+func NewServer(session uint16) *Server {
+	return &Server{
+		MaxVersion: Version2,
+		Refresh:    3600,
+		Retry:      600,
+		Expire:     7200,
+		session:    session,
+		vrps:       map[vrpKey]rpki.VRP{},
+		aspas:      map[uint32][]uint32{},
+		diffs:      map[uint32][]*pdu{},
+		conns:      map[*serverConn]struct{}{},
+		listeners:  map[net.Listener]struct{}{},
+	}
+}
+
+// Serial returns the serial number of the current data.
+func (s *Server) Serial() uint32 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.serial
+}
+
+func vrpPDU(v rpki.VRP, flags uint8) *pdu {
+	typ := typeIPv6Prefix
+	if v.Prefix.IP.To4() != nil {
+		typ = typeIPv4Prefix
+	}
+	return &pdu{typ: typ, flags: flags, vrp: v}
+}
+
+func aspaPDU(customer uint32, providers []uint32, flags uint8) *pdu {
+	return &pdu{typ: typeASPA, flags: flags, aspa: rpki.ASPA{Customer: customer, Providers: providers}}
+}
+
+// sortPayload orders withdrawals before announcements, then prefixes
+// before ASPAs, so that responses are deterministic.
+func sortPayload(pdus []*pdu) {
+	sort.Slice(pdus, func(i, j int) bool {
+		a, b := pdus[i], pdus[j]
+		if a.announce() != b.announce() {
+			return !a.announce()
+		}
+		if a.typ != b.typ {
+			return a.typ < b.typ
+		}
+		if a.typ == typeASPA {
+			return a.aspa.Customer < b.aspa.Customer
+		}
+		return a.vrp.String() < b.vrp.String()
+	})
+}
+
+// Update replaces the served data, increments the serial and sends a
+// Serial Notify to the connected clients.
+func (s *Server) Update(vrps []rpki.VRP, aspas []rpki.ASPA) {
+	s.mu.Lock()
+	newVRPs := map[vrpKey]rpki.VRP{}
+	for _, v := range vrps {
+		newVRPs[vrpKey{prefix: v.Prefix.String(), maxLength: v.MaxLength, asn: v.ASN}] = v
+	}
+	newASPAs := map[uint32][]uint32{}
+	for _, a := range aspas {
+		providers := append([]uint32(nil), a.Providers...)
+		sort.Slice(providers, func(i, j int) bool { return providers[i] < providers[j] })
+		newASPAs[a.Customer] = providers
+	}
+
+	var diff []*pdu
+	for k, v := range s.vrps {
+		if _, ok := newVRPs[k]; !ok {
+			diff = append(diff, vrpPDU(v, 0))
+		}
+	}
+	for k, v := range newVRPs {
+		if _, ok := s.vrps[k]; !ok {
+			diff = append(diff, vrpPDU(v, flagAnnounce))
+		}
+	}
+	for customer := range s.aspas {
+		if _, ok := newASPAs[customer]; !ok {
+			diff = append(diff, aspaPDU(customer, nil, 0))
+		}
+	}
+	for customer, providers := range newASPAs {
+		if old, ok := s.aspas[customer]; !ok || !reflect.DeepEqual(old, providers) {
+			diff = append(diff, aspaPDU(customer, providers, flagAnnounce))
+		}
+	}
+
+	sortPayload(diff)
+	s.vrps, s.aspas = newVRPs, newASPAs
+	s.serial++
+	s.hasData = true
+	s.diffs[s.serial] = diff
+	delete(s.diffs, s.serial-maxDiffs)
+	conns := make([]*serverConn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	session, serial := s.session, s.serial
+	s.mu.Unlock()
+
+	for _, c := range conns {
+		c.notify(session, serial)
+	}
+}
+
+// Serve accepts connections on l until it is closed.
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	s.listeners[l] = struct{}{}
+	s.mu.Unlock()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		c := &serverConn{server: s, conn: conn}
+		s.mu.Lock()
+		s.conns[c] = struct{}{}
+		s.mu.Unlock()
+		go c.serve()
+	}
+}
+
+// Close stops the listeners and disconnects all clients.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for l := range s.listeners {
+		l.Close()
+	}
+	for c := range s.conns {
+		c.conn.Close()
+	}
+	return nil
+}
+
+type serverConn struct {
+	server *Server
+	conn   net.Conn
+
+	mu      sync.Mutex
+	version uint8
+	known   bool
+}
+
+func (c *serverConn) write(pdus ...*pdu) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var b []byte
+	for _, p := range pdus {
+		// Payload PDUs are shared with other connections.
+		q := *p
+		q.version = c.version
+		b = append(b, q.marshal()...)
+	}
+	_, err := c.conn.Write(b)
+	return err
+}
+
+func (c *serverConn) notify(session uint16, serial uint32) {
+	c.mu.Lock()
+	known := c.known
+	c.mu.Unlock()
+	if known {
+		c.write(&pdu{typ: typeSerialNotify, session: session, serial: serial})
+	}
+}
+
+func (c *serverConn) serve() {
+	defer func() {
+		c.conn.Close()
+		c.server.mu.Lock()
+		delete(c.server.conns, c)
+		c.server.mu.Unlock()
+	}()
+	for {
+		p, err := readPDU(c.conn)
+		if err != nil {
+			return
+		}
+		c.mu.Lock()
+		known, version := c.known, c.version
+		c.mu.Unlock()
+		if !known {
+			if p.version > c.server.MaxVersion {
+				c.mu.Lock()
+				c.version = c.server.MaxVersion
+				c.mu.Unlock()
+				c.write(errorPDU(0, ErrUnsupportedVersion, p.marshal(), "unsupported protocol version"))
+				return
+			}
+			c.mu.Lock()
+			c.version, c.known = p.version, true
+			c.mu.Unlock()
+		} else if p.version != version {
+			c.write(errorPDU(0, ErrUnexpectedVersion, p.marshal(), "unexpected protocol version"))
+			return
+		}
+		switch p.typ {
+		case typeResetQuery:
+			c.reset()
+		case typeSerialQuery:
+			c.incremental(p.session, p.serial)
+		case typeErrorReport:
+			return
+		default:
+			c.write(errorPDU(0, ErrUnsupportedPDUType, p.marshal(), "unsupported PDU type"))
+			return
+		}
+	}
+}
+
+func (c *serverConn) endOfData() *pdu {
+	s := c.server
+	return &pdu{typ: typeEndOfData, session: s.session, serial: s.serial,
+		refresh: s.Refresh, retry: s.Retry, expire: s.Expire}
+}
+
+// payload filters out the PDUs the client's version cannot carry.
+func (c *serverConn) payload(pdus []*pdu) []*pdu {
+	c.mu.Lock()
+	version := c.version
+	c.mu.Unlock()
+	if version >= Version2 {
+		return pdus
+	}
+	var out []*pdu
+	for _, p := range pdus {
+		if p.typ != typeASPA {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func (c *serverConn) reset() {
+	s := c.server
+	s.mu.Lock()
+	if !s.hasData {
+		s.mu.Unlock()
+		c.write(errorPDU(0, ErrNoData, nil, "no data available"))
+		return
+	}
+	pdus := []*pdu{{typ: typeCacheResponse, session: s.session}}
+	var data []*pdu
+	for _, v := range s.vrps {
+		data = append(data, vrpPDU(v, flagAnnounce))
+	}
+	for customer, providers := range s.aspas {
+		data = append(data, aspaPDU(customer, providers, flagAnnounce))
+	}
+	sortPayload(data)
+	pdus = append(pdus, c.payload(data)...)
+	pdus = append(pdus, c.endOfData())
+	s.mu.Unlock()
+	c.write(pdus...)
+}
+
+func (c *serverConn) incremental(session uint16, serial uint32) {
+	s := c.server
+	s.mu.Lock()
+	if session != s.session || serial > s.serial {
+		s.mu.Unlock()
+		c.write(&pdu{typ: typeCacheReset})
+		return
+	}
+	var data []*pdu
+	for n := serial + 1; n <= s.serial; n++ {
+		diff, ok := s.diffs[n]
+		if !ok {
+			s.mu.Unlock()
+			c.write(&pdu{typ: typeCacheReset})
+			return
+		}
+		data = append(data, diff...)
+	}
+	pdus := []*pdu{{typ: typeCacheResponse, session: s.session}}
+	pdus = append(pdus, c.payload(data)...)
+	pdus = append(pdus, c.endOfData())
+	s.mu.Unlock()
+	c.write(pdus...)
+}
@@ -0,0 +1,349 @@
+// Package visibility tracks how many RIS peers and collectors have a route
+// for monitored prefixes, and raises alerts when too few do.
+package visibility
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	rislive "github.com/a16/go-rislive/pkg/message"
+	"github.com/a16/go-rislive/pkg/rib"
+)
+
+// Visibility is the number of peers and collectors having a route for a
+// prefix, out of those that have routes of its address family.
+type Visibility struct {
+	Prefix          string
+	Peers           int
+	TotalPeers      int
+	Collectors      int
+	TotalCollectors int
+}
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(n) / float64(total)
+}
+
+// PeerPercent returns the percentage of peers having a route.
+func (v Visibility) PeerPercent() float64 {
+	return percent(v.Peers, v.TotalPeers)
+}
+
+// CollectorPercent returns the percentage of collectors having a route.
+func (v Visibility) CollectorPercent() float64 {
+	return percent(v.Collectors, v.TotalCollectors)
+}
+
+func (v Visibility) String() string {
+	return fmt.Sprintf("%s seen by %d/%d peers (%.1f%%) on %d/%d collectors", v.Prefix,
+		v.Peers, v.TotalPeers, v.PeerPercent(), v.Collectors, v.TotalCollectors)
+}
+
+// DefaultMinPeers is the MinPeers of a new Tracker. RIS collectors have
+// hundreds of peers per address family, and a handful of them is not
+// enough to judge the visibility of a prefix.
+const DefaultMinPeers = 10
+
+type EventKind int
+
+const (
+	// VisibilityLow is emitted when the peer visibility of a prefix drops
+	// below the threshold.
+	VisibilityLow EventKind = iota
+	// VisibilityRestored is emitted when it is back at the threshold or
+	// above, or when the family has fewer than MinPeers peers left and
+	// the visibility can no longer be judged.
+	VisibilityRestored
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case VisibilityLow:
+		return "low"
+	case VisibilityRestored:
+		return "restored"
+	}
+	return "unknown"
+}
+
+type Event struct {
+	Kind EventKind
+	Visibility
+	Time time.Time
+}
+
+type prefixState struct {
+	v6    bool
+	peers map[rib.PeerKey]bool
+	low   bool
+}
+
+// family is the set of peers having routes of an address family, counted
+// by collector.
+type family struct {
+	peers      map[rib.PeerKey]bool
+	collectors map[string]int
+}
+
+func newFamily() *family {
+	return &family{peers: map[rib.PeerKey]bool{}, collectors: map[string]int{}}
+}
+
+// add records a peer and reports whether it is new.
+func (f *family) add(peer rib.PeerKey) bool {
+	if f.peers[peer] {
+		return false
+	}
+	f.peers[peer] = true
+	f.collectors[peer.Host]++
+	return true
+}
+
+func (f *family) remove(peer rib.PeerKey) bool {
+	if !f.peers[peer] {
+		return false
+	}
+	delete(f.peers, peer)
+	if f.collectors[peer.Host]--; f.collectors[peer.Host] == 0 {
+		delete(f.collectors, peer.Host)
+	}
+	return true
+}
+
+// Tracker counts the peers having a route for each monitored prefix. Only
+// routes for exactly the prefix count, not those for more or less
+// specifics of it. The totals are the peers that announced any prefix of
+// the same address family and have not gone down since. It is safe for
+// concurrent use.
+type Tracker struct {
+	// Threshold is the percentage of peers under which a prefix is
+	// reported as low.
+	Threshold float64
+	// MinPeers is the number of peers of a family needed before alerts
+	// are raised, so that they do not fire while peers are learned. With
+	// zero, alerts are raised from the first peer on, which flags every
+	// monitored prefix as low as soon as any other prefix is announced.
+	MinPeers int
+
+	mu        sync.Mutex
+	monitored map[string]*prefixState
+	families  [2]*family
+	now       time.Time
+}
+
+// NewTracker returns a tracker of prefixes with MinPeers set to
+// DefaultMinPeers.
+func NewTracker(threshold float64, prefixes ...*net.IPNet) *Tracker {
+	t := &Tracker{
+		Threshold: threshold,
+		MinPeers:  DefaultMinPeers,
+		monitored: map[string]*prefixState{},
+		families:  [2]*family{newFamily(), newFamily()},
+	}
+	for _, p := range prefixes {
+		t.monitored[p.String()] = &prefixState{v6: p.IP.To4() == nil, peers: map[rib.PeerKey]bool{}}
+	}
+	return t
+}
+
+func familyIndex(v6 bool) int {
+	if v6 {
+		return 1
+	}
+	return 0
+}
+
+func (t *Tracker) visibility(prefix string, s *prefixState) Visibility {
+	f := t.families[familyIndex(s.v6)]
+	collectors := map[string]bool{}
+	for peer := range s.peers {
+		collectors[peer.Host] = true
+	}
+	return Visibility{
+		Prefix:          prefix,
+		Peers:           len(s.peers),
+		TotalPeers:      len(f.peers),
+		Collectors:      len(collectors),
+		TotalCollectors: len(f.collectors),
+	}
+}
+
+// check emits events for the prefixes crossing the threshold.
+func (t *Tracker) check(events []Event, prefix string, s *prefixState) []Event {
+	v := t.visibility(prefix, s)
+	low := v.PeerPercent() < t.Threshold
+	if v.TotalPeers == 0 || v.TotalPeers < t.MinPeers {
+		low = false
+	}
+	if low == s.low {
+		return events
+	}
+	s.low = low
+	kind := VisibilityRestored
+	if low {
+		kind = VisibilityLow
+	}
+	return append(events, Event{Kind: kind, Visibility: v, Time: t.now})
+}
+
+// checkAll checks every monitored prefix, as needed when the totals change.
+func (t *Tracker) checkAll(events []Event) []Event {
+	prefixes := make([]string, 0, len(t.monitored))
+	for prefix := range t.monitored {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	for _, prefix := range prefixes {
+		events = t.check(events, prefix, t.monitored[prefix])
+	}
+	return events
+}
+
+// Apply counts the routes announced and withdrawn by an UPDATE. A peer
+// whose session ends with an OPEN or a RIS_PEER_STATE "down" leaves the
+// totals along with its routes. The prefixes whose visibility crossed the
+// threshold are returned.
+func (t *Tracker) Apply(m *rislive.RisLiveMessage) ([]Event, error) {
+	var events []Event
+	err := m.Dispatch(rib.PeerEvents{
+		Update: func(u *rislive.RisMessageUpdate) { events = t.ApplyUpdate(u) },
+		Flush:  func(peer rib.PeerKey, now time.Time) { events = t.flushPeer(peer, now) },
+	})
+	return events, err
+}
+
+// route records the route of a peer for prefix, or its withdrawal. It
+// reports whether the totals changed and the monitored prefix that did, if
+// any.
+func (t *Tracker) route(peer rib.PeerKey, prefix *net.IPNet, announce bool) (bool, string) {
+	key := prefix.String()
+	changed := false
+	if announce {
+		changed = t.families[familyIndex(prefix.IP.To4() == nil)].add(peer)
+	}
+	s := t.monitored[key]
+	if s == nil || s.peers[peer] == announce {
+		return changed, ""
+	}
+	if announce {
+		s.peers[peer] = true
+	} else {
+		delete(s.peers, peer)
+	}
+	return changed, key
+}
+
+// ApplyUpdate records the routes announced and withdrawn by an UPDATE.
+// Prefixes that cannot be parsed are skipped.
+func (t *Tracker) ApplyUpdate(u *rislive.RisMessageUpdate) []Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if ts := u.GetTimestamp(); ts.After(t.now) {
+		t.now = ts
+	}
+	totals := false
+	touched := map[string]bool{}
+	u.EachRouteEvent(func(ev rislive.RouteEvent) bool {
+		_, prefix, err := net.ParseCIDR(ev.Prefix)
+		if err != nil {
+			return true
+		}
+		changed, key := t.route(rib.PeerKey{Host: ev.Host, Peer: ev.Peer}, prefix, ev.Kind == rislive.RouteAnnouncement)
+		totals = totals || changed
+		if key != "" {
+			touched[key] = true
+		}
+		return true
+	})
+	if totals {
+		return t.checkAll(nil)
+	}
+	keys := make([]string, 0, len(touched))
+	for key := range touched {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var events []Event
+	for _, key := range keys {
+		events = t.check(events, key, t.monitored[key])
+	}
+	return events
+}
+
+// LoadRIB records the routes of a RIB, as when starting from a snapshot.
+func (t *Tracker) LoadRIB(r *rib.RIB) []Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	r.Walk(func(rt rib.Route) bool {
+		t.route(rt.PeerKey, rt.Prefix, true)
+		if rt.Time.After(t.now) {
+			t.now = rt.Time
+		}
+		return true
+	})
+	return t.checkAll(nil)
+}
+
+// FlushPeer removes a peer and its routes, as when its session goes down.
+func (t *Tracker) FlushPeer(peer rib.PeerKey) []Event {
+	return t.flushPeer(peer, time.Time{})
+}
+
+func (t *Tracker) flushPeer(peer rib.PeerKey, now time.Time) []Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if now.After(t.now) {
+		t.now = now
+	}
+	changed := false
+	for _, f := range t.families {
+		if f.remove(peer) {
+			changed = true
+		}
+	}
+	for _, s := range t.monitored {
+		if s.peers[peer] {
+			delete(s.peers, peer)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return t.checkAll(nil)
+}
+
+// Visibility returns the visibility of a monitored prefix.
+func (t *Tracker) Visibility(prefix string) (Visibility, bool) {
+	_, p, err := net.ParseCIDR(prefix)
+	if err != nil {
+		return Visibility{}, false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := p.String()
+	s := t.monitored[key]
+	if s == nil {
+		return Visibility{}, false
+	}
+	return t.visibility(key, s), true
+}
+
+// Report returns the visibility of every monitored prefix, ordered by
+// prefix.
+func (t *Tracker) Report() []Visibility {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	vs := make([]Visibility, 0, len(t.monitored))
+	for prefix, s := range t.monitored {
+		vs = append(vs, t.visibility(prefix, s))
+	}
+	sort.Slice(vs, func(i, j int) bool { return vs[i].Prefix < vs[j].Prefix })
+	return vs
+}
@@ -0,0 +1,126 @@
+package visibility
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/a16/go-rislive/pkg/rib"
+	"github.com/a16/go-rislive/pkg/rislivetest"
+	"github.com/stretchr/testify/assert"
+)
+
+// The tests count three peers: solo is alone on its collector while the
+// twins share theirs.
+var (
+	dawn  = time.Date(2022, 5, 2, 6, 0, 0, 0, time.UTC)
+	solo  = rib.PeerKey{Host: "rrc00", Peer: "198.18.0.1"}
+	twin1 = rib.PeerKey{Host: "rrc13", Peer: "198.18.0.2"}
+	twin2 = rib.PeerKey{Host: "rrc13", Peer: "198.18.0.3"}
+)
+
+func TestTracker(t *testing.T) {
+	assert := assert.New(t)
+	const p = "198.51.100.0/24"
+	tr := NewTracker(50, rislivetest.MustPrefix(p), rislivetest.MustPrefix("2001:db8::/32"))
+	tr.MinPeers = 2
+
+	assert.Empty(tr.ApplyUpdate(rislivetest.Announce(solo, dawn, nil, p)))
+	v, ok := tr.Visibility(p)
+	assert.True(ok)
+	assert.Equal(Visibility{Prefix: p, Peers: 1, TotalPeers: 1, Collectors: 1, TotalCollectors: 1}, v)
+	assert.Empty(tr.ApplyUpdate(rislivetest.Announce(twin1, dawn.Add(time.Minute), nil, "192.0.2.0/24")))
+
+	events := tr.ApplyUpdate(rislivetest.Announce(twin2, dawn.Add(2*time.Minute), nil, "192.0.2.0/24"))
+	if assert.Len(events, 1) {
+		assert.Equal(VisibilityLow, events[0].Kind)
+		assert.Equal(dawn.Add(2*time.Minute), events[0].Time)
+		assert.Equal("198.51.100.0/24 seen by 1/3 peers (33.3%) on 1/2 collectors", events[0].String())
+	}
+
+	events = tr.ApplyUpdate(rislivetest.Announce(twin1, dawn.Add(3*time.Minute), nil, p))
+	if assert.Len(events, 1) {
+		assert.Equal(VisibilityRestored, events[0].Kind)
+		assert.Equal(2, events[0].Collectors)
+		assert.InDelta(100, events[0].CollectorPercent(), 0.01)
+	}
+	assert.Empty(tr.ApplyUpdate(rislivetest.Announce(twin1, dawn.Add(4*time.Minute), nil, p)))
+
+	events = tr.ApplyUpdate(rislivetest.Withdraw(solo, dawn.Add(5*time.Minute), p))
+	if assert.Len(events, 1) {
+		assert.Equal(VisibilityLow, events[0].Kind)
+	}
+	events, err := tr.Apply(rislivetest.PeerState(twin2, dawn.Add(6*time.Minute), "down"))
+	assert.NoError(err)
+	if assert.Len(events, 1) {
+		assert.Equal(VisibilityRestored, events[0].Kind)
+		assert.Equal(Visibility{Prefix: p, Peers: 1, TotalPeers: 2, Collectors: 1, TotalCollectors: 2}, events[0].Visibility)
+	}
+
+	assert.Equal([]Visibility{
+		{Prefix: p, Peers: 1, TotalPeers: 2, Collectors: 1, TotalCollectors: 2},
+		{Prefix: "2001:db8::/32"},
+	}, tr.Report())
+	_, ok = tr.Visibility("203.0.113.0/24")
+	assert.False(ok)
+}
+
+func TestLoadRIB(t *testing.T) {
+	assert := assert.New(t)
+	r := rib.New()
+	r.Insert(rib.Route{Prefix: rislivetest.MustPrefix("2001:db8::/32"), PeerKey: solo, Time: dawn})
+	r.Insert(rib.Route{Prefix: rislivetest.MustPrefix("2001:db8:1::/48"), PeerKey: twin1, Time: dawn.Add(time.Minute)})
+	r.Insert(rib.Route{Prefix: rislivetest.MustPrefix("192.0.2.0/24"), PeerKey: twin2, Time: dawn})
+
+	tr := NewTracker(80, rislivetest.MustPrefix("2001:db8::/32"), rislivetest.MustPrefix("198.51.100.0/24"))
+	tr.MinPeers = 1
+	events := tr.LoadRIB(r)
+	if assert.Len(events, 2) {
+		assert.Equal(VisibilityLow, events[0].Kind)
+		assert.Equal("198.51.100.0/24", events[0].Prefix)
+		assert.Equal(VisibilityLow, events[1].Kind)
+		assert.Equal("2001:db8::/32", events[1].Prefix)
+		assert.Equal(50.0, events[1].PeerPercent())
+		assert.Equal(dawn.Add(time.Minute), events[1].Time)
+	}
+
+	events = tr.FlushPeer(twin1)
+	if assert.Len(events, 1) {
+		assert.Equal(VisibilityRestored, events[0].Kind)
+		assert.Equal("2001:db8::/32", events[0].Prefix)
+	}
+}
+
+func TestMinPeers(t *testing.T) {
+	assert := assert.New(t)
+	const p = "198.51.100.0/24"
+	tr := NewTracker(50, rislivetest.MustPrefix(p))
+	assert.Equal(DefaultMinPeers, tr.MinPeers)
+
+	// Until enough peers are known, missing routes are not alerts.
+	for i := 1; i < DefaultMinPeers; i++ {
+		peer := rib.PeerKey{Host: "rrc00", Peer: fmt.Sprintf("192.0.2.%d", i)}
+		assert.Empty(tr.ApplyUpdate(rislivetest.Announce(peer, dawn.Add(time.Duration(i)*time.Second), nil, "192.0.2.0/24")), "peer %d", i)
+	}
+	events := tr.ApplyUpdate(rislivetest.Announce(solo, dawn.Add(time.Minute), nil, "192.0.2.0/24"))
+	if assert.Len(events, 1) {
+		assert.Equal(VisibilityLow, events[0].Kind)
+		assert.Equal(DefaultMinPeers, events[0].TotalPeers)
+	}
+	// A more specific of the prefix does not count towards it.
+	assert.Empty(tr.ApplyUpdate(rislivetest.Announce(solo, dawn.Add(2*time.Minute), nil, "198.51.100.0/25")))
+	// Below MinPeers again, the alert is withdrawn rather than left raised.
+	events, err := tr.Apply(rislivetest.PeerState(solo, dawn.Add(3*time.Minute), "down"))
+	assert.NoError(err)
+	if assert.Len(events, 1) {
+		assert.Equal(VisibilityRestored, events[0].Kind)
+		assert.Equal(DefaultMinPeers-1, events[0].TotalPeers)
+	}
+
+	// With zero, the first unrelated route already raises an alert.
+	tr = NewTracker(50, rislivetest.MustPrefix(p))
+	tr.MinPeers = 0
+	if events := tr.ApplyUpdate(rislivetest.Announce(solo, dawn, nil, "192.0.2.0/24")); assert.Len(events, 1) {
+		assert.Equal(VisibilityLow, events[0].Kind)
+	}
+}